@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/sandalsoft/promptql-tui/internal/cli"
 	"github.com/sandalsoft/promptql-tui/internal/config"
 	"github.com/sandalsoft/promptql-tui/internal/tui"
 )
@@ -15,23 +20,110 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	if err := cfg.OpenSecretStore(vaultPassphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening the secret store: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Allow overriding PAT from environment
-	if pat := os.Getenv("PROMPTQL_PAT"); pat != "" {
-		cfg.PAT = pat
+	// Select a profile with --profile, falling back to PROMPTQL_PROFILE
+	profile, args, err := cli.ProfileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if profile == "" {
+		profile = os.Getenv("PROMPTQL_PROFILE")
+	}
+	if profile != "" {
+		if err := cfg.UseProfile(profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	if apiKey := os.Getenv("PROMPTQL_API_KEY"); apiKey != "" {
-		cfg.APIKey = apiKey
+
+	if !cli.IsCommand(args) {
+		// Ask for the vault passphrase before the TUI takes over the terminal,
+		// and move any plaintext credentials into the secret store before the
+		// environment overrides are applied. Without a vault, it is only
+		// needed if there are credentials to move; the TUI asks for a new
+		// passphrase when it first saves some. Subcommands read the
+		// credentials when they need them.
+		if vault, ok := cfg.SecretStore().(*config.Vault); ok && vault.Exists() {
+			if err := vault.Unlock(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := cfg.ResolveSecrets(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading credentials: %v\n", err)
+			os.Exit(1)
+		}
+		if cfg.HasPlaintextSecrets() {
+			if err := cfg.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error moving credentials to the secret store: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
-	if ddnURL := os.Getenv("PROMPTQL_DDN_URL"); ddnURL != "" {
-		cfg.DDNURL = ddnURL
+
+	// Allow overriding credentials from the environment; they are used for
+	// this run but never saved to the config file.
+	cfg.Override(config.Profile{
+		PAT:    os.Getenv("PROMPTQL_PAT"),
+		APIKey: os.Getenv("PROMPTQL_API_KEY"),
+		DDNURL: os.Getenv("PROMPTQL_DDN_URL"),
+	})
+
+	// Subcommands run non-interactively, e.g. from scripts and CI
+	if cli.IsCommand(args) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := cli.Run(ctx, args, cfg, os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
 	}
 
 	m := tui.New(cfg)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// vaultPassphrase returns the credential vault passphrase from
+// PROMPTQL_VAULT_PASSPHRASE or, on a terminal, by prompting for it.
+func vaultPassphrase(create bool) (string, error) {
+	if pass := os.Getenv("PROMPTQL_VAULT_PASSPHRASE"); pass != "" {
+		return pass, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", errors.New("the credential vault is locked: set PROMPTQL_VAULT_PASSPHRASE")
+	}
+	if !create {
+		return readPassword("Vault passphrase: ")
+	}
+	fmt.Fprintln(os.Stderr, "Credentials are stored in an encrypted vault. Choose a passphrase to protect it.")
+	pass, err := readPassword("New vault passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readPassword("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass != confirm {
+		return "", errors.New("passphrases do not match")
+	}
+	return pass, nil
+}
+
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading passphrase: %w", err)
+	}
+	return string(pass), nil
+}
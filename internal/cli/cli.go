@@ -0,0 +1,243 @@
+// Package cli implements the non-interactive subcommands of promptql-tui,
+// for use from shell scripts and CI.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/connect"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// command is a CLI subcommand such as "threads show".
+type command struct {
+	name    string
+	args    string // positional argument synopsis
+	summary string
+	run     func(r *runner, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"profiles list", "", "List config profiles", (*runner).profilesList},
+	{"projects list", "", "List your PromptQL projects", (*runner).projectsList},
+	{"threads list", "", "List threads in a project", (*runner).threadsList},
+	{"threads show", "<thread-id>", "Show a thread's conversation", (*runner).threadsShow},
+	{"ask", "<question>", "Ask a question (new thread, --thread, or direct query)", (*runner).ask},
+	{"prompts list", "", "List sample prompts", (*runner).promptsList},
+	{"prompts create", "", "Create a sample prompt (--display, --prompt)", (*runner).promptsCreate},
+	{"prompts delete", "<prompt-id>", "Delete a sample prompt", (*runner).promptsDelete},
+	{"keys list", "", "List runtime API keys", (*runner).keysList},
+	{"keys generate", "", "Generate a runtime API key (--name)", (*runner).keysGenerate},
+	{"keys remove", "<key-id>", "Remove a runtime API key", (*runner).keysRemove},
+}
+
+// IsCommand reports whether args start with a CLI subcommand (or a request
+// for help) rather than being empty, which launches the TUI.
+func IsCommand(args []string) bool {
+	return len(args) > 0
+}
+
+// ProfileFlag removes a leading --profile flag from args, which selects a
+// config profile for the TUI and subcommands alike, and returns its value.
+func ProfileFlag(args []string) (profile string, rest []string, err error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	switch a := args[0]; {
+	case a == "--profile" || a == "-profile":
+		if len(args) < 2 || args[1] == "" {
+			return "", nil, fmt.Errorf("flag needs an argument: %s", a)
+		}
+		return args[1], args[2:], nil
+	case strings.HasPrefix(a, "--profile="), strings.HasPrefix(a, "-profile="):
+		profile = a[strings.IndexByte(a, '=')+1:]
+		if profile == "" {
+			return "", nil, fmt.Errorf("flag needs an argument: --profile")
+		}
+		return profile, args[1:], nil
+	}
+	return "", args, nil
+}
+
+// Run executes the subcommand in args, writing results to stdout and
+// diagnostics to stderr. It returns the process exit code: 0 on success,
+// 1 on failure and 2 on invalid usage.
+func Run(ctx context.Context, args []string, cfg *config.Config, stdin io.Reader, stdout, stderr io.Writer) int {
+	r := &runner{
+		cfg:    cfg,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return r.run(ctx, args)
+}
+
+// runner holds the state shared by a single CLI invocation.
+type runner struct {
+	cfg    *config.Config
+	client *sdk.Client // set by loadCredentials
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	output string // output format, set by the --output flag
+}
+
+// usageError is an error caused by invalid arguments.
+type usageError struct {
+	cmd string
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func (r *runner) run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		r.usage(r.stdout)
+		return 0
+	}
+
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(r.stderr, "Error: unknown command %q\n\n", strings.Join(args[:min(len(args), 2)], " "))
+		r.usage(r.stderr)
+		return 2
+	}
+
+	err := cmd.run(r, ctx, rest)
+	var uerr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &uerr):
+		fmt.Fprintf(r.stderr, "Error: %s\n", uerr.msg)
+		fmt.Fprintf(r.stderr, "Run 'promptql-tui %s --help' for usage.\n", uerr.cmd)
+		return 2
+	default:
+		fmt.Fprintf(r.stderr, "Error: %v\n", err)
+		return 1
+	}
+}
+
+// findCommand returns the command named by the leading words of args and
+// the remaining arguments.
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func (r *runner) usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  promptql-tui [--profile name]     Launch the interactive TUI")
+	fmt.Fprintln(w, "  promptql-tui [--profile name] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-32s  %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --profile name                    Config profile to use (or set PROMPTQL_PROFILE)")
+	fmt.Fprintln(w, "  -o, --output table|json|yaml      Output format (default table)")
+}
+
+// flags returns a flag set for the named command with the common flags
+// registered.
+func (r *runner) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(r.stderr)
+	fs.StringVar(&r.output, "output", formatTable, "output format: table, json or yaml")
+	fs.StringVar(&r.output, "o", formatTable, "shorthand for --output")
+	return fs
+}
+
+// parse parses flags wherever they appear in args and checks that exactly
+// nargs positional arguments remain, which it returns.
+func (r *runner) parse(fs *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	var positional, tail []string
+	for i, a := range args {
+		if a == "--" {
+			args, tail = args[:i], args[i+1:]
+			break
+		}
+	}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{cmd: fs.Name(), msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	positional = append(positional, tail...)
+
+	switch r.output {
+	case formatTable, formatJSON, formatYAML:
+	default:
+		return nil, &usageError{cmd: fs.Name(), msg: fmt.Sprintf("invalid output format %q (use table, json or yaml)", r.output)}
+	}
+	if len(positional) != nargs {
+		return nil, &usageError{cmd: fs.Name(), msg: fmt.Sprintf("expected %d argument(s), got %d", nargs, len(positional))}
+	}
+	return positional, nil
+}
+
+// projectID returns the --project flag value, falling back to the project
+// saved in the config.
+func (r *runner) projectID(fs *flag.FlagSet, flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if r.cfg.ProjectID != "" {
+		return r.cfg.ProjectID, nil
+	}
+	return "", &usageError{cmd: fs.Name(), msg: "no project selected: pass --project or select one in the TUI"}
+}
+
+// loadCredentials reads the credentials from the secret store and builds
+// the client. Commands call it once their arguments are parsed, so neither
+// "profiles list" nor --help unlocks the vault.
+func (r *runner) loadCredentials() error {
+	if r.client != nil {
+		return nil
+	}
+	if err := r.cfg.ResolveSecrets(); err != nil {
+		return fmt.Errorf("loading credentials: %w", err)
+	}
+	r.client = connect.NewClient(r.cfg)
+	return nil
+}
+
+// requirePAT loads the credentials and fails if no personal access token
+// is configured.
+func (r *runner) requirePAT() error {
+	if err := r.loadCredentials(); err != nil {
+		return err
+	}
+	if r.cfg.PAT == "" {
+		return fmt.Errorf("a personal access token is required: set PROMPTQL_PAT or run the TUI setup")
+	}
+	return nil
+}
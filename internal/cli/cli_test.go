@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// newTestRunner returns a runner whose client answers every request with fn.
+func newTestRunner(cfg *config.Config, fn roundTripFunc) (*runner, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	client := sdk.NewClient(sdk.ClientOptions{
+		PAT:             cfg.PAT,
+		APIKey:          cfg.APIKey,
+		BaseURL:         "https://test.example.com",
+		APIURL:          "https://api.test.example.com",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient:      &http.Client{Transport: fn},
+	})
+	r := &runner{cfg: cfg, client: client, stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+	return r, &stdout, &stderr
+}
+
+func TestProjectsList_Formats(t *testing.T) {
+	fn := func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(200, `{"data":{"ddn_projects":[{"id":"p-1","name":"sales","ddn_builds":[{"fqdn":"sales.ddn.example.com"}]}]}}`), nil
+	}
+
+	r, stdout, stderr := newTestRunner(&config.Config{PAT: "pat"}, fn)
+	if code := r.run(context.Background(), []string{"projects", "list"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[1], "sales") {
+		t.Errorf("unexpected table output:\n%s", stdout)
+	}
+
+	r, stdout, _ = newTestRunner(&config.Config{PAT: "pat"}, fn)
+	if code := r.run(context.Background(), []string{"projects", "list", "--output", "json"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	var projects []sdk.UserProject
+	if err := json.Unmarshal(stdout.Bytes(), &projects); err != nil || len(projects) != 1 || projects[0].DDNProjectID != "p-1" {
+		t.Errorf("unexpected json output %q (%v)", stdout, err)
+	}
+
+	r, stdout, _ = newTestRunner(&config.Config{PAT: "pat"}, fn)
+	if code := r.run(context.Background(), []string{"projects", "list", "-o", "yaml"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	want := "- buildFqdn: sales.ddn.example.com\n  ddnProjectId: p-1\n  name: sales\n  projectId: \"\"\n"
+	if stdout.String() != want {
+		t.Errorf("unexpected yaml output:\nwant %q\ngot  %q", want, stdout)
+	}
+}
+
+func TestThreadsList_FlagsAfterArgsAndProjectDefault(t *testing.T) {
+	var vars map[string]interface{}
+	fn := func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		vars = body.Variables
+		return jsonResponse(200, `{"data":{"getThreads":[]}}`), nil
+	}
+
+	r, _, stderr := newTestRunner(&config.Config{PAT: "pat", ProjectID: "saved"}, fn)
+	if code := r.run(context.Background(), []string{"threads", "list", "-o", "json"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if vars["projectId"] != "saved" {
+		t.Errorf("expected saved project ID, got %v", vars["projectId"])
+	}
+
+	r, _, _ = newTestRunner(&config.Config{PAT: "pat", ProjectID: "saved"}, fn)
+	if code := r.run(context.Background(), []string{"threads", "list", "--project", "other"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if vars["projectId"] != "other" {
+		t.Errorf("expected --project to override, got %v", vars["projectId"])
+	}
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	fn := func(req *http.Request) (*http.Response, error) {
+		t.Fatal("no request expected")
+		return nil, nil
+	}
+	cases := [][]string{
+		{"bogus"},
+		{"threads", "show"},
+		{"keys", "remove", "abc", "--project", "p"},
+		{"prompts", "create", "--project", "p"},
+		{"projects", "list", "-o", "xml"},
+	}
+	for _, args := range cases {
+		r, _, stderr := newTestRunner(&config.Config{PAT: "pat"}, fn)
+		if code := r.run(context.Background(), args); code != 2 {
+			t.Errorf("%v: expected exit 2, got %d (%s)", args, code, stderr)
+		}
+	}
+}
+
+func TestAsk_DirectQuery(t *testing.T) {
+	fn := func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(200, `{"assistant_actions":[{"message":"There are 42 users."}]}`), nil
+	}
+	cfg := &config.Config{APIKey: "key", DDNURL: "https://ddn.example.com/graphql"}
+	r, stdout, stderr := newTestRunner(cfg, fn)
+	if code := r.run(context.Background(), []string{"ask", "how many users?"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout.String()) != "There are 42 users." {
+		t.Errorf("unexpected answer: %q", stdout)
+	}
+}
+
+func TestAsk_DirectQueryWithPAT(t *testing.T) {
+	var ddn map[string]interface{}
+	fn := func(req *http.Request) (*http.Response, error) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/ddn/promptql/token"):
+			return jsonResponse(200, `{"token":"ddn-token","expiry":"300"}`), nil
+		case strings.HasSuffix(req.URL.Path, "/query"):
+			var body struct {
+				DDN map[string]interface{} `json:"ddn"`
+			}
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			ddn = body.DDN
+			return jsonResponse(200, `{"assistant_actions":[{"message":"There are 42 users."}]}`), nil
+		}
+		return jsonResponse(200, `{"data":{"lookupProject":{"buildFqdn":"sales.ddn.example.com","projectId":"p-1"}}}`), nil
+	}
+	r, stdout, stderr := newTestRunner(&config.Config{PAT: "pat"}, fn)
+	if code := r.run(context.Background(), []string{"ask", "--direct", "--project", "p-1", "how many users?"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout.String()) != "There are 42 users." {
+		t.Errorf("unexpected answer: %q", stdout)
+	}
+	if ddn["url"] != "https://sales.ddn.example.com/graphql" {
+		t.Errorf("expected the project's build URL, got %v", ddn["url"])
+	}
+	if headers, _ := ddn["headers"].(map[string]interface{}); headers["Authorization"] != "Bearer ddn-token" {
+		t.Errorf("expected the DDN token in the DDN headers, got %v", ddn["headers"])
+	}
+}
+
+func TestAsk_APIErrorExitsOne(t *testing.T) {
+	fn := func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(401, `{"message":"invalid api key"}`), nil
+	}
+	cfg := &config.Config{APIKey: "key", DDNURL: "https://ddn.example.com/graphql"}
+	r, _, stderr := newTestRunner(cfg, fn)
+	if code := r.run(context.Background(), []string{"ask", "hi"}); code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "invalid api key") {
+		t.Errorf("expected API error on stderr, got %q", stderr)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	v := map[string]interface{}{
+		"empty":  []string{},
+		"nested": []interface{}{map[string]interface{}{"a": 1, "b": []int{1, 2}}, "x"},
+		"quoted": "yes",
+		"text":   "line one\nline two",
+		"zero":   nil,
+	}
+	var b strings.Builder
+	if err := writeYAML(&b, v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `empty: []
+nested:
+  - a: 1
+    b:
+      - 1
+      - 2
+  - x
+quoted: "yes"
+text: "line one\nline two"
+zero: null
+`
+	if b.String() != want {
+		t.Errorf("unexpected yaml:\nwant:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	cases := []struct {
+		args    []string
+		profile string
+		rest    []string
+	}{
+		{nil, "", nil},
+		{[]string{"threads", "list", "--profile", "x"}, "", []string{"threads", "list", "--profile", "x"}},
+		{[]string{"--profile", "staging", "projects", "list"}, "staging", []string{"projects", "list"}},
+		{[]string{"--profile=dev"}, "dev", []string{}},
+	}
+	for _, c := range cases {
+		profile, rest, err := ProfileFlag(c.args)
+		if err != nil || profile != c.profile || strings.Join(rest, " ") != strings.Join(c.rest, " ") {
+			t.Errorf("%v: got %q %v (%v)", c.args, profile, rest, err)
+		}
+	}
+	if _, _, err := ProfileFlag([]string{"--profile"}); err == nil {
+		t.Error("expected a missing profile name to be rejected")
+	}
+}
+
+func TestProfilesList(t *testing.T) {
+	cfg := &config.Config{PAT: "pat"}
+	if err := cfg.AddProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	r, stdout, _ := newTestRunner(cfg, nil)
+	if code := r.run(context.Background(), []string{"profiles", "list", "-o", "json"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	var got []profileInfo
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "default" || got[0].Active || got[1].Name != "staging" || !got[1].Active {
+		t.Errorf("unexpected profiles %+v", got)
+	}
+}
@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// --- Profiles ---
+
+// profileInfo is the json/yaml output of "profiles list".
+type profileInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+func (r *runner) profilesList(ctx context.Context, args []string) error {
+	fs := r.flags("profiles list")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	names := r.cfg.ProfileNames()
+	profiles := make([]profileInfo, len(names))
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		profiles[i] = profileInfo{Name: name, Active: name == r.cfg.Name()}
+		active := ""
+		if profiles[i].Active {
+			active = "*"
+		}
+		rows[i] = []string{name, active}
+	}
+	return r.print(profiles, []string{"NAME", "ACTIVE"}, rows)
+}
+
+// --- Projects ---
+
+func (r *runner) projectsList(ctx context.Context, args []string) error {
+	fs := r.flags("projects list")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	projects, err := r.client.Projects().ListUserProjectsContext(ctx)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(projects))
+	for i, p := range projects {
+		rows[i] = []string{p.Name, p.DDNProjectID, p.BuildFQDN}
+	}
+	return r.print(projects, []string{"NAME", "DDN PROJECT ID", "BUILD FQDN"}, rows)
+}
+
+// --- Threads ---
+
+func (r *runner) threadsList(ctx context.Context, args []string) error {
+	fs := r.flags("threads list")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	threads, err := r.client.Threads().ListContext(ctx, projectID, "")
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(threads))
+	for i, t := range threads {
+		rows[i] = []string{t.ThreadID, t.Title, t.Visibility, t.UpdatedAt}
+	}
+	return r.print(threads, []string{"THREAD ID", "TITLE", "VISIBILITY", "UPDATED"}, rows)
+}
+
+// threadDetail is the json/yaml output of "threads show".
+type threadDetail struct {
+	Thread *sdk.Thread       `json:"thread"`
+	Events []sdk.ThreadEvent `json:"events"`
+}
+
+func (r *runner) threadsShow(ctx context.Context, args []string) error {
+	fs := r.flags("threads show")
+	pos, err := r.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	thread, err := r.client.Threads().GetContext(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	threadEvents, err := r.client.Threads().GetEventsContext(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if r.output != formatTable {
+		return r.print(threadDetail{Thread: thread, Events: threadEvents}, nil, nil)
+	}
+	events := make([]sdk.Event, len(threadEvents))
+	for i, e := range threadEvents {
+		events[i] = e.Event()
+	}
+	return sdk.WriteTranscript(r.stdout, thread.Title, events)
+}
+
+// --- Ask ---
+
+// askResult is the json/yaml output of "ask".
+type askResult struct {
+	ThreadID string             `json:"thread_id,omitempty"`
+	Title    string             `json:"title,omitempty"`
+	Answer   string             `json:"answer"`
+	Events   []sdk.ThreadEvent  `json:"events,omitempty"`
+	Response *sdk.QueryResponse `json:"response,omitempty"`
+}
+
+func (r *runner) ask(ctx context.Context, args []string) error {
+	fs := r.flags("ask")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	threadID := fs.String("thread", "", "continue an existing thread instead of starting one")
+	direct := fs.Bool("direct", false, "use the query endpoint (API key + DDN URL, or PAT) even if a PAT is set")
+	pos, err := r.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	question := pos[0]
+	if err := r.loadCredentials(); err != nil {
+		return err
+	}
+
+	if *direct || r.cfg.PAT == "" {
+		opts := sdk.ExecuteOptions{
+			Interactions: []sdk.Interaction{sdk.NewUserInteraction(question)},
+			DDNURL:       r.cfg.DDNURL,
+			Timezone:     r.cfg.Timezone,
+		}
+		switch {
+		case r.cfg.APIKey != "":
+			if opts.DDNURL == "" {
+				return fmt.Errorf("direct queries with an API key need a DDN URL: set PROMPTQL_DDN_URL")
+			}
+		case r.cfg.PAT != "":
+			// The SDK exchanges the PAT for a DDN token; the DDN URL
+			// defaults to the project's build.
+			if opts.ProjectID, err = r.projectID(fs, *project); err != nil {
+				return err
+			}
+			if opts.DDNURL == "" {
+				fqdn := r.buildFQDN(ctx, opts.ProjectID)
+				if fqdn == "" {
+					return fmt.Errorf("could not determine the build of project %s: set PROMPTQL_DDN_URL", opts.ProjectID)
+				}
+				opts.DDNURL = sdk.DDNURLForBuild(fqdn)
+			}
+		default:
+			return fmt.Errorf("direct queries need an API key and DDN URL, or a PAT: set PROMPTQL_API_KEY and PROMPTQL_DDN_URL, or PROMPTQL_PAT")
+		}
+		resp, err := r.client.Query().ExecuteContext(ctx, opts)
+		if err != nil {
+			return err
+		}
+		answer := answerText(resp.Events())
+		return r.printText(askResult{Answer: answer, Response: resp}, answer)
+	}
+
+	if *threadID != "" {
+		result, err := r.client.Threads().SendMessageContext(ctx, sdk.SendMessageOptions{
+			ThreadID:  *threadID,
+			Message:   question,
+			BuildFQDN: r.buildFQDN(ctx, ""),
+			Timezone:  r.cfg.Timezone,
+		})
+		if err != nil {
+			return err
+		}
+		answer := answerText([]sdk.Event{result.Event()})
+		event := sdk.ThreadEvent{ThreadEventID: result.ThreadEventID, ThreadID: *threadID, EventData: result.EventData}
+		return r.printText(askResult{ThreadID: *threadID, Answer: answer, Events: []sdk.ThreadEvent{event}}, answer)
+	}
+
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	result, err := r.client.Threads().StartContext(ctx, sdk.StartOptions{
+		ProjectID: projectID,
+		Message:   question,
+		BuildFQDN: r.buildFQDN(ctx, projectID),
+		Timezone:  r.cfg.Timezone,
+	})
+	if err != nil {
+		return err
+	}
+	events := make([]sdk.Event, len(result.ThreadEvents))
+	for i, e := range result.ThreadEvents {
+		events[i] = e.Event()
+	}
+	answer := answerText(events)
+	return r.printText(askResult{ThreadID: result.ThreadID, Title: result.Title, Answer: answer, Events: result.ThreadEvents}, answer)
+}
+
+// buildFQDN looks up the project's build FQDN, returning "" if it cannot
+// be determined so the server default applies.
+func (r *runner) buildFQDN(ctx context.Context, projectID string) string {
+	if projectID == "" {
+		projectID = r.cfg.ProjectID
+	}
+	if projectID == "" {
+		return ""
+	}
+	result, err := r.client.Projects().LookupContext(ctx, sdk.LookupOptions{ProjectID: projectID})
+	if err != nil {
+		return ""
+	}
+	return result.BuildFQDN
+}
+
+// answerText renders the assistant's side of events as plain text.
+func answerText(events []sdk.Event) string {
+	var parts []string
+	for _, evt := range events {
+		switch e := evt.(type) {
+		case *sdk.AssistantMessageEvent:
+			parts = append(parts, e.Text)
+		case *sdk.PlanStepEvent:
+			parts = append(parts, "Plan: "+e.Plan)
+		case *sdk.CodeExecutionEvent:
+			if e.Code != "" {
+				parts = append(parts, "```\n"+strings.TrimRight(e.Code, "\n")+"\n```")
+			}
+			if e.Output != "" {
+				parts = append(parts, "Output:\n"+strings.TrimRight(e.Output, "\n"))
+			}
+			if e.Error != "" {
+				parts = append(parts, "Error:\n"+strings.TrimRight(e.Error, "\n"))
+			}
+		case *sdk.ArtifactCreatedEvent:
+			var b strings.Builder
+			if err := sdk.WriteArtifact(&b, e.Artifact, sdk.ExportMarkdown); err == nil {
+				parts = append(parts, strings.TrimRight(b.String(), "\n"))
+			}
+		case *sdk.ErrorEvent:
+			parts = append(parts, "Error: "+e.Message)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// --- Prompts ---
+
+func (r *runner) promptsList(ctx context.Context, args []string) error {
+	fs := r.flags("prompts list")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	prompts, err := r.client.Prompts().ListContext(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(prompts))
+	for i, p := range prompts {
+		rows[i] = []string{p.ID, p.DisplayText, p.UpdatedAt}
+	}
+	return r.print(prompts, []string{"ID", "DISPLAY TEXT", "UPDATED"}, rows)
+}
+
+func (r *runner) promptsCreate(ctx context.Context, args []string) error {
+	fs := r.flags("prompts create")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	display := fs.String("display", "", "text shown in the prompt list (required)")
+	full := fs.String("prompt", "", "full prompt text, or - to read it from stdin (required)")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *display == "" || *full == "" {
+		return &usageError{cmd: fs.Name(), msg: "--display and --prompt are required"}
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	fullPrompt := *full
+	if fullPrompt == "-" {
+		data, err := io.ReadAll(r.stdin)
+		if err != nil {
+			return fmt.Errorf("reading prompt from stdin: %w", err)
+		}
+		fullPrompt = strings.TrimRight(string(data), "\n")
+	}
+	prompt, err := r.client.Prompts().CreateContext(ctx, projectID, *display, fullPrompt)
+	if err != nil {
+		return err
+	}
+	return r.print(prompt, []string{"ID", "DISPLAY TEXT"}, [][]string{{prompt.ID, prompt.DisplayText}})
+}
+
+func (r *runner) promptsDelete(ctx context.Context, args []string) error {
+	fs := r.flags("prompts delete")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	pos, err := r.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	result, err := r.client.Prompts().DeleteContext(ctx, projectID, pos[0])
+	if err != nil {
+		return err
+	}
+	return r.printText(result, result.Message)
+}
+
+// --- API Keys ---
+
+func (r *runner) keysList(ctx context.Context, args []string) error {
+	fs := r.flags("keys list")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	keys, err := r.client.APIKeys().ListContext(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(keys))
+	for i, k := range keys {
+		active := ""
+		if k.IsActive != nil {
+			active = strconv.FormatBool(*k.IsActive)
+		}
+		rows[i] = []string{strconv.Itoa(k.ID), k.Name, k.APIKeyMasked, active, k.LastUsedAt}
+	}
+	return r.print(keys, []string{"ID", "NAME", "KEY", "ACTIVE", "LAST USED"}, rows)
+}
+
+func (r *runner) keysGenerate(ctx context.Context, args []string) error {
+	fs := r.flags("keys generate")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	name := fs.String("name", "", "key name (required)")
+	promptqlTimeout := fs.Int("promptql-timeout", 0, "PromptQL timeout in seconds (0 for the server default)")
+	sqlTimeout := fs.Int("sql-timeout", 0, "SQL timeout in seconds (0 for the server default)")
+	if _, err := r.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return &usageError{cmd: fs.Name(), msg: "--name is required"}
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	opts := sdk.GenerateOptions{ProjectID: projectID, Name: *name}
+	if *promptqlTimeout > 0 {
+		opts.PromptQLTimeout = promptqlTimeout
+	}
+	if *sqlTimeout > 0 {
+		opts.SQLTimeout = sqlTimeout
+	}
+	key, err := r.client.APIKeys().GenerateContext(ctx, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.stderr, "Store this key now; it will not be shown again.")
+	return r.print(key, []string{"ID", "NAME", "API KEY"}, [][]string{{strconv.Itoa(key.ID), key.Name, key.APIKey}})
+}
+
+func (r *runner) keysRemove(ctx context.Context, args []string) error {
+	fs := r.flags("keys remove")
+	project := fs.String("project", "", "project ID (defaults to the selected project)")
+	pos, err := r.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(pos[0])
+	if err != nil {
+		return &usageError{cmd: fs.Name(), msg: fmt.Sprintf("invalid key ID %q", pos[0])}
+	}
+	projectID, err := r.projectID(fs, *project)
+	if err != nil {
+		return err
+	}
+	if err := r.requirePAT(); err != nil {
+		return err
+	}
+	result, err := r.client.APIKeys().RemoveContext(ctx, projectID, id)
+	if err != nil {
+		return err
+	}
+	return r.printText(result, result.Message)
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// print writes v in the selected output format. For table output, headers
+// and rows are written as aligned columns instead.
+func (r *runner) print(v interface{}, headers []string, rows [][]string) error {
+	switch r.output {
+	case formatJSON:
+		enc := json.NewEncoder(r.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		return writeYAML(r.stdout, v)
+	}
+	return writeTable(r.stdout, headers, rows)
+}
+
+// printText writes text for table output, and v otherwise.
+func (r *runner) printText(v interface{}, text string) error {
+	if r.output != formatTable {
+		return r.print(v, nil, nil)
+	}
+	_, err := fmt.Fprintln(r.stdout, strings.TrimRight(text, "\n"))
+	return err
+}
+
+func writeTable(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(c)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeYAML writes v as YAML. v is first encoded as JSON so struct tags
+// and custom marshalers apply, and object keys keep their JSON order.
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeYAMLNode(dec)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	emitYAML(&b, node, 0)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// yamlMap is a JSON object with its keys in document order.
+type yamlMap struct {
+	keys   []string
+	values []interface{}
+}
+
+func decodeYAMLNode(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := &yamlMap{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, keyTok.(string))
+			m.values = append(m.values, value)
+		}
+		_, err := dec.Token() // '}'
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token() // ']'
+		return list, err
+	}
+	return tok, nil
+}
+
+func emitYAML(b *strings.Builder, node interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch n := node.(type) {
+	case *yamlMap:
+		if len(n.keys) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+		for i, k := range n.keys {
+			b.WriteString(pad + yamlScalar(k) + ":")
+			emitYAMLValue(b, n.values[i], indent+2)
+		}
+	case []interface{}:
+		if len(n) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range n {
+			// Render the item one level deeper, then hang its first line
+			// off the "- " marker.
+			var sub strings.Builder
+			emitYAML(&sub, item, indent+2)
+			b.WriteString(pad + "- " + sub.String()[indent+2:])
+		}
+	default:
+		b.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+// emitYAMLValue writes a mapping value after its "key:".
+func emitYAMLValue(b *strings.Builder, value interface{}, indent int) {
+	switch v := value.(type) {
+	case *yamlMap:
+		if len(v.keys) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	b.WriteString("\n")
+	emitYAML(b, value, indent)
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlNeedsQuotes(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlNeedsQuotes reports whether s would be misread as a plain scalar.
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxComposerHistory bounds the number of sent messages remembered per
+// project.
+const maxComposerHistory = 200
+
+// directQueryComposer names the composer state used without a project, in
+// direct query mode.
+const directQueryComposer = "_direct"
+
+// Composer is the chat input state kept per project: the messages sent,
+// oldest first, for recall, and the unsent drafts by thread. It is stored
+// under ~/.config/promptql-tui/composer/.
+type Composer struct {
+	ProjectID string            `json:"project_id"`
+	History   []string          `json:"history,omitempty"`
+	Drafts    map[string]string `json:"drafts,omitempty"`
+}
+
+func composerPath(projectID string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	name := directQueryComposer
+	if projectID != "" {
+		name = safeFileName(projectID)
+	}
+	return filepath.Join(dir, "composer", name+".json"), nil
+}
+
+// LoadComposer reads a project's composer state, returning an empty one if
+// none has been saved. An empty projectID is the direct query composer.
+func LoadComposer(projectID string) (*Composer, error) {
+	c := &Composer{ProjectID: projectID}
+	path, err := composerPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("reading composer state: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing composer state: %w", err)
+	}
+	return c, nil
+}
+
+// AddHistory records a sent message, unless it repeats the last one.
+func (c *Composer) AddHistory(text string) {
+	if n := len(c.History); n > 0 && c.History[n-1] == text {
+		return
+	}
+	c.History = append(c.History, text)
+	if n := len(c.History); n > maxComposerHistory {
+		c.History = c.History[n-maxComposerHistory:]
+	}
+}
+
+// SetDraft stores the unsent text for a thread, or removes it if empty.
+func (c *Composer) SetDraft(key, text string) {
+	if text == "" {
+		delete(c.Drafts, key)
+		return
+	}
+	if c.Drafts == nil {
+		c.Drafts = map[string]string{}
+	}
+	c.Drafts[key] = text
+}
+
+// Save writes the composer state to disk.
+func (c *Composer) Save() error {
+	path, err := composerPath(c.ProjectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating composer directory: %w", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling composer state: %w", err)
+	}
+	// Write to a temporary file first so a failed write cannot lose the
+	// history.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing composer state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing composer state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComposer_HistoryAndDraftsReload(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	c, err := LoadComposer("proj-1")
+	if err != nil {
+		t.Fatalf("LoadComposer() error: %v", err)
+	}
+	if len(c.History) != 0 || len(c.Drafts) != 0 {
+		t.Fatalf("expected an empty composer, got %+v", c)
+	}
+
+	c.AddHistory("first question")
+	c.AddHistory("second question")
+	c.AddHistory("second question")
+	c.SetDraft("thread-1", "half written")
+	c.SetDraft("thread-2", "dropped")
+	c.SetDraft("thread-2", "")
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmp, ".config", "promptql-tui", "composer", "proj-1.json")); err != nil {
+		t.Errorf("expected composer file inside the composer directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".config", "promptql-tui", "composer", "proj-1.json.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed, got %v", err)
+	}
+
+	loaded, err := LoadComposer("proj-1")
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if len(loaded.History) != 2 || loaded.History[1] != "second question" {
+		t.Errorf("expected repeated message to be recorded once, got %q", loaded.History)
+	}
+	if len(loaded.Drafts) != 1 || loaded.Drafts["thread-1"] != "half written" {
+		t.Errorf("unexpected drafts %q", loaded.Drafts)
+	}
+
+	direct, err := LoadComposer("")
+	if err != nil {
+		t.Fatalf("LoadComposer(\"\") error: %v", err)
+	}
+	if len(direct.History) != 0 {
+		t.Errorf("expected direct query history to be kept apart, got %q", direct.History)
+	}
+}
+
+func TestComposer_HistoryIsBounded(t *testing.T) {
+	c := &Composer{}
+	for i := range maxComposerHistory + 5 {
+		c.AddHistory(string(rune('a' + i%26)))
+	}
+	if len(c.History) != maxComposerHistory {
+		t.Fatalf("expected %d messages, got %d", maxComposerHistory, len(c.History))
+	}
+}
@@ -5,15 +5,81 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-// Config holds the persisted configuration for the TUI.
+// DefaultProfile is the name of the profile stored at the top level of the
+// config file, where configs written before profiles existed keep their
+// credentials.
+const DefaultProfile = "default"
+
+// Keys that can send a chat message; see Config.SendKey.
+const (
+	SendKeyEnter     = "enter"
+	SendKeyCtrlEnter = "ctrl+enter"
+)
+
+// Profile holds the credentials and endpoint overrides for one org or
+// environment. Empty endpoints use the SDK defaults.
+type Profile struct {
+	PAT             string `json:"pat"`
+	APIKey          string `json:"api_key,omitempty"`
+	ProjectID       string `json:"project_id,omitempty"`
+	DDNURL          string `json:"ddn_url,omitempty"`
+	Timezone        string `json:"timezone,omitempty"`
+	BaseURL         string `json:"base_url,omitempty"`
+	APIURL          string `json:"api_url,omitempty"`
+	AuthURL         string `json:"auth_url,omitempty"`
+	ControlPlaneURL string `json:"control_plane_url,omitempty"`
+}
+
+// Config holds the persisted configuration for the TUI. Its fields are the
+// settings of the active profile; Save stores changes to them back under
+// that profile's name.
 type Config struct {
-	PAT       string `json:"pat"`
-	APIKey    string `json:"api_key,omitempty"`
-	ProjectID string `json:"project_id,omitempty"`
-	DDNURL    string `json:"ddn_url,omitempty"`
-	Timezone  string `json:"timezone,omitempty"`
+	PAT             string
+	APIKey          string
+	ProjectID       string
+	DDNURL          string
+	Timezone        string
+	BaseURL         string
+	APIURL          string
+	AuthURL         string
+	ControlPlaneURL string
+
+	// ProfileName is the active profile. Empty means DefaultProfile.
+	ProfileName string
+	// Profiles holds every profile by name. The entry for the active
+	// profile is only brought up to date on Save and UseProfile.
+	Profiles map[string]Profile
+
+	// Secrets selects where credentials are stored; see OpenSecretStore.
+	Secrets SecretSettings
+
+	// SendKey is the key that sends a chat message, SendKeyEnter or
+	// SendKeyCtrlEnter; the other one inserts a newline. Empty means
+	// SendKeyCtrlEnter.
+	SendKey string
+
+	// CacheTokens keeps the DDN tokens obtained with a PAT on disk, so
+	// subcommands run in a row share them. They are stored unencrypted,
+	// so it is off by default.
+	CacheTokens bool
+
+	overrides Profile // settings for this run only; see Override
+	store     SecretStore
+	plaintext bool // the file holds credentials outside the secret store
+}
+
+// configFile is the on-disk layout: the default profile at the top level
+// and any others under "profiles".
+type configFile struct {
+	Profile
+	Profiles    map[string]Profile `json:"profiles,omitempty"`
+	Secrets     *SecretSettings    `json:"secrets,omitempty"`
+	SendKey     string             `json:"send_key,omitempty"`
+	CacheTokens bool               `json:"cache_tokens,omitempty"`
 }
 
 func configDir() (string, error) {
@@ -32,6 +98,37 @@ func configPath() (string, error) {
 	return filepath.Join(dir, "config.json"), nil
 }
 
+// SearchIndexPath returns where the full-text index of a project's threads
+// is kept, under ~/.config/promptql-tui/search/.
+func SearchIndexPath(projectID string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "search", safeFileName(projectID)+".json"), nil
+}
+
+// TokenCacheDir returns where DDN tokens are cached, under
+// ~/.config/promptql-tui/tokens/.
+func TokenCacheDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tokens"), nil
+}
+
+// ResponseCacheDir returns where query responses are cached for offline
+// use: promptql-tui/responses/ in the user's cache directory, e.g.
+// ~/.cache on Linux.
+func ResponseCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("getting cache directory: %w", err)
+	}
+	return filepath.Join(dir, "promptql-tui", "responses"), nil
+}
+
 // Load reads the config from disk, returning a zero-value Config if none exists.
 func Load() (*Config, error) {
 	path, err := configPath()
@@ -64,17 +161,215 @@ func (c *Config) Save() error {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
 
+	f := c.file()
+	if c.store != nil {
+		if err := c.storeSecrets(DefaultProfile, &f.Profile); err != nil {
+			return err
+		}
+		for name, p := range f.Profiles {
+			if err := c.storeSecrets(name, &p); err != nil {
+				return err
+			}
+			f.Profiles[name] = p
+		}
+	}
+
 	path := filepath.Join(dir, "config.json")
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	// Write to a temporary file first so a failed write cannot lose the
+	// profiles.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
 		return fmt.Errorf("writing config: %w", err)
 	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	c.plaintext = c.store == nil
+	return nil
+}
+
+// MarshalJSON encodes the config in the config file layout, with
+// credentials in plaintext.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.file())
+}
+
+func (c *Config) file() configFile {
+	profiles := c.profiles()
+	f := configFile{Profile: profiles[DefaultProfile], SendKey: c.SendKey, CacheTokens: c.CacheTokens}
+	delete(profiles, DefaultProfile)
+	if len(profiles) > 0 {
+		f.Profiles = profiles
+	}
+	if c.Secrets != (SecretSettings{}) {
+		secrets := c.Secrets
+		f.Secrets = &secrets
+	}
+	return f
+}
+
+// UnmarshalJSON decodes the config file layout, activating the default profile.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var f configFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if _, ok := f.Profiles[DefaultProfile]; ok {
+		return fmt.Errorf("profile %q is reserved for the top-level settings", DefaultProfile)
+	}
+	switch f.SendKey {
+	case "", SendKeyEnter, SendKeyCtrlEnter:
+	default:
+		return fmt.Errorf("send_key %q is not %q or %q", f.SendKey, SendKeyEnter, SendKeyCtrlEnter)
+	}
+	*c = Config{Profiles: map[string]Profile{DefaultProfile: f.Profile}, SendKey: f.SendKey, CacheTokens: f.CacheTokens}
+	for name, p := range f.Profiles {
+		c.Profiles[name] = p
+	}
+	if f.Secrets != nil {
+		c.Secrets = *f.Secrets
+	}
+	for _, p := range c.Profiles {
+		for _, field := range secretFields(&p) {
+			if *field.value != "" && !strings.HasPrefix(*field.value, secretRefPrefix) {
+				c.plaintext = true
+			}
+		}
+	}
+	c.setActive(f.Profile)
+	return nil
+}
+
+// Name returns the name of the active profile.
+func (c *Config) Name() string {
+	if c.ProfileName == "" {
+		return DefaultProfile
+	}
+	return c.ProfileName
+}
+
+// ProfileNames returns the names of all profiles, the default first and
+// the rest sorted.
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.profiles() {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// UseProfile makes the named profile active, keeping any changes made to
+// the previously active one. Overrides do not carry over to it.
+func (c *Config) UseProfile(name string) error {
+	profiles := c.profiles()
+	p, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.Profiles = profiles
+	c.ProfileName = name
+	c.overrides = Profile{}
+	c.setActive(p)
 	return nil
 }
 
+// Override applies settings given for this run only, such as those from
+// environment variables, over the active profile's. Save keeps the
+// profile's own values for them unless they are changed afterwards.
+func (c *Config) Override(p Profile) {
+	c.Profiles = c.profiles()
+	c.overrides = p
+	c.applyOverrides()
+}
+
+func (c *Config) applyOverrides() {
+	p := c.active()
+	env := c.overrides.fields()
+	for i, f := range p.fields() {
+		if *env[i] != "" {
+			*f = *env[i]
+		}
+	}
+	c.setActive(p)
+}
+
+// AddProfile adds an empty profile with the given name.
+func (c *Config) AddProfile(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	profiles := c.profiles()
+	if _, ok := profiles[name]; ok {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	profiles[name] = Profile{}
+	c.Profiles = profiles
+	return nil
+}
+
+// profiles returns a copy of Profiles with the active profile's entry
+// brought up to date.
+func (c *Config) profiles() map[string]Profile {
+	profiles := make(map[string]Profile, len(c.Profiles)+1)
+	for name, p := range c.Profiles {
+		profiles[name] = p
+	}
+	profiles[c.Name()] = c.active()
+	if _, ok := profiles[DefaultProfile]; !ok {
+		profiles[DefaultProfile] = Profile{}
+	}
+	return profiles
+}
+
+// active returns the settings of the active profile, with the profile's
+// own values in place of overrides that are still in effect.
+func (c *Config) active() Profile {
+	p := Profile{
+		PAT:             c.PAT,
+		APIKey:          c.APIKey,
+		ProjectID:       c.ProjectID,
+		DDNURL:          c.DDNURL,
+		Timezone:        c.Timezone,
+		BaseURL:         c.BaseURL,
+		APIURL:          c.APIURL,
+		AuthURL:         c.AuthURL,
+		ControlPlaneURL: c.ControlPlaneURL,
+	}
+	own := c.Profiles[c.Name()]
+	env, saved := c.overrides.fields(), own.fields()
+	for i, f := range p.fields() {
+		if *env[i] != "" && *f == *env[i] {
+			*f = *saved[i]
+		}
+	}
+	return p
+}
+
+// fields returns pointers to the settings of a profile, in a fixed order.
+func (p *Profile) fields() []*string {
+	return []*string{&p.PAT, &p.APIKey, &p.ProjectID, &p.DDNURL, &p.Timezone, &p.BaseURL, &p.APIURL, &p.AuthURL, &p.ControlPlaneURL}
+}
+
+func (c *Config) setActive(p Profile) {
+	c.PAT = p.PAT
+	c.APIKey = p.APIKey
+	c.ProjectID = p.ProjectID
+	c.DDNURL = p.DDNURL
+	c.Timezone = p.Timezone
+	c.BaseURL = p.BaseURL
+	c.APIURL = p.APIURL
+	c.AuthURL = p.AuthURL
+	c.ControlPlaneURL = p.ControlPlaneURL
+}
+
 // HasCredentials returns true if at least a PAT is configured.
 func (c *Config) HasCredentials() bool {
 	return c.PAT != ""
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -84,6 +85,23 @@ func TestLoad_MalformedJSON(t *testing.T) {
 	}
 }
 
+func TestLoad_UnknownSendKey(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	dir := filepath.Join(tmp, ".config", "promptql-tui")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"send_key":"shift+enter"}`), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "shift+enter") {
+		t.Fatalf("expected an error naming the unknown send key, got %v", err)
+	}
+}
+
 func TestHasCredentials_WithPAT(t *testing.T) {
 	cfg := Config{PAT: "some-pat"}
 	if !cfg.HasCredentials() {
@@ -103,11 +121,13 @@ func TestSave_RoundTrip(t *testing.T) {
 	t.Setenv("HOME", tmp)
 
 	original := &Config{
-		PAT:       "round-trip-pat",
-		APIKey:    "round-trip-key",
-		ProjectID: "proj-rt",
-		DDNURL:    "https://rt.example.com/graphql",
-		Timezone:  "Europe/London",
+		PAT:         "round-trip-pat",
+		APIKey:      "round-trip-key",
+		ProjectID:   "proj-rt",
+		DDNURL:      "https://rt.example.com/graphql",
+		Timezone:    "Europe/London",
+		SendKey:     SendKeyEnter,
+		CacheTokens: true,
 	}
 
 	if err := original.Save(); err != nil {
@@ -134,4 +154,135 @@ func TestSave_RoundTrip(t *testing.T) {
 	if loaded.Timezone != original.Timezone {
 		t.Errorf("Timezone: got %q, want %q", loaded.Timezone, original.Timezone)
 	}
+	if loaded.SendKey != original.SendKey {
+		t.Errorf("SendKey: got %q, want %q", loaded.SendKey, original.SendKey)
+	}
+	if !loaded.CacheTokens {
+		t.Error("CacheTokens: got false, want true")
+	}
+}
+
+func TestProfiles_SwitchAndSave(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	dir := filepath.Join(tmp, ".config", "promptql-tui")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	data := `{
+		"pat": "prod-pat",
+		"profiles": {
+			"staging": {"pat": "staging-pat", "base_url": "https://data.staging.example.com"}
+		}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Name() != DefaultProfile || cfg.PAT != "prod-pat" {
+		t.Fatalf("expected the default profile to be active, got %q with PAT %q", cfg.Name(), cfg.PAT)
+	}
+	if names := cfg.ProfileNames(); len(names) != 2 || names[0] != DefaultProfile || names[1] != "staging" {
+		t.Errorf("unexpected profile names %v", names)
+	}
+
+	if err := cfg.UseProfile("missing"); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatalf("UseProfile() error: %v", err)
+	}
+	if cfg.PAT != "staging-pat" || cfg.BaseURL != "https://data.staging.example.com" {
+		t.Errorf("expected staging settings, got PAT %q base URL %q", cfg.PAT, cfg.BaseURL)
+	}
+
+	cfg.APIKey = "staging-key"
+	if err := cfg.AddProfile("dev"); err != nil {
+		t.Fatalf("AddProfile() error: %v", err)
+	}
+	if err := cfg.AddProfile("staging"); err == nil {
+		t.Error("expected a duplicate profile to be rejected")
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.json.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed, got %v", err)
+	}
+	if loaded.PAT != "prod-pat" {
+		t.Errorf("expected the default profile to stay at the top level, got PAT %q", loaded.PAT)
+	}
+	if err := loaded.UseProfile("staging"); err != nil {
+		t.Fatalf("UseProfile() after reload: %v", err)
+	}
+	if loaded.APIKey != "staging-key" || loaded.BaseURL != "https://data.staging.example.com" {
+		t.Errorf("expected staging changes to be saved, got %+v", loaded.Profiles["staging"])
+	}
+	if err := loaded.UseProfile("dev"); err != nil {
+		t.Errorf("expected the new profile to be saved: %v", err)
+	}
+}
+
+func TestOverride_NotSaved(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	dir := filepath.Join(tmp, ".config", "promptql-tui")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	data := `{"pat": "file-pat", "profiles": {"staging": {"pat": "staging-pat"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	cfg.Override(Profile{PAT: "env-pat", DDNURL: "https://ddn.example.com/graphql"})
+	if cfg.PAT != "env-pat" || cfg.DDNURL != "https://ddn.example.com/graphql" {
+		t.Fatalf("expected the overrides to apply, got PAT %q DDN URL %q", cfg.PAT, cfg.DDNURL)
+	}
+
+	cfg.Timezone = "Europe/Paris"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if cfg.PAT != "env-pat" {
+		t.Errorf("expected the override to stay in effect after saving, got %q", cfg.PAT)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if loaded.PAT != "file-pat" || loaded.DDNURL != "" || loaded.Timezone != "Europe/Paris" {
+		t.Errorf("expected only the timezone to be saved, got PAT %q DDN URL %q timezone %q", loaded.PAT, loaded.DDNURL, loaded.Timezone)
+	}
+
+	// A setting changed after the override is saved.
+	cfg.PAT = "typed-pat"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if loaded, err = Load(); err != nil || loaded.PAT != "typed-pat" {
+		t.Errorf("expected the changed PAT to be saved, got %q (%v)", loaded.PAT, err)
+	}
+
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatalf("UseProfile() error: %v", err)
+	}
+	if cfg.PAT != "staging-pat" || cfg.DDNURL != "" {
+		t.Errorf("expected the overrides not to carry over, got PAT %q DDN URL %q", cfg.PAT, cfg.DDNURL)
+	}
 }
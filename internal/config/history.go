@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// InstructionVersion is one version of a project's system instructions.
+type InstructionVersion struct {
+	SavedAt      time.Time `json:"saved_at"`
+	Instructions string    `json:"instructions"`
+	Note         string    `json:"note,omitempty"`
+	// RestoredFrom is the number, counting from 1, of the version a
+	// rollback restored; 0 if the version is not a rollback.
+	RestoredFrom int `json:"restored_from,omitempty"`
+}
+
+// InstructionHistory is the local history of the system instructions
+// pushed to a project, oldest first. It is stored per project under
+// ~/.config/promptql-tui/history/.
+type InstructionHistory struct {
+	ProjectID string               `json:"project_id"`
+	Versions  []InstructionVersion `json:"versions"`
+}
+
+func historyPath(projectID string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history", safeFileName(projectID)+".json"), nil
+}
+
+// safeFileName maps s to a name that is safe to use as a single path element.
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// LoadInstructionHistory reads a project's instruction history, returning
+// an empty history if none has been recorded.
+func LoadInstructionHistory(projectID string) (*InstructionHistory, error) {
+	h := &InstructionHistory{ProjectID: projectID}
+	path, err := historyPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("reading instruction history: %w", err)
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("parsing instruction history: %w", err)
+	}
+	return h, nil
+}
+
+// Latest returns the most recent version, or nil if the history is empty.
+func (h *InstructionHistory) Latest() *InstructionVersion {
+	if len(h.Versions) == 0 {
+		return nil
+	}
+	return &h.Versions[len(h.Versions)-1]
+}
+
+// Record appends a version unless its instructions match the latest one,
+// and reports whether it was added.
+func (h *InstructionHistory) Record(v InstructionVersion) bool {
+	if latest := h.Latest(); latest != nil && latest.Instructions == v.Instructions {
+		return false
+	}
+	h.Versions = append(h.Versions, v)
+	return true
+}
+
+// Save writes the history to disk.
+func (h *InstructionHistory) Save() error {
+	path, err := historyPath(h.ProjectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling instruction history: %w", err)
+	}
+	// Write to a temporary file first so a failed write cannot lose the
+	// history.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing instruction history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing instruction history: %w", err)
+	}
+	return nil
+}
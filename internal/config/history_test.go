@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInstructionHistory_RecordAndReload(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	h, err := LoadInstructionHistory("proj/../1")
+	if err != nil {
+		t.Fatalf("LoadInstructionHistory() error: %v", err)
+	}
+	if h.Latest() != nil {
+		t.Fatal("expected an empty history")
+	}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !h.Record(InstructionVersion{SavedAt: at, Instructions: "Be concise."}) {
+		t.Error("expected first version to be recorded")
+	}
+	if h.Record(InstructionVersion{SavedAt: at.Add(time.Minute), Instructions: "Be concise."}) {
+		t.Error("expected an unchanged version to be skipped")
+	}
+	if !h.Record(InstructionVersion{SavedAt: at.Add(time.Hour), Instructions: "Be thorough.", RestoredFrom: 1}) {
+		t.Error("expected a changed version to be recorded")
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmp, ".config", "promptql-tui", "history", "proj_.._1.json")); err != nil {
+		t.Errorf("expected history file inside the history directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".config", "promptql-tui", "history", "proj_.._1.json.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed, got %v", err)
+	}
+
+	loaded, err := LoadInstructionHistory("proj/../1")
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if len(loaded.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(loaded.Versions))
+	}
+	latest := loaded.Latest()
+	if latest.Instructions != "Be thorough." || latest.RestoredFrom != 1 || !latest.SavedAt.Equal(at.Add(time.Hour)) {
+		t.Errorf("unexpected latest version %+v", latest)
+	}
+}
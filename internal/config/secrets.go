@@ -0,0 +1,507 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SecretStore keeps credentials out of the config file, which then only
+// holds references to them.
+type SecretStore interface {
+	// Get returns the secret stored under key, or ErrSecretNotFound.
+	Get(key string) (string, error)
+	// Set stores value under key.
+	Set(key, value string) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// ErrSecretNotFound is returned by SecretStore.Get for unknown keys.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret store backends, selected by the "secrets" section of the config file.
+const (
+	BackendVault  = "vault"  // encrypted file, the default
+	BackendHelper = "helper" // external git-credential style command
+	BackendPlain  = "plain"  // plaintext in config.json
+)
+
+// SecretSettings selects where credentials are stored.
+type SecretSettings struct {
+	Backend string `json:"backend,omitempty"`
+	// Helper is the credential helper command for BackendHelper,
+	// e.g. "git credential-store --file ~/.promptql-credentials".
+	Helper string `json:"helper,omitempty"`
+}
+
+// secretRefPrefix marks a config value that refers to a secret store entry.
+const secretRefPrefix = "secret:"
+
+// PassphraseFunc returns the vault passphrase. create is true when the
+// vault does not exist yet, so callers can ask for confirmation.
+type PassphraseFunc func(create bool) (string, error)
+
+// OpenSecretStore opens the secret backend selected in the config. The
+// store is not read until ResolveSecrets, so commands that need no
+// credentials never unlock it. Once opened, Save moves credentials into the
+// store and writes only references to the file.
+func (c *Config) OpenSecretStore(passphrase PassphraseFunc) error {
+	switch c.Secrets.Backend {
+	case "", BackendVault:
+		dir, err := configDir()
+		if err != nil {
+			return err
+		}
+		c.store = NewVault(filepath.Join(dir, "secrets.vault"), passphrase)
+	case BackendHelper:
+		if strings.TrimSpace(c.Secrets.Helper) == "" {
+			return fmt.Errorf("secrets backend %q needs a helper command", BackendHelper)
+		}
+		c.store = &HelperStore{Command: c.Secrets.Helper}
+	case BackendPlain:
+		c.store = nil
+	default:
+		return fmt.Errorf("unknown secrets backend %q", c.Secrets.Backend)
+	}
+	return nil
+}
+
+// SecretStore returns the store opened by OpenSecretStore, or nil if
+// credentials are kept in the config file.
+func (c *Config) SecretStore() SecretStore {
+	return c.store
+}
+
+// HasPlaintextSecrets reports whether the config file holds credentials
+// that belong in the secret store, i.e. whether Save would move them.
+func (c *Config) HasPlaintextSecrets() bool {
+	return c.store != nil && c.plaintext
+}
+
+// ResolveSecrets replaces secret references in every profile with the
+// secrets they refer to, reading them from the store opened by
+// OpenSecretStore. The store is left alone if there are no references;
+// credentials of the active profile that are overridden are not read.
+func (c *Config) ResolveSecrets() error {
+	profiles := c.profiles()
+	env := secretFields(&c.overrides)
+	for name, p := range profiles {
+		for i, f := range secretFields(&p) {
+			if !strings.HasPrefix(*f.value, secretRefPrefix) {
+				continue
+			}
+			// A credential overridden for this run is not needed.
+			if name == c.Name() && *env[i].value != "" {
+				continue
+			}
+			if c.store == nil {
+				return fmt.Errorf("profile %q refers to a stored %s but no secret store is configured", name, f.name)
+			}
+			v, err := c.store.Get(strings.TrimPrefix(*f.value, secretRefPrefix))
+			if err != nil {
+				return fmt.Errorf("reading %s for profile %q: %w", f.name, name, err)
+			}
+			*f.value = v
+		}
+		profiles[name] = p
+	}
+	c.Profiles = profiles
+	c.setActive(profiles[c.Name()])
+	c.applyOverrides()
+	return nil
+}
+
+// storeSecrets moves the credentials of a profile into the secret store,
+// replacing them with references. References that were never resolved are
+// kept as they are.
+func (c *Config) storeSecrets(name string, p *Profile) error {
+	for _, f := range secretFields(p) {
+		key := name + "/" + f.name
+		if strings.HasPrefix(*f.value, secretRefPrefix) {
+			continue
+		}
+		if *f.value == "" {
+			if err := c.store.Delete(key); err != nil {
+				return fmt.Errorf("removing %s for profile %q: %w", f.name, name, err)
+			}
+			continue
+		}
+		if err := c.store.Set(key, *f.value); err != nil {
+			return fmt.Errorf("storing %s for profile %q: %w", f.name, name, err)
+		}
+		*f.value = secretRefPrefix + key
+	}
+	return nil
+}
+
+type secretField struct {
+	name  string
+	value *string
+}
+
+// secretFields returns the fields of p that are kept in the secret store.
+func secretFields(p *Profile) []secretField {
+	return []secretField{{"pat", &p.PAT}, {"api_key", &p.APIKey}}
+}
+
+// --- Vault ---
+
+// Vault is a SecretStore kept in a single file encrypted with AES-256-GCM,
+// using a key derived from a passphrase with PBKDF2-SHA256.
+type Vault struct {
+	path       string
+	passphrase PassphraseFunc
+	iterations int
+
+	// Derived key, cached after the first unlock.
+	key  []byte
+	salt []byte
+}
+
+// vaultFile is the on-disk layout of a vault.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// defaultVaultIterations is the PBKDF2 work factor for new vaults.
+const defaultVaultIterations = 600000
+
+// NewVault returns a vault stored at path. The passphrase is requested on
+// first use.
+func NewVault(path string, passphrase PassphraseFunc) *Vault {
+	return &Vault{path: path, passphrase: passphrase, iterations: defaultVaultIterations}
+}
+
+// Unlock derives the vault key, asking for the passphrase if it is not yet
+// known. Get and Set unlock the vault as needed; calling Unlock up front
+// lets interactive programs ask for the passphrase before taking over the
+// terminal.
+func (v *Vault) Unlock() error {
+	_, err := v.load()
+	return err
+}
+
+// Exists reports whether the vault file has been created.
+func (v *Vault) Exists() bool {
+	_, err := os.Stat(v.path)
+	return err == nil
+}
+
+// Locked reports whether using the vault would ask for the passphrase.
+func (v *Vault) Locked() bool {
+	return v.key == nil
+}
+
+func (v *Vault) Get(key string) (string, error) {
+	secrets, err := v.load()
+	if err != nil {
+		return "", err
+	}
+	s, ok := secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return s, nil
+}
+
+func (v *Vault) Set(key, value string) error {
+	secrets, err := v.load()
+	if err != nil {
+		return err
+	}
+	if old, ok := secrets[key]; ok && old == value {
+		return nil
+	}
+	secrets[key] = value
+	return v.save(secrets)
+}
+
+func (v *Vault) Delete(key string) error {
+	if !v.Exists() {
+		// Nothing to delete, and no reason to ask for a passphrase.
+		return nil
+	}
+	secrets, err := v.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return v.save(secrets)
+}
+
+// load decrypts the vault, returning an empty set of secrets if the vault
+// does not exist yet.
+func (v *Vault) load() (map[string]string, error) {
+	data, err := os.ReadFile(v.path)
+	if os.IsNotExist(err) {
+		if v.key == nil {
+			if err := v.derive(true, nil, v.iterations); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading vault: %w", err)
+	}
+
+	var f vaultFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing vault: %w", err)
+	}
+	if f.Version != 1 || f.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported vault format (version %d, kdf %q)", f.Version, f.KDF)
+	}
+	if v.key == nil || !bytes.Equal(v.salt, f.Salt) {
+		if err := v.derive(false, f.Salt, f.Iterations); err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := v.cipher()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		v.key = nil
+		return nil, fmt.Errorf("unlocking vault: wrong passphrase or corrupted vault")
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("parsing vault contents: %w", err)
+	}
+	return secrets, nil
+}
+
+func (v *Vault) save(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("marshaling vault contents: %w", err)
+	}
+	gcm, err := v.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	data, err := json.MarshalIndent(vaultFile{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: v.iterations,
+		Salt:       v.salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling vault: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0700); err != nil {
+		return fmt.Errorf("creating vault directory: %w", err)
+	}
+	// Write to a temporary file first so a failed write cannot lose the vault.
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing vault: %w", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		return fmt.Errorf("writing vault: %w", err)
+	}
+	return nil
+}
+
+// derive asks for the passphrase and derives the key for salt, generating
+// a new salt when salt is nil.
+func (v *Vault) derive(create bool, salt []byte, iterations int) error {
+	if v.passphrase == nil {
+		return fmt.Errorf("vault passphrase required")
+	}
+	pass, err := v.passphrase(create)
+	if err != nil {
+		return err
+	}
+	if pass == "" {
+		return fmt.Errorf("vault passphrase must not be empty")
+	}
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("generating salt: %w", err)
+		}
+	}
+	key, err := pbkdf2.Key(sha256.New, pass, salt, iterations, 32)
+	if err != nil {
+		return fmt.Errorf("deriving vault key: %w", err)
+	}
+	v.key, v.salt, v.iterations = key, salt, iterations
+	return nil
+}
+
+func (v *Vault) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(v.key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// --- Credential helper ---
+
+// HelperStore is a SecretStore backed by an external command speaking the
+// git credential helper protocol, so existing helpers such as
+// "git credential-store" or "git credential-libsecret" can be used.
+//
+// The command is split into words as a shell would, honouring quotes and
+// backslashes and expanding a leading ~, but it is not run by a shell:
+// variables, globs and pipes are not supported. It is run with "get",
+// "store" or "erase" appended and reads
+// key=value lines on stdin: protocol=promptql-tui, host=<profile> and
+// username=<field>, plus password=<secret> for "store". For "get" it
+// prints password=<secret>, or nothing if the secret is unknown.
+type HelperStore struct {
+	Command string
+}
+
+func (h *HelperStore) Get(key string) (string, error) {
+	out, err := h.run("get", key, "")
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return v, nil
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+func (h *HelperStore) Set(key, value string) error {
+	if strings.ContainsAny(value, "\n\x00") {
+		return fmt.Errorf("credential helpers cannot store secrets containing newlines")
+	}
+	_, err := h.run("store", key, value)
+	return err
+}
+
+func (h *HelperStore) Delete(key string) error {
+	_, err := h.run("erase", key, "")
+	return err
+}
+
+func (h *HelperStore) run(action, key, secret string) ([]byte, error) {
+	args, err := splitCommand(h.Command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("credential helper command is empty")
+	}
+	host, username, _ := strings.Cut(key, "/")
+
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "protocol=promptql-tui\nhost=%s\nusername=%s\n", host, username)
+	if action == "store" {
+		fmt.Fprintf(&in, "password=%s\n", secret)
+	}
+	in.WriteString("\n")
+
+	cmd := exec.Command(args[0], append(args[1:], action)...)
+	cmd.Stdin = &in
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %s: %w: %s", action, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s: %w", action, err)
+	}
+	return out, nil
+}
+
+// splitCommand splits a helper command into words like a POSIX shell:
+// single quotes keep everything literally, double quotes and backslashes
+// escape as they do in sh, and an unquoted ~ at the start of a word is the
+// home directory.
+func splitCommand(s string) ([]string, error) {
+	home, _ := os.UserHomeDir()
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			}
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '~' && !inWord && home != "" && (i+1 == len(runes) || runes[i+1] == '/' || unicode.IsSpace(runes[i+1])):
+			word.WriteString(home)
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("credential helper command has an unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
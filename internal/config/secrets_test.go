@@ -0,0 +1,241 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func staticPassphrase(pass string) PassphraseFunc {
+	return func(bool) (string, error) { return pass, nil }
+}
+
+func TestVault_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+	v := NewVault(path, staticPassphrase("correct horse"))
+	v.iterations = 1000
+
+	if _, err := v.Get("default/pat"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound from an empty vault, got %v", err)
+	}
+	if err := v.Set("default/pat", "pat-123"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading vault: %v", err)
+	}
+	if strings.Contains(string(data), "pat-123") {
+		t.Error("expected the vault file to be encrypted")
+	}
+
+	reopened := NewVault(path, staticPassphrase("correct horse"))
+	if got, err := reopened.Get("default/pat"); err != nil || got != "pat-123" {
+		t.Errorf("expected the secret after reopening, got %q (%v)", got, err)
+	}
+	if err := reopened.Delete("default/pat"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := reopened.Get("default/pat"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected the secret to be deleted, got %v", err)
+	}
+
+	wrong := NewVault(path, staticPassphrase("wrong"))
+	if _, err := wrong.Get("default/pat"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected a wrong passphrase to be rejected, got %v", err)
+	}
+}
+
+func TestConfig_SaveMovesSecretsToVault(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	dir := filepath.Join(tmp, ".config", "promptql-tui")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	legacy := `{"pat": "prod-pat", "profiles": {"staging": {"pat": "staging-pat", "api_key": "staging-key"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(legacy), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if err := cfg.OpenSecretStore(staticPassphrase("pw")); err != nil {
+		t.Fatalf("OpenSecretStore() error: %v", err)
+	}
+	if err := cfg.ResolveSecrets(); err != nil {
+		t.Fatalf("ResolveSecrets() error: %v", err)
+	}
+	if !cfg.HasPlaintextSecrets() {
+		t.Fatal("expected the legacy config to need migration")
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if cfg.HasPlaintextSecrets() || cfg.PAT != "prod-pat" {
+		t.Errorf("expected secrets migrated and kept in memory, got PAT %q", cfg.PAT)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+	if strings.Contains(string(data), "prod-pat") || strings.Contains(string(data), "staging-key") {
+		t.Errorf("expected only references in the config file, got %s", data)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil || raw["pat"] != "secret:default/pat" {
+		t.Errorf("expected a reference for the default PAT, got %v", raw["pat"])
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if err := loaded.OpenSecretStore(staticPassphrase("pw")); err != nil {
+		t.Fatalf("OpenSecretStore() after reload: %v", err)
+	}
+	if err := loaded.ResolveSecrets(); err != nil {
+		t.Fatalf("ResolveSecrets() after reload: %v", err)
+	}
+	if err := loaded.UseProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.PAT != "staging-pat" || loaded.APIKey != "staging-key" {
+		t.Errorf("expected resolved staging credentials, got %q %q", loaded.PAT, loaded.APIKey)
+	}
+
+	noStore, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	noStore.Secrets.Backend = BackendPlain
+	if err := noStore.OpenSecretStore(nil); err != nil {
+		t.Fatalf("OpenSecretStore() error: %v", err)
+	}
+	if err := noStore.ResolveSecrets(); err == nil {
+		t.Error("expected references without a secret store to be an error")
+	}
+}
+
+func TestConfig_SecretsResolvedOnlyWhenAsked(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	dir := filepath.Join(tmp, ".config", "promptql-tui")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	refs := `{"pat": "secret:default/pat", "profiles": {"staging": {"pat": "secret:staging/pat"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(refs), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := func(bool) (string, error) { return "", errors.New("vault passphrase requested") }
+	if err := cfg.OpenSecretStore(locked); err != nil {
+		t.Fatalf("OpenSecretStore() error: %v", err)
+	}
+	if names := cfg.ProfileNames(); len(names) != 2 {
+		t.Errorf("expected the profiles to be listed without the vault, got %v", names)
+	}
+	cfg.Timezone = "UTC"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() without resolving: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil || !strings.Contains(string(data), `"secret:staging/pat"`) {
+		t.Errorf("expected unresolved references to be kept, got %s (%v)", data, err)
+	}
+	if err := cfg.ResolveSecrets(); err == nil || !strings.Contains(err.Error(), "passphrase requested") {
+		t.Errorf("expected resolving to unlock the vault, got %v", err)
+	}
+
+	// An overridden credential is not read from the store.
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Override(Profile{PAT: "env-pat"})
+	if err := cfg.ResolveSecrets(); err == nil || !strings.Contains(err.Error(), `"default"`) {
+		t.Errorf("expected only the other profile's reference to be read, got %v", err)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"git credential-store --file ~/.creds", []string{"git", "credential-store", "--file", home + "/.creds"}},
+		{`"/Applications/My Helper/helper" --name 'a b'`, []string{"/Applications/My Helper/helper", "--name", "a b"}},
+		{`helper a\ b "x\"y" '~/literal'`, []string{"helper", "a b", `x"y`, "~/literal"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.in)
+		if err != nil || strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("splitCommand(%q) = %q (%v), want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := splitCommand(`helper "unterminated`); err == nil {
+		t.Error("expected an unterminated quote to be an error")
+	}
+}
+
+func TestHelperStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	// A minimal helper that keeps one file per host/username pair.
+	script := `#!/bin/sh
+while IFS='=' read -r k v; do
+  [ -z "$k" ] && break
+  eval "in_$k=\$v"
+done
+f="` + dir + `/$in_host.$in_username"
+case "$1" in
+  get) if [ -f "$f" ]; then printf 'username=%s\npassword=%s\n' "$in_username" "$(cat "$f")"; fi ;;
+  store) printf '%s' "$in_password" > "$f" ;;
+  erase) rm -f "$f" ;;
+esac
+`
+	helper := filepath.Join(dir, "helper.sh")
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	h := &HelperStore{Command: helper}
+
+	if _, err := h.Get("default/pat"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+	if err := h.Set("default/pat", "pat-456"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if got, err := h.Get("default/pat"); err != nil || got != "pat-456" {
+		t.Errorf("expected stored secret, got %q (%v)", got, err)
+	}
+	if err := h.Delete("default/pat"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := h.Get("default/pat"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected the secret to be erased, got %v", err)
+	}
+	if err := h.Set("default/pat", "a\nb"); err == nil {
+		t.Error("expected a multi-line secret to be rejected")
+	}
+}
@@ -0,0 +1,43 @@
+// Package connect builds SDK clients from the configuration, for the TUI
+// and the CLI.
+package connect
+
+import (
+	"time"
+
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// Bounds of the offline response cache.
+const (
+	responseCacheMaxAge     = 30 * 24 * time.Hour
+	responseCacheMaxEntries = 1000
+)
+
+// NewClient builds an SDK client from the active profile's credentials and
+// endpoints. Query responses are cached for offline use in
+// config.ResponseCacheDir, and DDN tokens under
+// ~/.config/promptql-tui/tokens/ if Config.CacheTokens is set.
+func NewClient(cfg *config.Config) *sdk.Client {
+	var tokens sdk.TokenCache
+	if dir, err := config.TokenCacheDir(); err == nil && cfg.CacheTokens {
+		tokens = sdk.FileTokenCache{Dir: dir}
+	}
+	var cache sdk.ResponseCache
+	if dir, err := config.ResponseCacheDir(); err == nil {
+		cache = sdk.FileResponseCache{Dir: dir, MaxAge: responseCacheMaxAge, MaxEntries: responseCacheMaxEntries}
+	}
+	return sdk.NewClient(sdk.ClientOptions{
+		PAT:             cfg.PAT,
+		APIKey:          cfg.APIKey,
+		ProjectID:       cfg.ProjectID,
+		BaseURL:         cfg.BaseURL,
+		APIURL:          cfg.APIURL,
+		AuthURL:         cfg.AuthURL,
+		ControlPlaneURL: cfg.ControlPlaneURL,
+		Retry:           sdk.DefaultRetryPolicy(),
+		TokenCache:      tokens,
+		Cache:           cache,
+	})
+}
@@ -0,0 +1,164 @@
+// Package diff computes line-based unified diffs of text.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpKind is the kind of a diff operation.
+type OpKind byte
+
+const (
+	Equal  OpKind = ' '
+	Delete OpKind = '-'
+	Insert OpKind = '+'
+)
+
+// Op is a single line of a diff.
+type Op struct {
+	Kind OpKind
+	Text string
+}
+
+// Lines returns the operations turning the lines of a into the lines of b.
+func Lines(a, b string) []Op {
+	al, bl := splitLines(a), splitLines(b)
+
+	// Trim the common prefix and suffix so the quadratic part only sees
+	// the region that changed.
+	prefix := 0
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Op
+	for _, l := range al[:prefix] {
+		ops = append(ops, Op{Equal, l})
+	}
+	ops = append(ops, lcs(al[prefix:len(al)-suffix], bl[prefix:len(bl)-suffix])...)
+	for _, l := range al[len(al)-suffix:] {
+		ops = append(ops, Op{Equal, l})
+	}
+	return ops
+}
+
+// lcs diffs a and b via their longest common subsequence.
+func lcs(a, b []string) []Op {
+	n, m := len(a), len(b)
+	// table[i][j] is the LCS length of a[i:] and b[j:].
+	table := make([][]int32, n+1)
+	for i := range table {
+		table[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Equal, a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			ops = append(ops, Op{Delete, a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Op{Delete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Op{Insert, b[j]})
+	}
+	return ops
+}
+
+// Unified returns a unified diff turning a into b, with context lines of
+// unchanged text around each change. It returns "" if a and b are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	ops := Lines(a, b)
+
+	// Find the ranges of ops to show: each change plus its context,
+	// merging ranges that touch.
+	type span struct{ start, end int }
+	var spans []span
+	for i, op := range ops {
+		if op.Kind == Equal {
+			continue
+		}
+		start, end := max(i-context, 0), min(i+context+1, len(ops))
+		if n := len(spans); n > 0 && start <= spans[n-1].end {
+			spans[n-1].end = max(spans[n-1].end, end)
+			continue
+		}
+		spans = append(spans, span{start, end})
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	aLine, bLine, pos := 1, 1, 0
+	for _, s := range spans {
+		for ; pos < s.start; pos++ {
+			aLine++
+			bLine++
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[s.start:s.end] {
+			if op.Kind != Insert {
+				aCount++
+			}
+			if op.Kind != Delete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, op := range ops[s.start:s.end] {
+			out.WriteByte(byte(op.Kind))
+			out.WriteString(op.Text)
+			out.WriteByte('\n')
+		}
+		aLine += aCount
+		bLine += bCount
+		pos = s.end
+	}
+	return out.String()
+}
+
+// hunkRange formats a hunk's line range. An empty range refers to the
+// line before it, as in GNU diff.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,54 @@
+package diff
+
+import "testing"
+
+func TestUnified_Equal(t *testing.T) {
+	if got := Unified("a", "b", "same\ntext\n", "same\ntext", 3); got != "" {
+		t.Errorf("expected no diff, got %q", got)
+	}
+}
+
+func TestUnified_SingleChange(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+	b := "one\ntwo\nthree\nFOUR\nfive\nsix\nseven\n"
+	want := `--- old
++++ new
+@@ -2,5 +2,5 @@
+ two
+ three
+-four
++FOUR
+ five
+ six
+`
+	if got := Unified("old", "new", a, b, 2); got != want {
+		t.Errorf("unexpected diff:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+	b := "A\nb\nc\nd\ne\nf\ng\nh\nI\nj\n"
+	want := `--- old
++++ new
+@@ -1,2 +1,2 @@
+-a
++A
+ b
+@@ -8,2 +8,3 @@
+ h
+-i
++I
++j
+`
+	if got := Unified("old", "new", a, b, 1); got != want {
+		t.Errorf("unexpected diff:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestUnified_FromEmpty(t *testing.T) {
+	want := "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("old", "new", "", "x\ny\n", 3); got != want {
+		t.Errorf("unexpected diff:\nwant %q\ngot  %q", want, got)
+	}
+}
@@ -1,5 +1,7 @@
 package sdk
 
+import "context"
+
 // APIKeysResource provides management of runtime API keys.
 type APIKeysResource struct {
 	client *Client
@@ -7,6 +9,11 @@ type APIKeysResource struct {
 
 // List lists all runtime API keys for a project.
 func (r *APIKeysResource) List(projectID string) ([]RuntimeAPIKey, error) {
+	return r.ListContext(context.Background(), projectID)
+}
+
+// ListContext is like List but uses ctx for the request.
+func (r *APIKeysResource) ListContext(ctx context.Context, projectID string) ([]RuntimeAPIKey, error) {
 	query := `
 		query ListRuntimeApiKeys($projectId: String!) {
 			getRuntimeApiKeys(projectId: $projectId) {
@@ -22,7 +29,7 @@ func (r *APIKeysResource) List(projectID string) ([]RuntimeAPIKey, error) {
 				sqlTimeout
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +48,14 @@ type GenerateOptions struct {
 	SQLTimeout      *int
 }
 
-// Generate creates a new runtime API key.
-func (r *APIKeysResource) Generate(opts GenerateOptions) (map[string]interface{}, error) {
+// Generate creates a new runtime API key. The plaintext key is only
+// returned here; store it before discarding the result.
+func (r *APIKeysResource) Generate(opts GenerateOptions) (*GeneratedAPIKey, error) {
+	return r.GenerateContext(context.Background(), opts)
+}
+
+// GenerateContext is like Generate but uses ctx for the request.
+func (r *APIKeysResource) GenerateContext(ctx context.Context, opts GenerateOptions) (*GeneratedAPIKey, error) {
 	query := `
 		mutation GenerateRuntimeApiKey($projectId: String!, $name: String!, $promptqlTimeout: Int, $sqlTimeout: Int) {
 			generateRuntimeApiKey(projectId: $projectId, name: $name, promptqlTimeout: $promptqlTimeout, sqlTimeout: $sqlTimeout) {
@@ -69,19 +82,24 @@ func (r *APIKeysResource) Generate(opts GenerateOptions) (map[string]interface{}
 		variables["sqlTimeout"] = *opts.SQLTimeout
 	}
 
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
-	result, ok := data["generateRuntimeApiKey"].(map[string]interface{})
-	if !ok {
-		return nil, &PromptQLError{Message: "unexpected response format for generateRuntimeApiKey"}
+	var result GeneratedAPIKey
+	if err := decodeJSONField(data, "generateRuntimeApiKey", &result); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return &result, nil
 }
 
 // Remove removes (deactivates) a runtime API key.
 func (r *APIKeysResource) Remove(projectID string, apiKeyID int) (*MessageResult, error) {
+	return r.RemoveContext(context.Background(), projectID, apiKeyID)
+}
+
+// RemoveContext is like Remove but uses ctx for the request.
+func (r *APIKeysResource) RemoveContext(ctx context.Context, projectID string, apiKeyID int) (*MessageResult, error) {
 	query := `
 		mutation RemoveRuntimeApiKey($projectId: String!, $apiKeyId: Int!) {
 			removeRuntimeApiKey(projectId: $projectId, apiKeyId: $apiKeyId) {
@@ -92,7 +110,7 @@ func (r *APIKeysResource) Remove(projectID string, apiKeyID int) (*MessageResult
 		"projectId": projectID,
 		"apiKeyId":  apiKeyID,
 	}
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Generate
+// ---------------------------------------------------------------------------
+
+func TestGenerateAPIKey_Typed(t *testing.T) {
+	var sent struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sent)
+		body := graphqlJSON(`{
+			"generateRuntimeApiKey": {
+				"id": 7,
+				"name": "ci",
+				"projectId": "proj-1",
+				"apiKey": "pql_secret",
+				"apiKeyMasked": "pql_****cret",
+				"isActive": true,
+				"promptqlTimeout": 120,
+				"sqlTimeout": null
+			}
+		}`)
+		return jsonResponse(200, body), nil
+	})
+
+	timeout := 120
+	key, err := client.APIKeys().Generate(GenerateOptions{ProjectID: "proj-1", Name: "ci", PromptQLTimeout: &timeout})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.ID != 7 || key.Name != "ci" || key.APIKey != "pql_secret" {
+		t.Errorf("unexpected key: %+v", key)
+	}
+	if key.IsActive == nil || !*key.IsActive {
+		t.Error("expected IsActive=true")
+	}
+	if key.PromptQLTimeout == nil || *key.PromptQLTimeout != 120 || key.SQLTimeout != nil {
+		t.Errorf("unexpected timeouts: %v, %v", key.PromptQLTimeout, key.SQLTimeout)
+	}
+	if sent.Variables["promptqlTimeout"] != float64(120) {
+		t.Errorf("expected promptqlTimeout variable 120, got %v", sent.Variables["promptqlTimeout"])
+	}
+	if _, ok := sent.Variables["sqlTimeout"]; ok {
+		t.Error("expected sqlTimeout to be omitted")
+	}
+}
+
+func TestGenerateAPIKey_MissingField(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(200, graphqlJSON(`{}`)), nil
+	})
+	if _, err := client.APIKeys().Generate(GenerateOptions{ProjectID: "proj-1", Name: "ci"}); err == nil {
+		t.Fatal("expected error for missing generateRuntimeApiKey field")
+	}
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Table is the tabular form of a table artifact. Columns are listed in the
+// order they first appear in the data; Rows holds one value per column,
+// nil where a row lacks that column. Numbers are decoded as json.Number so
+// they keep their original precision and formatting.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Table decodes a table artifact's data, which is a JSON array of row objects.
+func (a Artifact) Table() (*Table, error) {
+	if a.ArtifactType != ArtifactTypeTable {
+		return nil, fmt.Errorf("artifact %q is of type %q, not %q", a.Identifier, a.ArtifactType, ArtifactTypeTable)
+	}
+	var rawRows []json.RawMessage
+	if len(a.Data) > 0 {
+		if err := json.Unmarshal(a.Data, &rawRows); err != nil {
+			return nil, fmt.Errorf("decoding table artifact %q: %w", a.Identifier, err)
+		}
+	}
+
+	table := &Table{Rows: make([][]interface{}, 0, len(rawRows))}
+	index := map[string]int{}
+	for _, raw := range rawRows {
+		keys, values, err := decodeOrderedObject(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decoding table artifact %q: %w", a.Identifier, err)
+		}
+		row := make([]interface{}, len(table.Columns))
+		for i, k := range keys {
+			col, ok := index[k]
+			if !ok {
+				col = len(table.Columns)
+				index[k] = col
+				table.Columns = append(table.Columns, k)
+			}
+			for len(row) <= col {
+				row = append(row, nil)
+			}
+			row[col] = values[i]
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	// Earlier rows may be shorter than the final column count.
+	for i := range table.Rows {
+		for len(table.Rows[i]) < len(table.Columns) {
+			table.Rows[i] = append(table.Rows[i], nil)
+		}
+	}
+	return table, nil
+}
+
+// Text decodes a text artifact's data.
+func (a Artifact) Text() (string, error) {
+	if a.ArtifactType != ArtifactTypeText {
+		return "", fmt.Errorf("artifact %q is of type %q, not %q", a.Identifier, a.ArtifactType, ArtifactTypeText)
+	}
+	var text string
+	if err := json.Unmarshal(a.Data, &text); err != nil {
+		return "", fmt.Errorf("decoding text artifact %q: %w", a.Identifier, err)
+	}
+	return text, nil
+}
+
+// decodeOrderedObject decodes a JSON object, returning its keys in document order.
+func decodeOrderedObject(data []byte) ([]string, []interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, nil, fmt.Errorf("expected object, got %v", tok)
+	}
+	var keys []string
+	var values []interface{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected object key, got %v", tok)
+		}
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
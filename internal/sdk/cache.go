@@ -0,0 +1,158 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotCached is returned for cache-only requests (see CacheOnly) that
+// have no cached response.
+var ErrNotCached = errors.New("no cached response")
+
+// CachedResponse is the data of a GraphQL query response with the time it
+// was received.
+type CachedResponse struct {
+	Data     json.RawMessage `json:"data"`
+	StoredAt time.Time       `json:"stored_at"`
+}
+
+// ResponseCache stores GraphQL query responses so they can be served
+// without the network. Mutations are never cached.
+type ResponseCache interface {
+	// Get returns the response cached under key, or nil if there is none.
+	Get(key string) (*CachedResponse, error)
+	// Put caches r under key.
+	Put(key string, r *CachedResponse) error
+}
+
+// FileResponseCache is a ResponseCache keeping one JSON file per key in Dir.
+// Every Put prunes the entries older than MaxAge and, beyond MaxEntries,
+// the least recently used ones. Zero limits are not enforced.
+type FileResponseCache struct {
+	Dir        string
+	MaxAge     time.Duration
+	MaxEntries int
+}
+
+func (c FileResponseCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+func (c FileResponseCache) Get(key string) (*CachedResponse, error) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cached response: %w", err)
+	}
+	var r CachedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing cached response: %w", err)
+	}
+	now := time.Now()
+	if c.MaxAge > 0 && now.Sub(r.StoredAt) > c.MaxAge {
+		os.Remove(path)
+		return nil, nil
+	}
+	// The modification time records the last use, for pruning.
+	_ = os.Chtimes(path, now, now)
+	return &r, nil
+}
+
+func (c FileResponseCache) Put(key string, r *CachedResponse) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling cached response: %w", err)
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing cached response: %w", err)
+	}
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		return fmt.Errorf("writing cached response: %w", err)
+	}
+	return c.prune()
+}
+
+// prune removes the entries past MaxAge, then the least recently used
+// ones beyond MaxEntries.
+func (c FileResponseCache) prune() error {
+	if c.MaxAge <= 0 && c.MaxEntries <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return fmt.Errorf("pruning cache: %w", err)
+	}
+	type entry struct {
+		path   string
+		usedAt time.Time
+	}
+	var kept []entry
+	now := time.Now()
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(c.Dir, e.Name())
+		if c.MaxAge > 0 && now.Sub(info.ModTime()) > c.MaxAge {
+			os.Remove(path)
+			continue
+		}
+		kept = append(kept, entry{path, info.ModTime()})
+	}
+	if c.MaxEntries <= 0 || len(kept) <= c.MaxEntries {
+		return nil
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].usedAt.Before(kept[j].usedAt) })
+	for _, e := range kept[:len(kept)-c.MaxEntries] {
+		os.Remove(e.path)
+	}
+	return nil
+}
+
+type cacheOnlyKey struct{}
+
+// CacheOnly returns a context that makes GraphQL queries made with it
+// return the client's cached response instead of calling the API, or
+// ErrNotCached. If storedAt is not nil it is set to the time the response
+// was cached. Used to render cached data while a fresh request is in
+// flight, or when the network is down.
+func CacheOnly(ctx context.Context, storedAt *time.Time) context.Context {
+	if storedAt == nil {
+		storedAt = new(time.Time)
+	}
+	return context.WithValue(ctx, cacheOnlyKey{}, storedAt)
+}
+
+// cacheOnly returns the storedAt pointer of a CacheOnly context.
+func cacheOnly(ctx context.Context) (*time.Time, bool) {
+	at, ok := ctx.Value(cacheOnlyKey{}).(*time.Time)
+	return at, ok
+}
+
+// responseCacheKey identifies a query by endpoint, credentials and payload,
+// so different users never share cached responses.
+func responseCacheKey(endpoint, auth string, body []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(endpoint), []byte(auth), body} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
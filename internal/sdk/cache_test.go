@@ -0,0 +1,139 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func newCacheTestClient(t *testing.T, fn func(*http.Request) (*http.Response, error)) (*Client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	return NewClient(ClientOptions{
+		PAT:     "test-pat",
+		BaseURL: "https://test.example.com",
+		Cache:   FileResponseCache{Dir: dir},
+		HTTPClient: &http.Client{
+			Transport: &mockRoundTripper{fn: fn},
+		},
+	}), dir
+}
+
+func TestCache_QueryServedFromCache(t *testing.T) {
+	calls := 0
+	client, _ := newCacheTestClient(t, func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(200, graphqlJSON(`{"ddn_projects":[{"id":"proj-1","name":"Alpha","ddn_builds":[]}]}`)), nil
+	})
+
+	// Nothing is cached before the first request.
+	if _, err := client.Projects().ListUserProjectsContext(CacheOnly(context.Background(), nil)); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("expected ErrNotCached, got %v", err)
+	}
+	if _, err := client.Projects().ListUserProjects(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var at time.Time
+	projects, err := client.Projects().ListUserProjectsContext(CacheOnly(context.Background(), &at))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the cached request not to call the API, got %d calls", calls)
+	}
+	if len(projects) != 1 || projects[0].Name != "Alpha" {
+		t.Errorf("unexpected cached projects %+v", projects)
+	}
+	if at.IsZero() || time.Since(at) > time.Minute {
+		t.Errorf("expected the cache time to be set, got %v", at)
+	}
+}
+
+func TestCache_MutationsNotCached(t *testing.T) {
+	client, dir := newCacheTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(200, graphqlJSON(`{"ok":true}`)), nil
+	})
+
+	mutation := `mutation Touch { ok }`
+	if _, err := client.GraphQL(mutation, nil, "pat"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no cached responses, got %d", len(entries))
+	}
+	if _, err := client.GraphQLContext(CacheOnly(context.Background(), nil), mutation, nil, "pat"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached for a mutation, got %v", err)
+	}
+}
+
+func TestCache_FailedQueriesNotCached(t *testing.T) {
+	client, dir := newCacheTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(500, `{"message":"boom"}`), nil
+	})
+
+	if _, err := client.Projects().ListUserProjects(); err == nil {
+		t.Fatal("expected error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no cached responses, got %d", len(entries))
+	}
+}
+
+func TestFileResponseCache_Prunes(t *testing.T) {
+	cache := FileResponseCache{Dir: t.TempDir(), MaxAge: time.Hour, MaxEntries: 2}
+	now := time.Now()
+	put := func(key string, storedAt time.Time) {
+		t.Helper()
+		if err := cache.Put(key, &CachedResponse{Data: []byte(`{}`), StoredAt: storedAt}); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+		if err := os.Chtimes(cache.path(key), storedAt, storedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("expired", now.Add(-2*time.Hour))
+	if r, _ := cache.Get("expired"); r != nil {
+		t.Error("expected an entry past MaxAge not to be served")
+	}
+
+	put("a", now.Add(-3*time.Minute))
+	put("b", now.Add(-2*time.Minute))
+	if r, _ := cache.Get("a"); r == nil {
+		t.Fatal("expected a to be cached")
+	}
+	put("c", now)
+
+	// a was used last, so b is the least recently used entry.
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if r, _ := cache.Get(key); (r != nil) != want {
+			t.Errorf("entry %s: cached %v, want %v", key, r != nil, want)
+		}
+	}
+	entries, _ := os.ReadDir(cache.Dir)
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries on disk, got %d", len(entries))
+	}
+}
+
+func TestIsNetworkError(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	_, err := client.Projects().ListUserProjects()
+	if !IsNetworkError(err) {
+		t.Errorf("expected a network error, got %v", err)
+	}
+
+	client = newTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(401, `{"message":"invalid token"}`), nil
+	})
+	_, err = client.Projects().ListUserProjects()
+	if IsNetworkError(err) {
+		t.Errorf("expected an API error not to be a network error, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ package sdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,6 +32,13 @@ type ClientOptions struct {
 	Timeout time.Duration
 	// HTTPClient allows injecting a custom *http.Client (useful for testing).
 	HTTPClient *http.Client
+	// Retry configures automatic retries of failed requests. Nil disables retries.
+	Retry *RetryPolicy
+	// TokenCache optionally persists DDN tokens across processes. Tokens
+	// are always cached in memory.
+	TokenCache TokenCache
+	// Cache optionally stores GraphQL query responses for offline use.
+	Cache ResponseCache
 }
 
 // Client is the main entry point for the PromptQL SDK.
@@ -43,6 +51,7 @@ type Client struct {
 	authURL         string
 	controlPlaneURL string
 	http            *http.Client
+	retry           *RetryPolicy
 
 	projects *ProjectsResource
 	prompts  *PromptsResource
@@ -50,6 +59,8 @@ type Client struct {
 	threads  *ThreadsResource
 	query    *QueryResource
 	users    *UsersResource
+	tokens   *TokenManager
+	cache    ResponseCache
 }
 
 // NewClient creates a new PromptQL client with the given options.
@@ -89,6 +100,8 @@ func NewClient(opts ClientOptions) *Client {
 		authURL:         authURL,
 		controlPlaneURL: controlPlaneURL,
 		http:            httpClient,
+		retry:           opts.Retry,
+		cache:           opts.Cache,
 	}
 
 	c.projects = &ProjectsResource{client: c}
@@ -97,6 +110,7 @@ func NewClient(opts ClientOptions) *Client {
 	c.threads = &ThreadsResource{client: c}
 	c.query = &QueryResource{client: c}
 	c.users = &UsersResource{client: c}
+	c.tokens = newTokenManager(c, opts.TokenCache)
 
 	return c
 }
@@ -119,31 +133,36 @@ func (c *Client) Query() *QueryResource { return c.query }
 // Users returns the users resource.
 func (c *Client) Users() *UsersResource { return c.users }
 
+// DDNTokens returns the DDN token manager.
+func (c *Client) DDNTokens() *TokenManager { return c.tokens }
+
 // GetDDNToken exchanges a PAT for a DDN bearer token.
 func (c *Client) GetDDNToken(projectID string) (*TokenResponse, error) {
+	return c.GetDDNTokenContext(context.Background(), projectID)
+}
+
+// GetDDNTokenContext is like GetDDNToken but uses ctx for the request.
+func (c *Client) GetDDNTokenContext(ctx context.Context, projectID string) (*TokenResponse, error) {
 	if c.pat == "" {
 		return nil, &AuthenticationError{PromptQLError{
 			Message: "A Personal Access Token (pat) is required to obtain a DDN token",
 		}}
 	}
 
-	req, err := http.NewRequest("POST", c.authURL+"/ddn/promptql/token", nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("Authorization", "pat "+c.pat)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-hasura-project-id", projectID)
-
-	resp, err := c.http.Do(req)
+	resp, err := c.send(ctx, c.http, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.authURL+"/ddn/promptql/token", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "pat "+c.pat)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-hasura-project-id", projectID)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if err := checkResponse(resp); err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var token TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
@@ -162,6 +181,13 @@ func (c *Client) authHeader(authType string) (string, error) {
 			}}
 		}
 		return "pat " + c.pat, nil
+	case "query":
+		// The query endpoint takes an API key, or the PAT for clients
+		// without one.
+		if c.apiKey == "" && c.pat != "" {
+			return c.authHeader("pat")
+		}
+		return c.authHeader("bearer")
 	case "bearer":
 		if c.apiKey == "" {
 			return "", &AuthenticationError{PromptQLError{
@@ -190,16 +216,26 @@ type graphqlResponse struct {
 
 // GraphQL executes a GraphQL query/mutation and returns the data field.
 func (c *Client) GraphQL(query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
-	return c.graphqlTo(c.baseURL+"/graphql", query, variables, authType)
+	return c.GraphQLContext(context.Background(), query, variables, authType)
+}
+
+// GraphQLContext is like GraphQL but uses ctx for the request.
+func (c *Client) GraphQLContext(ctx context.Context, query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
+	return c.graphqlTo(ctx, c.baseURL+"/graphql", query, variables, authType)
 }
 
 // GraphQLControlPlane executes a GraphQL query against the DDN control-plane API.
 func (c *Client) GraphQLControlPlane(query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
-	return c.graphqlTo(c.controlPlaneURL+"/v1/graphql", query, variables, authType)
+	return c.GraphQLControlPlaneContext(context.Background(), query, variables, authType)
+}
+
+// GraphQLControlPlaneContext is like GraphQLControlPlane but uses ctx for the request.
+func (c *Client) GraphQLControlPlaneContext(ctx context.Context, query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
+	return c.graphqlTo(ctx, c.controlPlaneURL+"/v1/graphql", query, variables, authType)
 }
 
 // graphqlTo executes a GraphQL query/mutation against the given endpoint URL.
-func (c *Client) graphqlTo(endpoint string, query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
+func (c *Client) graphqlTo(ctx context.Context, endpoint string, query string, variables map[string]interface{}, authType string) (map[string]interface{}, error) {
 	auth, err := c.authHeader(authType)
 	if err != nil {
 		return nil, err
@@ -211,22 +247,38 @@ func (c *Client) graphqlTo(endpoint string, query string, variables map[string]i
 		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+	cacheKey := ""
+	if c.cache != nil && !isMutation(query) {
+		cacheKey = responseCacheKey(endpoint, auth, body)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", auth)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+	if storedAt, ok := cacheOnly(ctx); ok {
+		if cacheKey == "" {
+			return nil, ErrNotCached
+		}
+		cached, err := c.cache.Get(cacheKey)
+		if err != nil {
+			return nil, err
+		}
+		if cached == nil {
+			return nil, ErrNotCached
+		}
+		*storedAt = cached.StoredAt
+		return decodeGraphQLData(cached.Data)
 	}
-	defer resp.Body.Close()
 
-	if err := checkResponse(resp); err != nil {
+	resp, err := c.send(ctx, c.http, !isMutation(query), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", auth)
+		return req, nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var gqlResp graphqlResponse
 	if err := json.NewDecoder(resp.Body).Decode(&gqlResp); err != nil {
@@ -237,8 +289,20 @@ func (c *Client) graphqlTo(endpoint string, query string, variables map[string]i
 		return nil, &PromptQLError{Message: gqlResp.Errors[0].Message}
 	}
 
+	data, err := decodeGraphQLData(gqlResp.Data)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKey != "" {
+		// A failing cache only costs offline access.
+		_ = c.cache.Put(cacheKey, &CachedResponse{Data: gqlResp.Data, StoredAt: time.Now()})
+	}
+	return data, nil
+}
+
+func decodeGraphQLData(raw json.RawMessage) (map[string]interface{}, error) {
 	var data map[string]interface{}
-	if err := json.Unmarshal(gqlResp.Data, &data); err != nil {
+	if err := json.Unmarshal(raw, &data); err != nil {
 		return nil, fmt.Errorf("unmarshaling data: %w", err)
 	}
 	return data, nil
@@ -246,38 +310,56 @@ func (c *Client) graphqlTo(endpoint string, query string, variables map[string]i
 
 // PostAPI sends a POST request to the REST API and returns the response body.
 func (c *Client) PostAPI(path string, jsonBody map[string]interface{}, authType string) (map[string]interface{}, error) {
-	auth, err := c.authHeader(authType)
+	return c.PostAPIContext(context.Background(), path, jsonBody, authType)
+}
+
+// PostAPIContext is like PostAPI but uses ctx for the request.
+func (c *Client) PostAPIContext(ctx context.Context, path string, jsonBody map[string]interface{}, authType string) (map[string]interface{}, error) {
+	resp, err := c.postAPI(ctx, c.http, path, jsonBody, authType, "application/json")
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	body, err := json.Marshal(jsonBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling request: %w", err)
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
 	}
+	return result, nil
+}
 
-	req, err := http.NewRequest("POST", c.apiURL+path, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", auth)
+// PostAPIStream sends a POST request to the REST API and returns the raw
+// response for incremental reading. The caller must close the response body.
+// The client's Timeout does not apply to the body; use ctx to bound the stream.
+func (c *Client) PostAPIStream(ctx context.Context, path string, jsonBody map[string]interface{}, authType string) (*http.Response, error) {
+	streamHTTP := *c.http
+	streamHTTP.Timeout = 0
+	return c.postAPI(ctx, &streamHTTP, path, jsonBody, authType, "text/event-stream, application/x-ndjson")
+}
 
-	resp, err := c.http.Do(req)
+// postAPI sends a POST request to the REST API and returns the successful response.
+func (c *Client) postAPI(ctx context.Context, httpClient *http.Client, path string, jsonBody map[string]interface{}, authType, accept string) (*http.Response, error) {
+	auth, err := c.authHeader(authType)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if err := checkResponse(resp); err != nil {
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+	body, err := json.Marshal(jsonBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
-	return result, nil
+
+	// A query can run actions against DDN, so it is not replayed once sent.
+	return c.send(ctx, httpClient, false, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.apiURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Authorization", auth)
+		return req, nil
+	})
 }
 
 // checkResponse inspects the HTTP response and returns a typed error for non-2xx status codes.
@@ -297,7 +379,7 @@ func checkResponse(resp *http.Response) error {
 		}
 	}
 
-	return newErrorForStatus(resp.StatusCode, message)
+	return newErrorForStatus(resp.StatusCode, message, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
 }
 
 // decodeJSONField is a helper that extracts a named field from a map and decodes it into the target.
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Context propagation
+// ---------------------------------------------------------------------------
+
+func TestContext_PassedToTransport(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "span-1")
+
+	var got interface{}
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		got = req.Context().Value(ctxKey{})
+		return jsonResponse(200, graphqlJSON(`{"getThreads": []}`)), nil
+	})
+
+	if _, err := client.Threads().ListContext(ctx, "proj-1", "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "span-1" {
+		t.Errorf("expected request context to carry caller value, got %v", got)
+	}
+}
+
+func TestContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return jsonResponse(200, graphqlJSON(`{"getThreadEvents": []}`)), nil
+	})
+
+	_, err := client.Threads().GetEventsContext(ctx, "t-1")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %T: %v", err, err)
+	}
+}
+
+func TestContext_QueryExecute(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewClient(ClientOptions{
+		APIKey: "test-key",
+		APIURL: "https://api.test.example.com",
+		HTTPClient: &http.Client{Transport: &mockRoundTripper{fn: func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			return jsonResponse(200, `{}`), nil
+		}}},
+	})
+
+	_, err := client.Query().AskContext(ctx, "how many users?", "https://ddn.example.com/graphql", nil, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,184 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Conversation accumulates the interaction history of a direct query
+// session, so every query is sent with the context of the earlier turns.
+//
+//	conv := &sdk.Conversation{Limits: sdk.HistoryLimits{MaxInteractions: 20}}
+//	resp, err := client.Query().Converse(conv, "How many orders last week?", opts)
+//	resp, err = client.Query().Converse(conv, "And the week before?", opts)
+type Conversation struct {
+	// Interactions is the history sent with each query, oldest first.
+	Interactions []Interaction
+	// Artifacts holds the latest version of each artifact produced so far.
+	Artifacts []Artifact
+	// Summary condenses interactions dropped by Limits, if Limits.Summarize is set.
+	Summary *Interaction
+	// Limits bounds the history sent with each query.
+	Limits HistoryLimits
+}
+
+// HistoryLimits bounds the history a Conversation sends. When a limit is
+// exceeded the oldest interactions are dropped (the latest is always kept).
+type HistoryLimits struct {
+	// MaxInteractions is the most interactions kept. Zero means unlimited.
+	MaxInteractions int
+	// MaxBytes bounds the JSON-encoded size of the history. Zero means unlimited.
+	MaxBytes int
+	// Summarize, if set, condenses dropped interactions (preceded by the
+	// previous summary, if any) into a single interaction that is sent
+	// ahead of the remaining history.
+	Summarize func(dropped []Interaction) Interaction
+}
+
+// AddUserMessage starts a new turn with the user's text and applies the
+// history limits.
+func (c *Conversation) AddUserMessage(text string) {
+	c.Interactions = append(c.Interactions, NewUserInteraction(text))
+	c.Trim()
+}
+
+// Record attaches the assistant's response to the latest turn and merges
+// any modified artifacts.
+func (c *Conversation) Record(resp *QueryResponse) {
+	if resp == nil || len(c.Interactions) == 0 {
+		return
+	}
+	last := &c.Interactions[len(c.Interactions)-1]
+	last.AssistantActions = append(last.AssistantActions, resp.AssistantActions...)
+	for _, a := range resp.ModifiedArtifacts {
+		c.mergeArtifact(a)
+	}
+	c.Trim()
+}
+
+// DropPending removes the latest turn if it has not been answered, e.g.
+// after a failed query, so the question is not replayed with the next one.
+// It returns the dropped user text.
+func (c *Conversation) DropPending() string {
+	n := len(c.Interactions)
+	if n == 0 || len(c.Interactions[n-1].AssistantActions) > 0 {
+		return ""
+	}
+	last := c.Interactions[n-1]
+	c.Interactions = c.Interactions[:n-1]
+	if last.UserMessage != nil {
+		return last.UserMessage.Text
+	}
+	return ""
+}
+
+// Reset clears the history.
+func (c *Conversation) Reset() {
+	c.Interactions = nil
+	c.Artifacts = nil
+	c.Summary = nil
+}
+
+// History returns the interactions to send: the summary, if any, followed
+// by the retained interactions.
+func (c *Conversation) History() []Interaction {
+	history := make([]Interaction, 0, len(c.Interactions)+1)
+	if c.Summary != nil {
+		history = append(history, *c.Summary)
+	}
+	return append(history, c.Interactions...)
+}
+
+// Apply returns opts with the conversation's history and artifacts.
+func (c *Conversation) Apply(opts ExecuteOptions) ExecuteOptions {
+	opts.Interactions = c.History()
+	opts.Artifacts = c.Artifacts
+	return opts
+}
+
+// Trim drops the oldest interactions until the history fits the limits.
+func (c *Conversation) Trim() {
+	var dropped []Interaction
+	for len(c.Interactions) > 1 && c.overLimit() {
+		dropped = append(dropped, c.Interactions[0])
+		c.Interactions = c.Interactions[1:]
+	}
+	if len(dropped) == 0 || c.Limits.Summarize == nil {
+		return
+	}
+	if c.Summary != nil {
+		dropped = append([]Interaction{*c.Summary}, dropped...)
+	}
+	summary := c.Limits.Summarize(dropped)
+	c.Summary = &summary
+}
+
+func (c *Conversation) overLimit() bool {
+	if c.Limits.MaxInteractions > 0 && len(c.Interactions) > c.Limits.MaxInteractions {
+		return true
+	}
+	if c.Limits.MaxBytes > 0 {
+		data, err := json.Marshal(c.Interactions)
+		if err == nil && len(data) > c.Limits.MaxBytes {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Conversation) mergeArtifact(a Artifact) {
+	for i := range c.Artifacts {
+		if c.Artifacts[i].Identifier == a.Identifier {
+			c.Artifacts[i] = a
+			return
+		}
+	}
+	c.Artifacts = append(c.Artifacts, a)
+}
+
+// SummarizeText returns a Summarize function that condenses dropped turns
+// into a plain-text recap of at most maxChars characters, keeping the most
+// recent text when it must cut.
+func SummarizeText(maxChars int) func([]Interaction) Interaction {
+	return func(dropped []Interaction) Interaction {
+		var b strings.Builder
+		for _, inter := range dropped {
+			if inter.UserMessage != nil && inter.UserMessage.Text != "" {
+				b.WriteString("User: " + inter.UserMessage.Text + "\n")
+			}
+			for _, a := range inter.AssistantActions {
+				if a.Message != "" {
+					b.WriteString("Assistant: " + a.Message + "\n")
+				}
+			}
+		}
+		recap := []rune(b.String())
+		if maxChars > 0 && len(recap) > maxChars {
+			recap = append([]rune("…"), recap[len(recap)-maxChars:]...)
+		}
+		return Interaction{
+			Role:             "user",
+			UserMessage:      &UserMessage{Text: "Summary of our earlier conversation:\n" + string(recap)},
+			AssistantActions: []AssistantAction{{Message: "Noted."}},
+		}
+	}
+}
+
+// Converse adds question to the conversation, executes it with the full
+// history, and records the response.
+func (r *QueryResource) Converse(conv *Conversation, question string, opts ExecuteOptions) (*QueryResponse, error) {
+	return r.ConverseContext(context.Background(), conv, question, opts)
+}
+
+// ConverseContext is like Converse but uses ctx for the request.
+func (r *QueryResource) ConverseContext(ctx context.Context, conv *Conversation, question string, opts ExecuteOptions) (*QueryResponse, error) {
+	conv.AddUserMessage(question)
+	resp, err := r.ExecuteContext(ctx, conv.Apply(opts))
+	if err != nil {
+		conv.DropPending()
+		return nil, err
+	}
+	conv.Record(resp)
+	return resp, nil
+}
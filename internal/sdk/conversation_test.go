@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConverse_SendsHistory(t *testing.T) {
+	var sent []int
+	var roles []string
+	replies := []string{"There are 42.", "Last week there were 40."}
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Interactions []Interaction `json:"interactions"`
+		}
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &body)
+		sent = append(sent, len(body.Interactions))
+		for _, i := range body.Interactions {
+			roles = append(roles, i.Role)
+		}
+		reply := replies[len(sent)-1]
+		return jsonResponse(200, `{"assistant_actions":[{"message":"`+reply+`"}]}`), nil
+	})
+
+	conv := &Conversation{}
+	if _, err := client.Query().Converse(conv, "How many users?", ExecuteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Query().Converse(conv, "And last week?", ExecuteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sent) != 2 || sent[0] != 1 || sent[1] != 2 {
+		t.Errorf("expected interactions sent per turn [1 2], got %v", sent)
+	}
+	if strings.Join(roles, ",") != "user,user,user" {
+		t.Errorf("expected every interaction sent with role user, got %v", roles)
+	}
+	if len(conv.Interactions) != 2 {
+		t.Fatalf("expected 2 interactions in history, got %d", len(conv.Interactions))
+	}
+	if got := conv.Interactions[0].AssistantActions[0].Message; got != "There are 42." {
+		t.Errorf("expected first reply recorded, got %q", got)
+	}
+}
+
+func TestConverse_ErrorDropsPendingTurn(t *testing.T) {
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(500, `{"message":"boom"}`), nil
+	})
+
+	conv := &Conversation{}
+	if _, err := client.Query().Converse(conv, "How many users?", ExecuteOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(conv.Interactions) != 0 {
+		t.Errorf("expected failed turn to be dropped, got %d interactions", len(conv.Interactions))
+	}
+}
+
+func TestConversation_TrimAndSummarize(t *testing.T) {
+	conv := &Conversation{Limits: HistoryLimits{
+		MaxInteractions: 2,
+		Summarize:       SummarizeText(1000),
+	}}
+	for _, q := range []string{"q1", "q2", "q3"} {
+		conv.AddUserMessage(q)
+		conv.Record(&QueryResponse{AssistantActions: []AssistantAction{{Message: "a-" + q}}})
+	}
+
+	if len(conv.Interactions) != 2 {
+		t.Fatalf("expected 2 retained interactions, got %d", len(conv.Interactions))
+	}
+	if conv.Interactions[0].UserMessage.Text != "q2" {
+		t.Errorf("expected oldest retained turn to be q2, got %q", conv.Interactions[0].UserMessage.Text)
+	}
+	if conv.Summary == nil || !strings.Contains(conv.Summary.UserMessage.Text, "User: q1") {
+		t.Fatalf("expected summary of dropped turn, got %+v", conv.Summary)
+	}
+
+	history := conv.History()
+	if len(history) != 3 || history[0].UserMessage.Text != conv.Summary.UserMessage.Text {
+		t.Errorf("expected summary to lead the history, got %d interactions", len(history))
+	}
+}
+
+func TestConversation_MaxBytesKeepsLatest(t *testing.T) {
+	conv := &Conversation{Limits: HistoryLimits{MaxBytes: 10}}
+	conv.AddUserMessage("a fairly long first question")
+	conv.AddUserMessage("a fairly long second question")
+
+	if len(conv.Interactions) != 1 {
+		t.Fatalf("expected only the latest turn to be kept, got %d", len(conv.Interactions))
+	}
+	if conv.Interactions[0].UserMessage.Text != "a fairly long second question" {
+		t.Errorf("unexpected retained turn: %q", conv.Interactions[0].UserMessage.Text)
+	}
+}
+
+func TestConversation_RecordMergesArtifacts(t *testing.T) {
+	conv := &Conversation{}
+	conv.AddUserMessage("q")
+	conv.Record(&QueryResponse{ModifiedArtifacts: []Artifact{{Identifier: "a", Title: "v1"}}})
+	conv.AddUserMessage("q2")
+	conv.Record(&QueryResponse{ModifiedArtifacts: []Artifact{{Identifier: "a", Title: "v2"}, {Identifier: "b"}}})
+
+	opts := conv.Apply(ExecuteOptions{DDNURL: "https://ddn.example.com"})
+	if len(opts.Artifacts) != 2 || opts.Artifacts[0].Title != "v2" {
+		t.Errorf("expected merged artifacts [a(v2) b], got %+v", opts.Artifacts)
+	}
+	if opts.DDNURL != "https://ddn.example.com" || len(opts.Interactions) != 2 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
@@ -1,12 +1,19 @@
 package sdk
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
 
 // PromptQLError is the base error type for all PromptQL SDK errors.
 type PromptQLError struct {
 	Message    string
 	StatusCode int
 	Detail     string
+	// RetryAfter is the delay requested by the server's Retry-After header, if any.
+	RetryAfter time.Duration
 }
 
 func (e *PromptQLError) Error() string {
@@ -34,9 +41,16 @@ type RateLimitError struct{ PromptQLError }
 // ServerError is returned on HTTP 5xx responses.
 type ServerError struct{ PromptQLError }
 
+// IsNetworkError reports whether err is a failure to reach the server, as
+// opposed to an error response from it.
+func IsNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
 // newErrorForStatus returns the appropriate typed error for the given HTTP status code.
-func newErrorForStatus(status int, message string) error {
-	base := PromptQLError{Message: message, StatusCode: status, Detail: message}
+func newErrorForStatus(status int, message string, retryAfter time.Duration) error {
+	base := PromptQLError{Message: message, StatusCode: status, Detail: message, RetryAfter: retryAfter}
 	switch status {
 	case 401:
 		return &AuthenticationError{base}
@@ -54,3 +68,15 @@ func newErrorForStatus(status int, message string) error {
 	}
 	return &base
 }
+
+// RetryError wraps the final error of a request that was attempted more than once.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
@@ -0,0 +1,219 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EventKind identifies the kind of a decoded thread event payload.
+type EventKind string
+
+const (
+	EventUserMessage      EventKind = "user_message"
+	EventAssistantMessage EventKind = "assistant_message"
+	EventPlanStep         EventKind = "plan_step"
+	EventCodeExecution    EventKind = "code_execution"
+	EventArtifactCreated  EventKind = "artifact_created"
+	EventError            EventKind = "error"
+	EventTitleUpdate      EventKind = "title_update"
+	EventUnknown          EventKind = "unknown"
+)
+
+// Event is a decoded thread event payload. Use a type switch on the
+// concrete *XxxEvent types, or Kind to branch without one.
+type Event interface {
+	Kind() EventKind
+}
+
+// UserMessageEvent is a message sent by the user.
+type UserMessageEvent struct {
+	MessageID string
+	Text      string
+}
+
+// AssistantMessageEvent is a message from the assistant.
+type AssistantMessageEvent struct {
+	MessageID string
+	Text      string
+}
+
+// PlanStepEvent is a step of the assistant's plan.
+type PlanStepEvent struct {
+	Plan string
+}
+
+// CodeExecutionEvent is a program the assistant ran and its result.
+type CodeExecutionEvent struct {
+	Code   string
+	Output string
+	Error  string
+}
+
+// ArtifactCreatedEvent carries an artifact produced or modified by a program.
+type ArtifactCreatedEvent struct {
+	Artifact Artifact
+}
+
+// ErrorEvent is an error reported within the thread.
+type ErrorEvent struct {
+	Message string
+}
+
+// TitleUpdateEvent is a change of the thread's title.
+type TitleUpdateEvent struct {
+	Title string
+}
+
+// UnknownEvent is an event whose shape is not recognized. Raw holds the
+// original payload.
+type UnknownEvent struct {
+	Raw json.RawMessage
+}
+
+func (*UserMessageEvent) Kind() EventKind      { return EventUserMessage }
+func (*AssistantMessageEvent) Kind() EventKind { return EventAssistantMessage }
+func (*PlanStepEvent) Kind() EventKind         { return EventPlanStep }
+func (*CodeExecutionEvent) Kind() EventKind    { return EventCodeExecution }
+func (*ArtifactCreatedEvent) Kind() EventKind  { return EventArtifactCreated }
+func (*ErrorEvent) Kind() EventKind            { return EventError }
+func (*TitleUpdateEvent) Kind() EventKind      { return EventTitleUpdate }
+func (*UnknownEvent) Kind() EventKind          { return EventUnknown }
+
+// Event decodes the event's payload.
+func (e ThreadEvent) Event() Event { return DecodeEvent(e.EventData) }
+
+// Event decodes the result's payload.
+func (r SendMessageResult) Event() Event { return DecodeEvent(r.EventData) }
+
+// DecodeEvent decodes a raw event_data payload. Payloads with an explicit
+// "type" (or "event_type") discriminator are decoded by that; others are
+// recognized by the keys they carry. Anything else yields an *UnknownEvent.
+func DecodeEvent(data json.RawMessage) Event {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return &UnknownEvent{Raw: data}
+	}
+	p := payload(fields)
+
+	kind := p.str("type")
+	if kind == "" {
+		kind = p.str("event_type")
+	}
+	if kind != "" {
+		if evt := decodeTypedEvent(kind, p); evt != nil {
+			return evt
+		}
+		return &UnknownEvent{Raw: data}
+	}
+
+	switch {
+	case p.has("user_message"):
+		msg := p.obj("user_message")
+		return &UserMessageEvent{MessageID: firstNonEmpty(msg.str("message_id"), msg.str("id"), p.str("message_id")), Text: msg.text()}
+	case p.has("assistant_message"):
+		msg := p.obj("assistant_message")
+		return &AssistantMessageEvent{MessageID: firstNonEmpty(msg.str("message_id"), msg.str("id"), p.str("message_id")), Text: msg.text()}
+	case p.has("artifact"):
+		var a Artifact
+		if err := json.Unmarshal(fields["artifact"], &a); err == nil {
+			return &ArtifactCreatedEvent{Artifact: a}
+		}
+	case p.has("code"):
+		return &CodeExecutionEvent{Code: p.str("code"), Output: firstNonEmpty(p.str("code_output"), p.str("output")), Error: p.str("code_error")}
+	case p.has("plan"):
+		return &PlanStepEvent{Plan: p.str("plan")}
+	case p.has("error"):
+		return &ErrorEvent{Message: p.errorText()}
+	case p.has("title") && !p.has("message") && !p.has("text"):
+		return &TitleUpdateEvent{Title: p.str("title")}
+	case p.has("message") || p.has("text"):
+		if p.str("role") == "user" {
+			return &UserMessageEvent{MessageID: p.str("message_id"), Text: p.text()}
+		}
+		return &AssistantMessageEvent{MessageID: p.str("message_id"), Text: p.text()}
+	}
+	return &UnknownEvent{Raw: data}
+}
+
+// decodeTypedEvent decodes a payload that names its kind explicitly, or
+// returns nil if the kind is not recognized.
+func decodeTypedEvent(kind string, p payload) Event {
+	switch strings.ToLower(kind) {
+	case "user_message", "user":
+		if p.has("user_message") {
+			p = p.obj("user_message")
+		}
+		return &UserMessageEvent{MessageID: firstNonEmpty(p.str("message_id"), p.str("id")), Text: p.text()}
+	case "assistant_message", "assistant":
+		if p.has("assistant_message") {
+			p = p.obj("assistant_message")
+		}
+		return &AssistantMessageEvent{MessageID: firstNonEmpty(p.str("message_id"), p.str("id")), Text: p.text()}
+	case "plan_step", "plan":
+		return &PlanStepEvent{Plan: firstNonEmpty(p.str("plan"), p.text())}
+	case "code_execution", "code":
+		return &CodeExecutionEvent{Code: p.str("code"), Output: firstNonEmpty(p.str("code_output"), p.str("output")), Error: p.str("code_error")}
+	case "artifact_created", "artifact_update", "artifact":
+		var a Artifact
+		if err := json.Unmarshal(p["artifact"], &a); err != nil {
+			return nil
+		}
+		return &ArtifactCreatedEvent{Artifact: a}
+	case "error":
+		return &ErrorEvent{Message: p.errorText()}
+	case "title_update", "title":
+		return &TitleUpdateEvent{Title: p.str("title")}
+	}
+	return nil
+}
+
+// payload is a partially decoded JSON object with lenient accessors.
+type payload map[string]json.RawMessage
+
+func (p payload) has(key string) bool {
+	v, ok := p[key]
+	return ok && string(v) != "null"
+}
+
+// str returns the field as a string, or "" if it is missing or not a string.
+func (p payload) str(key string) string {
+	var s string
+	if err := json.Unmarshal(p[key], &s); err != nil {
+		return ""
+	}
+	return s
+}
+
+// obj returns the field as an object, or an empty payload.
+func (p payload) obj(key string) payload {
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal(p[key], &o); err != nil {
+		return payload{}
+	}
+	return o
+}
+
+// text returns the message text carried under "text" or "message".
+func (p payload) text() string {
+	return firstNonEmpty(p.str("text"), p.str("message"))
+}
+
+// errorText returns the error message, whether given as a string or an object.
+func (p payload) errorText() string {
+	if s := p.str("error"); s != "" {
+		return s
+	}
+	if s := p.obj("error").text(); s != "" {
+		return s
+	}
+	return p.text()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
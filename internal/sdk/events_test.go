@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestDecodeEvent_Shapes(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		kind EventKind
+	}{
+		{"user message", `{"user_message":{"text":"hi","message_id":"m-1"}}`, EventUserMessage},
+		{"assistant message", `{"assistant_message":{"text":"hello"}}`, EventAssistantMessage},
+		{"role user", `{"role":"user","message":"hi"}`, EventUserMessage},
+		{"bare text", `{"text":"hello"}`, EventAssistantMessage},
+		{"plan", `{"plan":"1. query users"}`, EventPlanStep},
+		{"code", `{"code":"run()","code_output":"42"}`, EventCodeExecution},
+		{"artifact", `{"artifact":{"identifier":"a1","artifact_type":"table","data":[]}}`, EventArtifactCreated},
+		{"error string", `{"error":"boom"}`, EventError},
+		{"title", `{"title":"Revenue by region"}`, EventTitleUpdate},
+		{"typed title", `{"type":"title_update","title":"New"}`, EventTitleUpdate},
+		{"typed assistant", `{"type":"assistant_message","assistant_message":{"text":"x"}}`, EventAssistantMessage},
+		{"typed unknown", `{"type":"heartbeat"}`, EventUnknown},
+		{"unrecognized", `{"foo":1}`, EventUnknown},
+		{"not an object", `[1,2]`, EventUnknown},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			evt := DecodeEvent(json.RawMessage(tc.data))
+			if evt.Kind() != tc.kind {
+				t.Errorf("expected kind %q, got %q (%#v)", tc.kind, evt.Kind(), evt)
+			}
+		})
+	}
+}
+
+func TestDecodeEvent_Fields(t *testing.T) {
+	evt := DecodeEvent(json.RawMessage(`{"user_message":{"text":"hi","message_id":"m-1"}}`))
+	user, ok := evt.(*UserMessageEvent)
+	if !ok {
+		t.Fatalf("expected *UserMessageEvent, got %T", evt)
+	}
+	if user.Text != "hi" || user.MessageID != "m-1" {
+		t.Errorf("unexpected fields: %+v", user)
+	}
+
+	evt = DecodeEvent(json.RawMessage(`{"error":{"message":"sql failed"}}`))
+	if e, ok := evt.(*ErrorEvent); !ok || e.Message != "sql failed" {
+		t.Errorf("expected ErrorEvent 'sql failed', got %#v", evt)
+	}
+
+	raw := json.RawMessage(`{"foo":1}`)
+	if u, ok := DecodeEvent(raw).(*UnknownEvent); !ok || string(u.Raw) != string(raw) {
+		t.Errorf("expected UnknownEvent to keep raw payload, got %#v", DecodeEvent(raw))
+	}
+}
+
+func TestGetEvents_DecodesPayloads(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		body := graphqlJSON(`{
+			"getThreadEvents": [
+				{"thread_event_id": 1, "event_data": {"user_message": {"text": "q"}}},
+				{"thread_event_id": 2, "event_data": {"assistant_message": {"text": "a"}}}
+			]
+		}`)
+		return jsonResponse(200, body), nil
+	})
+
+	events, err := client.Threads().GetEvents("t-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Event().Kind() != EventUserMessage {
+		t.Errorf("expected first event to be a user message, got %q", events[0].Event().Kind())
+	}
+	if a, ok := events[1].Event().(*AssistantMessageEvent); !ok || a.Text != "a" {
+		t.Errorf("expected assistant message 'a', got %#v", events[1].Event())
+	}
+}
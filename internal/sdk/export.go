@@ -0,0 +1,269 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ExportFormat is a file format artifacts and transcripts can be written in.
+type ExportFormat string
+
+const (
+	ExportCSV      ExportFormat = "csv"
+	ExportJSON     ExportFormat = "json"
+	ExportMarkdown ExportFormat = "markdown"
+)
+
+// ExportFormatForPath infers the export format from a file's extension
+// (.csv, .json, .md or .markdown).
+func ExportFormatForPath(path string) (ExportFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ExportCSV, nil
+	case ".json":
+		return ExportJSON, nil
+	case ".md", ".markdown":
+		return ExportMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported export file extension %q (use .csv, .json or .md)", filepath.Ext(path))
+}
+
+// WriteArtifact writes an artifact's data to w. Table artifacts can be
+// written in any format; text artifacts as JSON or Markdown.
+func WriteArtifact(w io.Writer, a Artifact, format ExportFormat) error {
+	switch format {
+	case ExportJSON:
+		return writeIndentedJSON(w, a.Data)
+	case ExportCSV:
+		table, err := a.Table()
+		if err != nil {
+			return err
+		}
+		return table.WriteCSV(w)
+	case ExportMarkdown:
+		if a.ArtifactType == ArtifactTypeText {
+			text, err := a.Text()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, text)
+			return err
+		}
+		table, err := a.Table()
+		if err != nil {
+			return err
+		}
+		return table.WriteMarkdown(w)
+	}
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+// Strings returns the table's rows with each value formatted by FormatCell.
+func (t *Table) Strings() [][]string {
+	rows := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = make([]string, len(row))
+		for j, v := range row {
+			rows[i][j] = FormatCell(v)
+		}
+	}
+	return rows
+}
+
+// WriteCSV writes the table as CSV with a header row.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Strings()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteMarkdown writes the table as a GitHub-flavored Markdown table.
+func (t *Table) WriteMarkdown(w io.Writer) error {
+	if len(t.Columns) == 0 {
+		_, err := fmt.Fprintln(w, "_(empty table)_")
+		return err
+	}
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + escapeMarkdownCell(c) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(t.Columns)
+	b.WriteString("|" + strings.Repeat(" --- |", len(t.Columns)) + "\n")
+	for _, row := range t.Strings() {
+		writeRow(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// FormatCell renders a decoded table value as text: strings as-is, numbers
+// in their original form, nil as empty, and anything else as JSON.
+func FormatCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
+
+// WriteTranscript writes events as a Markdown transcript titled title, with
+// code in fenced blocks and table artifacts as Markdown tables.
+func WriteTranscript(w io.Writer, title string, events []Event) error {
+	var b strings.Builder
+	if title == "" {
+		title = "PromptQL conversation"
+	}
+	b.WriteString("# " + title + "\n")
+
+	for _, evt := range events {
+		switch e := evt.(type) {
+		case *UserMessageEvent:
+			b.WriteString("\n## User\n\n" + e.Text + "\n")
+		case *AssistantMessageEvent:
+			b.WriteString("\n## PromptQL\n\n" + e.Text + "\n")
+		case *PlanStepEvent:
+			b.WriteString("\n**Plan:** " + e.Plan + "\n")
+		case *CodeExecutionEvent:
+			if e.Code != "" {
+				b.WriteString("\n" + fencedBlock("python", e.Code))
+			}
+			if e.Output != "" {
+				b.WriteString("\nOutput:\n\n" + fencedBlock("", e.Output))
+			}
+			if e.Error != "" {
+				b.WriteString("\nError:\n\n" + fencedBlock("", e.Error))
+			}
+		case *ArtifactCreatedEvent:
+			name := e.Artifact.Title
+			if name == "" {
+				name = e.Artifact.Identifier
+			}
+			b.WriteString("\n### " + name + "\n\n")
+			if err := WriteArtifact(&b, e.Artifact, ExportMarkdown); err != nil {
+				b.WriteString(fencedBlock("json", string(e.Artifact.Data)))
+			}
+		case *ErrorEvent:
+			b.WriteString("\n> **Error:** " + e.Message + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ExportTranscript writes a thread's events to w as a Markdown transcript.
+func (r *ThreadsResource) ExportTranscript(threadID string, w io.Writer) error {
+	return r.ExportTranscriptContext(context.Background(), threadID, w)
+}
+
+// ExportTranscriptContext is like ExportTranscript but uses ctx for the requests.
+func (r *ThreadsResource) ExportTranscriptContext(ctx context.Context, threadID string, w io.Writer) error {
+	thread, err := r.GetContext(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	threadEvents, err := r.GetEventsContext(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	events := make([]Event, len(threadEvents))
+	for i, e := range threadEvents {
+		events[i] = e.Event()
+	}
+	return WriteTranscript(w, thread.Title, events)
+}
+
+// Events returns the conversation as thread-style events, for use with
+// WriteTranscript. The summary of dropped turns, if any, is not included.
+func (c *Conversation) Events() []Event {
+	var events []Event
+	for _, inter := range c.Interactions {
+		if inter.UserMessage != nil {
+			events = append(events, &UserMessageEvent{Text: inter.UserMessage.Text})
+		}
+		events = append(events, actionEvents(inter.AssistantActions)...)
+	}
+	for _, a := range c.Artifacts {
+		events = append(events, &ArtifactCreatedEvent{Artifact: a})
+	}
+	return events
+}
+
+// Events returns the response as thread-style events, for use with
+// WriteTranscript.
+func (r *QueryResponse) Events() []Event {
+	events := actionEvents(r.AssistantActions)
+	for _, a := range r.ModifiedArtifacts {
+		events = append(events, &ArtifactCreatedEvent{Artifact: a})
+	}
+	return events
+}
+
+func actionEvents(actions []AssistantAction) []Event {
+	var events []Event
+	for _, a := range actions {
+		if a.Message != "" {
+			events = append(events, &AssistantMessageEvent{Text: a.Message})
+		}
+		if a.Plan != "" {
+			events = append(events, &PlanStepEvent{Plan: a.Plan})
+		}
+		if a.Code != "" || a.CodeOutput != "" || a.CodeError != "" {
+			events = append(events, &CodeExecutionEvent{Code: a.Code, Output: a.CodeOutput, Error: a.CodeError})
+		}
+	}
+	return events
+}
+
+// writeIndentedJSON writes data indented, keeping its key order.
+func writeIndentedJSON(w io.Writer, data json.RawMessage) error {
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return fmt.Errorf("decoding artifact data: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func fencedBlock(lang, body string) string {
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(body, "\n") + "\n" + fence + "\n"
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
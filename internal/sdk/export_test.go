@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func exportTestArtifact() Artifact {
+	return Artifact{
+		Identifier:   "users",
+		Title:        "Users",
+		ArtifactType: ArtifactTypeTable,
+		Data:         json.RawMessage(`[{"name":"alice","note":"a, b"},{"name":"bob|jr","age":7}]`),
+	}
+}
+
+func TestExportFormatForPath(t *testing.T) {
+	cases := map[string]ExportFormat{
+		"out.csv":        ExportCSV,
+		"/tmp/OUT.JSON":  ExportJSON,
+		"transcript.md":  ExportMarkdown,
+		"notes.markdown": ExportMarkdown,
+	}
+	for path, want := range cases {
+		got, err := ExportFormatForPath(path)
+		if err != nil || got != want {
+			t.Errorf("ExportFormatForPath(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := ExportFormatForPath("out.xlsx"); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+}
+
+func TestWriteArtifact_CSV(t *testing.T) {
+	var b strings.Builder
+	if err := WriteArtifact(&b, exportTestArtifact(), ExportCSV); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "name,note,age\nalice,\"a, b\",\nbob|jr,,7\n"
+	if b.String() != want {
+		t.Errorf("unexpected CSV:\nwant %q\ngot  %q", want, b.String())
+	}
+}
+
+func TestWriteArtifact_JSONKeepsKeyOrder(t *testing.T) {
+	var b strings.Builder
+	if err := WriteArtifact(&b, exportTestArtifact(), ExportJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+	if strings.Index(out, `"name"`) > strings.Index(out, `"note"`) {
+		t.Errorf("expected original key order, got:\n%s", out)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &rows); err != nil || len(rows) != 2 {
+		t.Errorf("expected valid JSON with 2 rows, got %v (%v)", rows, err)
+	}
+}
+
+func TestWriteArtifact_MarkdownEscapesCells(t *testing.T) {
+	var b strings.Builder
+	if err := WriteArtifact(&b, exportTestArtifact(), ExportMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(b.String(), `| bob\|jr |  | 7 |`) {
+		t.Errorf("expected escaped pipe in Markdown table, got:\n%s", b.String())
+	}
+
+	text := Artifact{Identifier: "t", ArtifactType: ArtifactTypeText, Data: json.RawMessage(`"hello"`)}
+	if err := WriteArtifact(&b, text, ExportCSV); err == nil {
+		t.Error("expected error exporting text artifact as CSV")
+	}
+}
+
+func TestExportTranscript(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		if strings.Contains(string(raw), "getThreadEvents") {
+			return jsonResponse(200, graphqlJSON(`{"getThreadEvents": [
+				{"thread_event_id": 1, "thread_id": "t-1", "event_data": {"user_message": {"text": "How many users?"}}},
+				{"thread_event_id": 2, "thread_id": "t-1", "event_data": {"code": "print(1)\n", "code_output": "1"}},
+				{"thread_event_id": 3, "thread_id": "t-1", "event_data": {"artifact": {"identifier": "users", "artifact_type": "table", "data": [{"n": 1}]}}},
+				{"thread_event_id": 4, "thread_id": "t-1", "event_data": {"assistant_message": {"text": "There is 1 user."}}}
+			]}`)), nil
+		}
+		return jsonResponse(200, graphqlJSON(`{"getThread": {"thread_id": "t-1", "title": "User count"}}`)), nil
+	})
+
+	var b strings.Builder
+	if err := client.Threads().ExportTranscript("t-1", &b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# User count\n",
+		"## User\n\nHow many users?\n",
+		"```python\nprint(1)\n```\n",
+		"Output:\n\n```\n1\n```\n",
+		"### users\n\n| n |\n| --- |\n| 1 |\n",
+		"## PromptQL\n\nThere is 1 user.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("transcript missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConversation_Events(t *testing.T) {
+	conv := &Conversation{}
+	conv.AddUserMessage("hi")
+	conv.Record(&QueryResponse{
+		AssistantActions:  []AssistantAction{{Message: "hello", Code: "x()"}},
+		ModifiedArtifacts: []Artifact{exportTestArtifact()},
+	})
+
+	events := conv.Events()
+	kinds := make([]EventKind, len(events))
+	for i, e := range events {
+		kinds[i] = e.Kind()
+	}
+	want := []EventKind{EventUserMessage, EventAssistantMessage, EventCodeExecution, EventArtifactCreated}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected kinds %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], kinds[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Extra holds JSON fields that a type does not model, so that values
+// decoded from the API survive being encoded and sent back unchanged.
+type Extra map[string]json.RawMessage
+
+// unmarshalWithExtra decodes data into known and collects every field that
+// known does not declare into extra.
+func unmarshalWithExtra(data []byte, known interface{}, extra *Extra) error {
+	if err := json.Unmarshal(data, known); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, name := range jsonFieldNames(known) {
+		delete(all, name)
+	}
+	if len(all) == 0 {
+		*extra = nil
+		return nil
+	}
+	*extra = all
+	return nil
+}
+
+// marshalWithExtra encodes known and merges in extra fields. Declared fields
+// take precedence over extra fields of the same name.
+func marshalWithExtra(known interface{}, extra Extra) ([]byte, error) {
+	data, err := json.Marshal(known)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if _, ok := all[k]; !ok {
+			all[k] = v
+		}
+	}
+	return json.Marshal(all)
+}
+
+// jsonFieldNames returns the JSON names of the fields declared by v's struct type.
+func jsonFieldNames(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func (i *Interaction) UnmarshalJSON(data []byte) error {
+	type plain Interaction
+	return unmarshalWithExtra(data, (*plain)(i), &i.Extra)
+}
+
+func (i Interaction) MarshalJSON() ([]byte, error) {
+	type plain Interaction
+	return marshalWithExtra(plain(i), i.Extra)
+}
+
+func (m *UserMessage) UnmarshalJSON(data []byte) error {
+	type plain UserMessage
+	return unmarshalWithExtra(data, (*plain)(m), &m.Extra)
+}
+
+func (m UserMessage) MarshalJSON() ([]byte, error) {
+	type plain UserMessage
+	return marshalWithExtra(plain(m), m.Extra)
+}
+
+func (a *AssistantAction) UnmarshalJSON(data []byte) error {
+	type plain AssistantAction
+	return unmarshalWithExtra(data, (*plain)(a), &a.Extra)
+}
+
+func (a AssistantAction) MarshalJSON() ([]byte, error) {
+	type plain AssistantAction
+	return marshalWithExtra(plain(a), a.Extra)
+}
+
+func (a *Artifact) UnmarshalJSON(data []byte) error {
+	type plain Artifact
+	return unmarshalWithExtra(data, (*plain)(a), &a.Extra)
+}
+
+func (a Artifact) MarshalJSON() ([]byte, error) {
+	type plain Artifact
+	return marshalWithExtra(plain(a), a.Extra)
+}
+
+func (r *QueryResponse) UnmarshalJSON(data []byte) error {
+	type plain QueryResponse
+	return unmarshalWithExtra(data, (*plain)(r), &r.Extra)
+}
+
+func (r QueryResponse) MarshalJSON() ([]byte, error) {
+	type plain QueryResponse
+	return marshalWithExtra(plain(r), r.Extra)
+}
@@ -1,5 +1,7 @@
 package sdk
 
+import "encoding/json"
+
 // TokenResponse is returned from the DDN token exchange endpoint.
 type TokenResponse struct {
 	Token  string `json:"token"`
@@ -39,6 +41,13 @@ type RuntimeAPIKey struct {
 	SQLTimeout      *int   `json:"sqlTimeout,omitempty"`
 }
 
+// GeneratedAPIKey is a newly generated runtime API key. APIKey is the
+// plaintext key, which the API returns only once.
+type GeneratedAPIKey struct {
+	RuntimeAPIKey
+	APIKey string `json:"apiKey"`
+}
+
 // UserProject is a project visible to the current user.
 type UserProject struct {
 	BuildFQDN    string `json:"buildFqdn,omitempty"`
@@ -70,11 +79,12 @@ type LookupProjectResult struct {
 
 // ThreadEvent is an event within a thread.
 type ThreadEvent struct {
-	ThreadEventID int                    `json:"thread_event_id"`
-	ThreadID      string                 `json:"thread_id,omitempty"`
-	EventData     map[string]interface{} `json:"event_data,omitempty"`
-	CreatedAt     string                 `json:"created_at,omitempty"`
-	UserID        string                 `json:"user_id,omitempty"`
+	ThreadEventID int    `json:"thread_event_id"`
+	ThreadID      string `json:"thread_id,omitempty"`
+	// EventData is the raw payload; use Event to decode it.
+	EventData json.RawMessage `json:"event_data,omitempty"`
+	CreatedAt string          `json:"created_at,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
 }
 
 // Thread is a conversation thread.
@@ -100,11 +110,18 @@ type StartThreadResult struct {
 
 // SendMessageResult is the result of sending a message to a thread.
 type SendMessageResult struct {
-	ThreadEventID int                    `json:"thread_event_id"`
-	EventData     map[string]interface{} `json:"event_data,omitempty"`
-	CreatedAt     string                 `json:"created_at,omitempty"`
+	ThreadEventID int `json:"thread_event_id"`
+	// EventData is the raw payload; use Event to decode it.
+	EventData json.RawMessage `json:"event_data,omitempty"`
+	CreatedAt string          `json:"created_at,omitempty"`
 }
 
+// Feedback values accepted by SubmitFeedback.
+const (
+	FeedbackPositive = 1
+	FeedbackNegative = -1
+)
+
 // ThreadFeedback holds feedback on a thread message.
 type ThreadFeedback struct {
 	ThreadID       string `json:"thread_id"`
@@ -134,6 +151,54 @@ type Program struct {
 	UpdatedAt  string `json:"updated_at,omitempty"`
 }
 
+// Interaction is one conversational turn sent to the query endpoint: a user
+// message and, for previous turns, the assistant's actions in reply. Role is
+// "user" for turns started by the user, as the API has always been sent.
+type Interaction struct {
+	Role             string            `json:"role,omitempty"`
+	UserMessage      *UserMessage      `json:"user_message,omitempty"`
+	AssistantActions []AssistantAction `json:"assistant_actions,omitempty"`
+	Extra            Extra             `json:"-"`
+}
+
+// UserMessage is the user's side of an interaction.
+type UserMessage struct {
+	Text  string `json:"text"`
+	Extra Extra  `json:"-"`
+}
+
+// AssistantAction is one step of an assistant response to a query.
+type AssistantAction struct {
+	Message    string `json:"message,omitempty"`
+	Plan       string `json:"plan,omitempty"`
+	Code       string `json:"code,omitempty"`
+	CodeOutput string `json:"code_output,omitempty"`
+	CodeError  string `json:"code_error,omitempty"`
+	Extra      Extra  `json:"-"`
+}
+
+// Artifact types produced by PromptQL programs.
+const (
+	ArtifactTypeTable = "table"
+	ArtifactTypeText  = "text"
+)
+
+// Artifact is a piece of data (e.g. a table or text) produced by a PromptQL program.
+type Artifact struct {
+	Identifier   string          `json:"identifier"`
+	Title        string          `json:"title,omitempty"`
+	ArtifactType string          `json:"artifact_type"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	Extra        Extra           `json:"-"`
+}
+
+// QueryResponse is the result of a natural language query.
+type QueryResponse struct {
+	AssistantActions  []AssistantAction `json:"assistant_actions"`
+	ModifiedArtifacts []Artifact        `json:"modified_artifacts,omitempty"`
+	Extra             Extra             `json:"-"`
+}
+
 // MessageResult is a generic message result from mutation operations.
 type MessageResult struct {
 	Message string `json:"message"`
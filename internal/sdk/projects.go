@@ -1,5 +1,7 @@
 package sdk
 
+import "context"
+
 // ProjectsResource provides operations on PromptQL projects.
 type ProjectsResource struct {
 	client *Client
@@ -7,6 +9,11 @@ type ProjectsResource struct {
 
 // GetConfig fetches the PromptQL feature configuration for a project.
 func (r *ProjectsResource) GetConfig(projectID string) (*PromptQLConfig, error) {
+	return r.GetConfigContext(context.Background(), projectID)
+}
+
+// GetConfigContext is like GetConfig but uses ctx for the request.
+func (r *ProjectsResource) GetConfigContext(ctx context.Context, projectID string) (*PromptQLConfig, error) {
 	query := `
 		query GetPromptQLConfig($projectId: String!) {
 			getPromptQlConfig(projectId: $projectId) {
@@ -14,7 +21,7 @@ func (r *ProjectsResource) GetConfig(projectID string) (*PromptQLConfig, error)
 				playgroundEnabled
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -27,6 +34,11 @@ func (r *ProjectsResource) GetConfig(projectID string) (*PromptQLConfig, error)
 
 // GetPlaygroundConfig fetches the playground configuration for a project.
 func (r *ProjectsResource) GetPlaygroundConfig(projectID string) (*PlaygroundConfig, error) {
+	return r.GetPlaygroundConfigContext(context.Background(), projectID)
+}
+
+// GetPlaygroundConfigContext is like GetPlaygroundConfig but uses ctx for the request.
+func (r *ProjectsResource) GetPlaygroundConfigContext(ctx context.Context, projectID string) (*PlaygroundConfig, error) {
 	query := `
 		query GetPlaygroundConfig($projectId: String!) {
 			getPlaygroundConfig(projectId: $projectId) {
@@ -40,7 +52,7 @@ func (r *ProjectsResource) GetPlaygroundConfig(projectID string) (*PlaygroundCon
 				userTokenUsageLimit
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -51,9 +63,99 @@ func (r *ProjectsResource) GetPlaygroundConfig(projectID string) (*PlaygroundCon
 	return &result, nil
 }
 
+// PlaygroundConfigUpdate lists playground settings to change. Nil fields
+// are left unchanged.
+type PlaygroundConfigUpdate struct {
+	AllowPublicAccess      *bool
+	FeatureFlags           map[string]interface{}
+	LLMProvider            *string
+	ProjectTokenUsageLimit *int
+	Readme                 *string
+	SystemInstructions     *string
+	UserTokenUsageLimit    *int
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u PlaygroundConfigUpdate) IsEmpty() bool {
+	return u.AllowPublicAccess == nil && u.FeatureFlags == nil && u.LLMProvider == nil &&
+		u.ProjectTokenUsageLimit == nil && u.Readme == nil && u.SystemInstructions == nil &&
+		u.UserTokenUsageLimit == nil
+}
+
+// UpdatePlaygroundConfig changes a project's playground settings and
+// returns the resulting configuration.
+func (r *ProjectsResource) UpdatePlaygroundConfig(projectID string, update PlaygroundConfigUpdate) (*PlaygroundConfig, error) {
+	return r.UpdatePlaygroundConfigContext(context.Background(), projectID, update)
+}
+
+// UpdatePlaygroundConfigContext is like UpdatePlaygroundConfig but uses ctx for the request.
+func (r *ProjectsResource) UpdatePlaygroundConfigContext(ctx context.Context, projectID string, update PlaygroundConfigUpdate) (*PlaygroundConfig, error) {
+	query := `
+		mutation UpdatePlaygroundConfig($projectId: String!, $allowPublicAccess: Boolean, $featureFlags: JSON, $llmProvider: String, $projectTokenUsageLimit: Int, $readme: String, $systemInstructions: String, $userTokenUsageLimit: Int) {
+			updatePlaygroundConfig(projectId: $projectId, allowPublicAccess: $allowPublicAccess, featureFlags: $featureFlags, llmProvider: $llmProvider, projectTokenUsageLimit: $projectTokenUsageLimit, readme: $readme, systemInstructions: $systemInstructions, userTokenUsageLimit: $userTokenUsageLimit) {
+				allowPublicAccess
+				featureFlags
+				llmApiKey
+				llmProvider
+				projectTokenUsageLimit
+				readme
+				systemInstructions
+				userTokenUsageLimit
+			}
+		}`
+	variables := map[string]interface{}{"projectId": projectID}
+	if update.AllowPublicAccess != nil {
+		variables["allowPublicAccess"] = *update.AllowPublicAccess
+	}
+	if update.FeatureFlags != nil {
+		variables["featureFlags"] = update.FeatureFlags
+	}
+	if update.LLMProvider != nil {
+		variables["llmProvider"] = *update.LLMProvider
+	}
+	if update.ProjectTokenUsageLimit != nil {
+		variables["projectTokenUsageLimit"] = *update.ProjectTokenUsageLimit
+	}
+	if update.Readme != nil {
+		variables["readme"] = *update.Readme
+	}
+	if update.SystemInstructions != nil {
+		variables["systemInstructions"] = *update.SystemInstructions
+	}
+	if update.UserTokenUsageLimit != nil {
+		variables["userTokenUsageLimit"] = *update.UserTokenUsageLimit
+	}
+
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
+	if err != nil {
+		return nil, err
+	}
+	var result PlaygroundConfig
+	if err := decodeJSONField(data, "updatePlaygroundConfig", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateSystemInstructions replaces a project's playground system
+// instructions, leaving its other settings unchanged.
+func (r *ProjectsResource) UpdateSystemInstructions(projectID, instructions string) (*PlaygroundConfig, error) {
+	return r.UpdateSystemInstructionsContext(context.Background(), projectID, instructions)
+}
+
+// UpdateSystemInstructionsContext is like UpdateSystemInstructions but uses ctx for the request.
+func (r *ProjectsResource) UpdateSystemInstructionsContext(ctx context.Context, projectID, instructions string) (*PlaygroundConfig, error) {
+	return r.UpdatePlaygroundConfigContext(ctx, projectID, PlaygroundConfigUpdate{SystemInstructions: &instructions})
+}
+
 // ListUserProjects lists all projects visible to the authenticated user
 // by querying the DDN control-plane API, including their latest build FQDN.
 func (r *ProjectsResource) ListUserProjects() ([]UserProject, error) {
+	return r.ListUserProjectsContext(context.Background())
+}
+
+// ListUserProjectsContext is like ListUserProjects but uses ctx for the request.
+func (r *ProjectsResource) ListUserProjectsContext(ctx context.Context) ([]UserProject, error) {
 	query := `
 		query ListProjects {
 			ddn_projects(order_by: {created_at: desc}) {
@@ -64,7 +166,7 @@ func (r *ProjectsResource) ListUserProjects() ([]UserProject, error) {
 				}
 			}
 		}`
-	data, err := r.client.GraphQLControlPlane(query, nil, "pat")
+	data, err := r.client.GraphQLControlPlaneContext(ctx, query, nil, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +204,11 @@ type LookupOptions struct {
 
 // Lookup looks up a project by ID, name, or FQDN.
 func (r *ProjectsResource) Lookup(opts LookupOptions) (*LookupProjectResult, error) {
+	return r.LookupContext(context.Background(), opts)
+}
+
+// LookupContext is like Lookup but uses ctx for the request.
+func (r *ProjectsResource) LookupContext(ctx context.Context, opts LookupOptions) (*LookupProjectResult, error) {
 	query := `
 		query LookupProject($projectId: String, $projectName: String, $fqdn: String) {
 			lookupProject(projectId: $projectId, projectName: $projectName, fqdn: $fqdn) {
@@ -123,7 +230,7 @@ func (r *ProjectsResource) Lookup(opts LookupOptions) (*LookupProjectResult, err
 		variables["fqdn"] = opts.FQDN
 	}
 
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -136,13 +243,18 @@ func (r *ProjectsResource) Lookup(opts LookupOptions) (*LookupProjectResult, err
 
 // Enable enables PromptQL for a project.
 func (r *ProjectsResource) Enable(projectID string) (*MessageResult, error) {
+	return r.EnableContext(context.Background(), projectID)
+}
+
+// EnableContext is like Enable but uses ctx for the request.
+func (r *ProjectsResource) EnableContext(ctx context.Context, projectID string) (*MessageResult, error) {
 	query := `
 		mutation EnablePromptQL($projectId: String!) {
 			enablePromptQl(projectId: $projectId) {
 				message
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -155,13 +267,18 @@ func (r *ProjectsResource) Enable(projectID string) (*MessageResult, error) {
 
 // Disable disables PromptQL for a project.
 func (r *ProjectsResource) Disable(projectID string) (*MessageResult, error) {
+	return r.DisableContext(context.Background(), projectID)
+}
+
+// DisableContext is like Disable but uses ctx for the request.
+func (r *ProjectsResource) DisableContext(ctx context.Context, projectID string) (*MessageResult, error) {
 	query := `
 		mutation DisablePromptQL($projectId: String!) {
 			disablePromptQl(projectId: $projectId) {
 				message
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,9 @@
 package sdk
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"testing"
 )
@@ -250,6 +252,88 @@ func TestGetPlaygroundConfig_MalformedResponse(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// UpdatePlaygroundConfig
+// ---------------------------------------------------------------------------
+
+func TestUpdatePlaygroundConfig_SendsOnlyChangedFields(t *testing.T) {
+	var sent struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sent)
+		body := graphqlJSON(`{
+			"updatePlaygroundConfig": {
+				"llmProvider": "anthropic",
+				"systemInstructions": "Be brief",
+				"userTokenUsageLimit": 500
+			}
+		}`)
+		return jsonResponse(200, body), nil
+	})
+
+	provider, limit := "anthropic", 500
+	cfg, err := client.Projects().UpdatePlaygroundConfig("proj-1", PlaygroundConfigUpdate{
+		LLMProvider:         &provider,
+		UserTokenUsageLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLMProvider != "anthropic" || cfg.UserTokenUsageLimit == nil || *cfg.UserTokenUsageLimit != 500 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if !containsString(sent.Query, "updatePlaygroundConfig") {
+		t.Errorf("expected updatePlaygroundConfig mutation, got %q", sent.Query)
+	}
+	want := map[string]interface{}{"projectId": "proj-1", "llmProvider": "anthropic", "userTokenUsageLimit": float64(500)}
+	if len(sent.Variables) != len(want) {
+		t.Fatalf("expected variables %v, got %v", want, sent.Variables)
+	}
+	for k, v := range want {
+		if sent.Variables[k] != v {
+			t.Errorf("variable %q: expected %v, got %v", k, v, sent.Variables[k])
+		}
+	}
+}
+
+func TestPlaygroundConfigUpdate_IsEmpty(t *testing.T) {
+	if !(PlaygroundConfigUpdate{}).IsEmpty() {
+		t.Error("expected zero update to be empty")
+	}
+	readme := ""
+	if (PlaygroundConfigUpdate{Readme: &readme}).IsEmpty() {
+		t.Error("expected update clearing the readme not to be empty")
+	}
+	if (PlaygroundConfigUpdate{FeatureFlags: map[string]interface{}{}}).IsEmpty() {
+		t.Error("expected update replacing feature flags not to be empty")
+	}
+}
+
+func TestUpdateSystemInstructions_SendsOnlyInstructions(t *testing.T) {
+	var sent struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sent)
+		return jsonResponse(200, graphqlJSON(`{"updatePlaygroundConfig": {"systemInstructions": ""}}`)), nil
+	})
+
+	cfg, err := client.Projects().UpdateSystemInstructions("proj-1", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SystemInstructions != "" {
+		t.Errorf("unexpected instructions %q", cfg.SystemInstructions)
+	}
+	if len(sent.Variables) != 2 || sent.Variables["systemInstructions"] != "" {
+		t.Errorf("expected only projectId and systemInstructions, got %v", sent.Variables)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // helpers
 // ---------------------------------------------------------------------------
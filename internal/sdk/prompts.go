@@ -1,5 +1,7 @@
 package sdk
 
+import "context"
+
 // PromptsResource provides CRUD operations on sample prompts.
 type PromptsResource struct {
 	client *Client
@@ -7,6 +9,11 @@ type PromptsResource struct {
 
 // List lists all sample prompts for a project.
 func (r *PromptsResource) List(projectID string) ([]SamplePrompt, error) {
+	return r.ListContext(context.Background(), projectID)
+}
+
+// ListContext is like List but uses ctx for the request.
+func (r *PromptsResource) ListContext(ctx context.Context, projectID string) ([]SamplePrompt, error) {
 	query := `
 		query ListSamplePrompts($projectId: String!) {
 			getSamplePrompts(projectId: $projectId) {
@@ -20,7 +27,7 @@ func (r *PromptsResource) List(projectID string) ([]SamplePrompt, error) {
 				updatedAt
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"projectId": projectID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"projectId": projectID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +40,11 @@ func (r *PromptsResource) List(projectID string) ([]SamplePrompt, error) {
 
 // Create creates a new sample prompt.
 func (r *PromptsResource) Create(projectID, displayText, fullPrompt string) (*SamplePrompt, error) {
+	return r.CreateContext(context.Background(), projectID, displayText, fullPrompt)
+}
+
+// CreateContext is like Create but uses ctx for the request.
+func (r *PromptsResource) CreateContext(ctx context.Context, projectID, displayText, fullPrompt string) (*SamplePrompt, error) {
 	query := `
 		mutation CreateSamplePrompt($projectId: String!, $displayText: String!, $fullPrompt: String!) {
 			createSamplePrompt(projectId: $projectId, displayText: $displayText, fullPrompt: $fullPrompt) {
@@ -51,7 +63,7 @@ func (r *PromptsResource) Create(projectID, displayText, fullPrompt string) (*Sa
 		"displayText": displayText,
 		"fullPrompt":  fullPrompt,
 	}
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +76,11 @@ func (r *PromptsResource) Create(projectID, displayText, fullPrompt string) (*Sa
 
 // Update updates an existing sample prompt.
 func (r *PromptsResource) Update(projectID, promptID, displayText, fullPrompt string) (*SamplePrompt, error) {
+	return r.UpdateContext(context.Background(), projectID, promptID, displayText, fullPrompt)
+}
+
+// UpdateContext is like Update but uses ctx for the request.
+func (r *PromptsResource) UpdateContext(ctx context.Context, projectID, promptID, displayText, fullPrompt string) (*SamplePrompt, error) {
 	query := `
 		mutation UpdateSamplePrompt($projectId: String!, $promptId: String!, $displayText: String!, $fullPrompt: String!) {
 			updateSamplePrompt(projectId: $projectId, promptId: $promptId, displayText: $displayText, fullPrompt: $fullPrompt) {
@@ -83,7 +100,7 @@ func (r *PromptsResource) Update(projectID, promptID, displayText, fullPrompt st
 		"displayText": displayText,
 		"fullPrompt":  fullPrompt,
 	}
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +113,11 @@ func (r *PromptsResource) Update(projectID, promptID, displayText, fullPrompt st
 
 // Delete deletes a sample prompt.
 func (r *PromptsResource) Delete(projectID, promptID string) (*MessageResult, error) {
+	return r.DeleteContext(context.Background(), projectID, promptID)
+}
+
+// DeleteContext is like Delete but uses ctx for the request.
+func (r *PromptsResource) DeleteContext(ctx context.Context, projectID, promptID string) (*MessageResult, error) {
 	query := `
 		mutation DeleteSamplePrompt($projectId: String!, $promptId: String!) {
 			deleteSamplePrompt(projectId: $projectId, promptId: $promptId) {
@@ -106,7 +128,7 @@ func (r *PromptsResource) Delete(projectID, promptID string) (*MessageResult, er
 		"projectId": projectID,
 		"promptId":  promptID,
 	}
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -1,5 +1,14 @@
 package sdk
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
 // QueryResource provides natural language query execution.
 type QueryResource struct {
 	client *Client
@@ -7,16 +16,112 @@ type QueryResource struct {
 
 // ExecuteOptions configures a natural language query.
 type ExecuteOptions struct {
-	Interactions []map[string]interface{}
+	Interactions []Interaction
+	Artifacts    []Artifact // artifacts from earlier turns the query may reference
 	DDNURL       string
 	DDNHeaders   map[string]string
 	Timezone     string // defaults to "UTC"
-	Stream       bool
 	Version      string // defaults to "v1"
+	// ProjectID is the project whose DDN token is added to DDNHeaders for
+	// clients with a PAT but no API key. Defaults to the client's ProjectID.
+	ProjectID string
 }
 
 // Execute sends a natural language query to the PromptQL query endpoint.
-func (r *QueryResource) Execute(opts ExecuteOptions) (map[string]interface{}, error) {
+func (r *QueryResource) Execute(opts ExecuteOptions) (*QueryResponse, error) {
+	return r.ExecuteContext(context.Background(), opts)
+}
+
+// ExecuteContext is like Execute but uses ctx for the request.
+func (r *QueryResource) ExecuteContext(ctx context.Context, opts ExecuteOptions) (*QueryResponse, error) {
+	resp, err := r.post(ctx, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result QueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &result, nil
+}
+
+// ExecuteStream sends a natural language query and returns a stream of
+// incremental chunks as the server produces them.
+func (r *QueryResource) ExecuteStream(opts ExecuteOptions) (*QueryStream, error) {
+	return r.ExecuteStreamContext(context.Background(), opts)
+}
+
+// ExecuteStreamContext is like ExecuteStream but uses ctx for the request.
+// Cancelling ctx aborts the stream.
+func (r *QueryResource) ExecuteStreamContext(ctx context.Context, opts ExecuteOptions) (*QueryStream, error) {
+	resp, err := r.post(ctx, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return newQueryStream(resp.Body), nil
+}
+
+// post sends a query. If the DDN token added by withDDNToken is rejected,
+// e.g. because it was revoked before it expired, it is dropped and the
+// query is sent once more with a new one.
+func (r *QueryResource) post(ctx context.Context, opts ExecuteOptions, stream bool) (*http.Response, error) {
+	withToken, projectID, err := r.withDDNToken(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.send(ctx, withToken, stream)
+	var authErr *AuthenticationError
+	if projectID == "" || !errors.As(err, &authErr) {
+		return resp, err
+	}
+	r.client.tokens.Invalidate(projectID)
+	if withToken, _, err = r.withDDNToken(ctx, opts); err != nil {
+		return nil, err
+	}
+	return r.send(ctx, withToken, stream)
+}
+
+func (r *QueryResource) send(ctx context.Context, opts ExecuteOptions, stream bool) (*http.Response, error) {
+	if stream {
+		return r.client.PostAPIStream(ctx, "/query", queryBody(opts, true), "query")
+	}
+	return r.client.postAPI(ctx, r.client.http, "/query", queryBody(opts, false), "query", "application/json")
+}
+
+// withDDNToken adds a DDN token to the DDN headers of clients that have a
+// PAT but no API key, unless the caller set an Authorization header. It
+// returns the project the token is for, or "" if none was added.
+func (r *QueryResource) withDDNToken(ctx context.Context, opts ExecuteOptions) (ExecuteOptions, string, error) {
+	c := r.client
+	if c.apiKey != "" || c.pat == "" {
+		return opts, "", nil
+	}
+	for k := range opts.DDNHeaders {
+		if strings.EqualFold(k, "Authorization") {
+			return opts, "", nil
+		}
+	}
+	projectID := opts.ProjectID
+	if projectID == "" {
+		projectID = c.ProjectID
+	}
+	token, err := c.tokens.TokenContext(ctx, projectID)
+	if err != nil {
+		return opts, "", fmt.Errorf("obtaining DDN token: %w", err)
+	}
+	headers := make(map[string]string, len(opts.DDNHeaders)+1)
+	for k, v := range opts.DDNHeaders {
+		headers[k] = v
+	}
+	headers["Authorization"] = "Bearer " + token.Token
+	opts.DDNHeaders = headers
+	return opts, projectID, nil
+}
+
+// queryBody builds the /query request payload.
+func queryBody(opts ExecuteOptions, stream bool) map[string]interface{} {
 	tz := opts.Timezone
 	if tz == "" {
 		tz = "UTC"
@@ -35,34 +140,54 @@ func (r *QueryResource) Execute(opts ExecuteOptions) (map[string]interface{}, er
 		ddn["headers"] = headers
 	}
 
+	interactions := opts.Interactions
+	if interactions == nil {
+		interactions = []Interaction{}
+	}
 	body := map[string]interface{}{
 		"version":      version,
-		"stream":       opts.Stream,
+		"stream":       stream,
 		"timezone":     tz,
-		"interactions": opts.Interactions,
+		"interactions": interactions,
 		"ddn":          ddn,
 	}
-
-	return r.client.PostAPI("/query", body, "bearer")
+	if len(opts.Artifacts) > 0 {
+		body["artifacts"] = opts.Artifacts
+	}
+	return body
 }
 
 // Ask is a convenience method that sends a single user question.
-func (r *QueryResource) Ask(question, ddnURL string, ddnHeaders map[string]string, timezone string) (map[string]interface{}, error) {
-	if timezone == "" {
-		timezone = "UTC"
-	}
-	interactions := []map[string]interface{}{
-		{
-			"role": "user",
-			"user_message": map[string]interface{}{
-				"text": question,
-			},
-		},
-	}
-	return r.Execute(ExecuteOptions{
-		Interactions: interactions,
+func (r *QueryResource) Ask(question, ddnURL string, ddnHeaders map[string]string, timezone string) (*QueryResponse, error) {
+	return r.AskContext(context.Background(), question, ddnURL, ddnHeaders, timezone)
+}
+
+// AskContext is like Ask but uses ctx for the request.
+func (r *QueryResource) AskContext(ctx context.Context, question, ddnURL string, ddnHeaders map[string]string, timezone string) (*QueryResponse, error) {
+	return r.ExecuteContext(ctx, ExecuteOptions{
+		Interactions: []Interaction{NewUserInteraction(question)},
 		DDNURL:       ddnURL,
 		DDNHeaders:   ddnHeaders,
 		Timezone:     timezone,
 	})
 }
+
+// AskStream is like Ask but streams the response.
+func (r *QueryResource) AskStream(question, ddnURL string, ddnHeaders map[string]string, timezone string) (*QueryStream, error) {
+	return r.AskStreamContext(context.Background(), question, ddnURL, ddnHeaders, timezone)
+}
+
+// AskStreamContext is like AskStream but uses ctx for the request.
+func (r *QueryResource) AskStreamContext(ctx context.Context, question, ddnURL string, ddnHeaders map[string]string, timezone string) (*QueryStream, error) {
+	return r.ExecuteStreamContext(ctx, ExecuteOptions{
+		Interactions: []Interaction{NewUserInteraction(question)},
+		DDNURL:       ddnURL,
+		DDNHeaders:   ddnHeaders,
+		Timezone:     timezone,
+	})
+}
+
+// NewUserInteraction returns an interaction carrying a single user message.
+func NewUserInteraction(text string) Interaction {
+	return Interaction{Role: "user", UserMessage: &UserMessage{Text: text}}
+}
@@ -0,0 +1,307 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newQueryTestClient(fn func(*http.Request) (*http.Response, error)) *Client {
+	return NewClient(ClientOptions{
+		APIKey: "test-key",
+		APIURL: "https://api.test.example.com",
+		HTTPClient: &http.Client{
+			Transport: &mockRoundTripper{fn: fn},
+		},
+	})
+}
+
+func collectChunks(t *testing.T, stream *QueryStream) []QueryChunk {
+	t.Helper()
+	defer stream.Close()
+	var chunks []QueryChunk
+	for stream.Next() {
+		chunks = append(chunks, stream.Chunk())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	return chunks
+}
+
+// ---------------------------------------------------------------------------
+// ExecuteStream
+// ---------------------------------------------------------------------------
+
+func TestExecuteStream_SSE(t *testing.T) {
+	var sentBody map[string]interface{}
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sentBody)
+		body := ": keep-alive\n\n" +
+			"event: message\n" +
+			`data: {"type":"assistant_action_chunk","index":0,"message":"There are "}` + "\n\n" +
+			`data: {"type":"assistant_action_chunk","index":0,"message":"42 users."}` + "\n\n" +
+			`data: {"type":"artifact_update_chunk","artifact":{"identifier":"users","title":"Users","artifact_type":"table","data":[{"id":1}]}}` + "\n\n" +
+			"data: [DONE]\n\n"
+		return jsonResponse(200, body), nil
+	})
+
+	stream, err := client.Query().AskStream("how many users?", "https://ddn.example.com/graphql", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunks := collectChunks(t, stream)
+
+	if sentBody["stream"] != true {
+		t.Errorf("expected stream=true in request body, got %v", sentBody["stream"])
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Type != ChunkAssistantAction || chunks[0].Message != "There are " {
+		t.Errorf("unexpected first chunk: %+v", chunks[0])
+	}
+	if chunks[2].Type != ChunkArtifactUpdate || chunks[2].Artifact == nil || chunks[2].Artifact.Identifier != "users" {
+		t.Errorf("unexpected artifact chunk: %+v", chunks[2])
+	}
+
+	var resp QueryResponse
+	for _, c := range chunks {
+		resp.Apply(c)
+	}
+	if len(resp.AssistantActions) != 1 || resp.AssistantActions[0].Message != "There are 42 users." {
+		t.Errorf("unexpected accumulated actions: %+v", resp.AssistantActions)
+	}
+	if len(resp.ModifiedArtifacts) != 1 {
+		t.Errorf("expected 1 artifact, got %d", len(resp.ModifiedArtifacts))
+	}
+}
+
+func TestExecuteStream_NDJSON(t *testing.T) {
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		body := `{"type":"assistant_action_chunk","index":0,"code":"print(1)"}` + "\n" +
+			`{"type":"assistant_action_chunk","index":1,"message":"done"}` + "\n" +
+			`{"type":"error_chunk","error":"sql timeout"}` + "\n"
+		return jsonResponse(200, body), nil
+	})
+
+	stream, err := client.Query().ExecuteStream(ExecuteOptions{DDNURL: "https://ddn.example.com/graphql"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunks := collectChunks(t, stream)
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[2].Type != ChunkError || chunks[2].Error != "sql timeout" {
+		t.Errorf("unexpected error chunk: %+v", chunks[2])
+	}
+
+	var resp QueryResponse
+	for _, c := range chunks {
+		resp.Apply(c)
+	}
+	if len(resp.AssistantActions) != 2 {
+		t.Fatalf("expected 2 actions, got %d", len(resp.AssistantActions))
+	}
+	if resp.AssistantActions[0].Code != "print(1)" {
+		t.Errorf("expected code 'print(1)', got %q", resp.AssistantActions[0].Code)
+	}
+}
+
+func TestExecuteStream_RejectsSkippedActionIndex(t *testing.T) {
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		body := `{"type":"assistant_action_chunk","index":0,"message":"ok"}` + "\n" +
+			`{"type":"assistant_action_chunk","index":1000000000,"message":"huge"}` + "\n"
+		return jsonResponse(200, body), nil
+	})
+
+	stream, err := client.Query().ExecuteStream(ExecuteOptions{DDNURL: "https://ddn.example.com/graphql"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+	n := 0
+	for stream.Next() {
+		n++
+	}
+	if n != 1 || stream.Err() == nil || !strings.Contains(stream.Err().Error(), "decoding chunk") {
+		t.Errorf("expected a decode error after the first chunk, got %d chunks and %v", n, stream.Err())
+	}
+
+	var resp QueryResponse
+	resp.Apply(QueryChunk{Type: ChunkAssistantAction, Index: 1000000000, Message: "huge"})
+	if len(resp.AssistantActions) != 0 {
+		t.Errorf("expected the out of order chunk to be ignored, got %d actions", len(resp.AssistantActions))
+	}
+}
+
+func TestExecuteStream_AuthError(t *testing.T) {
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(401, `{"message":"invalid api key"}`), nil
+	})
+
+	_, err := client.Query().ExecuteStream(ExecuteOptions{})
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Errorf("expected *AuthenticationError, got %T: %v", err, err)
+	}
+}
+
+func TestExecuteStream_MalformedChunk(t *testing.T) {
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(200, "data: {broken\n\n"), nil
+	})
+
+	stream, err := client.Query().ExecuteStream(ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+	if stream.Next() {
+		t.Fatal("expected Next to return false on malformed chunk")
+	}
+	if stream.Err() == nil {
+		t.Fatal("expected stream error, got nil")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Execute / typed interactions
+// ---------------------------------------------------------------------------
+
+func TestExecute_TypedResponse(t *testing.T) {
+	var sent struct {
+		Interactions []Interaction `json:"interactions"`
+		Stream       bool          `json:"stream"`
+	}
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sent)
+		return jsonResponse(200, `{
+			"assistant_actions": [{"message": "Here you go", "code": "sql()", "code_output": "ok"}],
+			"modified_artifacts": [{"identifier": "t1", "title": "Top", "artifact_type": "table", "data": [{"name": "a", "n": 1}]}]
+		}`), nil
+	})
+
+	history := []Interaction{
+		{
+			UserMessage:      &UserMessage{Text: "first"},
+			AssistantActions: []AssistantAction{{Message: "reply"}},
+		},
+		NewUserInteraction("second"),
+	}
+	resp, err := client.Query().Execute(ExecuteOptions{Interactions: history, DDNURL: "https://ddn.example.com/graphql"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sent.Stream {
+		t.Error("expected stream=false for Execute")
+	}
+	if len(sent.Interactions) != 2 || sent.Interactions[1].UserMessage == nil || sent.Interactions[1].UserMessage.Text != "second" {
+		t.Errorf("unexpected interactions sent: %+v", sent.Interactions)
+	}
+	if len(resp.AssistantActions) != 1 || resp.AssistantActions[0].Message != "Here you go" {
+		t.Errorf("unexpected assistant actions: %+v", resp.AssistantActions)
+	}
+	if resp.AssistantActions[0].CodeOutput != "ok" {
+		t.Errorf("expected code output 'ok', got %q", resp.AssistantActions[0].CodeOutput)
+	}
+	if len(resp.ModifiedArtifacts) != 1 || resp.ModifiedArtifacts[0].ArtifactType != ArtifactTypeTable {
+		t.Errorf("unexpected artifacts: %+v", resp.ModifiedArtifacts)
+	}
+}
+
+func TestInteraction_PreservesUnknownFields(t *testing.T) {
+	in := `{"user_message":{"text":"hi","attachments":[1]},"assistant_actions":[{"message":"yo","confidence":0.9}],"timestamp":"2025-01-01"}`
+
+	var inter Interaction
+	if err := json.Unmarshal([]byte(in), &inter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inter.UserMessage.Text != "hi" {
+		t.Errorf("expected text 'hi', got %q", inter.UserMessage.Text)
+	}
+	if string(inter.Extra["timestamp"]) != `"2025-01-01"` {
+		t.Errorf("expected timestamp in Extra, got %v", inter.Extra)
+	}
+
+	out, err := json.Marshal(inter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var want, got interface{}
+	_ = json.Unmarshal([]byte(in), &want)
+	_ = json.Unmarshal(out, &got)
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(wantJSON) != string(gotJSON) {
+		t.Errorf("round trip mismatch:\nwant %s\ngot  %s", wantJSON, gotJSON)
+	}
+}
+
+func TestArtifact_Table(t *testing.T) {
+	a := Artifact{
+		Identifier:   "t1",
+		ArtifactType: ArtifactTypeTable,
+		Data:         json.RawMessage(`[{"name":"alice","age":30},{"name":"bob","city":"NYC"}]`),
+	}
+	table, err := a.Table()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantCols := []string{"name", "age", "city"}
+	if len(table.Columns) != len(wantCols) {
+		t.Fatalf("expected columns %v, got %v", wantCols, table.Columns)
+	}
+	for i, c := range wantCols {
+		if table.Columns[i] != c {
+			t.Errorf("column %d: expected %q, got %q", i, c, table.Columns[i])
+		}
+	}
+	if len(table.Rows) != 2 || len(table.Rows[0]) != 3 {
+		t.Fatalf("unexpected rows: %v", table.Rows)
+	}
+	if table.Rows[0][1] != json.Number("30") {
+		t.Errorf("expected age json.Number(30), got %#v", table.Rows[0][1])
+	}
+	if table.Rows[1][1] != nil {
+		t.Errorf("expected missing age to be nil, got %#v", table.Rows[1][1])
+	}
+
+	if _, err := (Artifact{ArtifactType: ArtifactTypeText}).Table(); err == nil {
+		t.Error("expected error decoding text artifact as table")
+	}
+}
+
+func TestQueryStream_CloseWhileReading(t *testing.T) {
+	pr, pw := io.Pipe()
+	stream := newQueryStream(pr)
+	go func() {
+		_, _ = pw.Write([]byte(`{"type":"assistant_action_chunk","index":0,"message":"hi"}` + "\n"))
+	}()
+	if !stream.Next() {
+		t.Fatal("expected the first chunk")
+	}
+
+	done := make(chan bool)
+	go func() { done <- stream.Next() }()
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if <-done {
+		t.Error("expected Next to stop once the stream is closed")
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("expected no error after Close, got %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("expected a second Close to be a no-op, got %v", err)
+	}
+}
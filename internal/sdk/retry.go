@@ -0,0 +1,203 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of failed requests.
+//
+// Requests that may change server state (GraphQL mutations such as
+// startThread or sendMessage, and /query executions, which can run actions
+// against DDN) are only replayed when they never reached the server (a
+// failed connection) or the server rejected them without processing
+// (HTTP 429), unless RetryMutations is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including server Retry-After values.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0–1) of each backoff delay that is randomized.
+	Jitter float64
+	// Retryable reports whether an error should be retried. Defaults to IsRetryable.
+	Retryable func(error) bool
+	// RetryMutations allows replaying non-idempotent requests on any retryable error.
+	RetryMutations bool
+}
+
+// DefaultRetryPolicy returns a policy suitable for interactive use: three
+// attempts with exponential backoff starting at 500ms, capped at 10s.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// IsRetryable reports whether err is a transient failure: a rate limit, a
+// server error, or a network error. Context cancellation is never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.StatusCode != http.StatusNotImplemented
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Attempts returns the number of attempts made for the request that produced
+// err. Errors from requests that were not retried report a single attempt.
+func Attempts(err error) int {
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		return retryErr.Attempts
+	}
+	return 1
+}
+
+// send executes the request built by newReq, retrying according to the
+// client's policy. It returns the response only if the status is 2xx; the
+// caller must close its body.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, idempotent bool, newReq func() (*http.Request, error)) (*http.Response, error) {
+	policy := c.retry
+	maxAttempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := doOnce(httpClient, newReq)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= maxAttempts || !policy.shouldRetry(err, idempotent) {
+			if attempt > 1 {
+				return nil, &RetryError{Attempts: attempt, Err: err}
+			}
+			return nil, err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetryError{Attempts: attempt, Err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// doOnce performs a single HTTP round trip and maps non-2xx statuses to errors.
+func doOnce(httpClient *http.Client, newReq func() (*http.Request, error)) (*http.Response, error) {
+	req, err := newReq()
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// shouldRetry reports whether a failed attempt may be replayed.
+func (p *RetryPolicy) shouldRetry(err error, idempotent bool) bool {
+	retryable := IsRetryable
+	if p.Retryable != nil {
+		retryable = p.Retryable
+	}
+	if !retryable(err) {
+		return false
+	}
+	if idempotent || p.RetryMutations || notSent(err) {
+		return true
+	}
+	// A rate-limited request was rejected before being processed.
+	var rateErr *RateLimitError
+	return errors.As(err, &rateErr)
+}
+
+// notSent reports whether a request failed before it was sent: the
+// connection to the server could not be made.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// delay returns how long to wait before the attempt following the given one.
+// A server-provided Retry-After takes precedence over exponential backoff.
+func (p *RetryPolicy) delay(attempt int, err error) time.Duration {
+	var d time.Duration
+	var promptErr interface{ retryAfter() time.Duration }
+	if errors.As(err, &promptErr) && promptErr.retryAfter() > 0 {
+		d = promptErr.retryAfter()
+	} else {
+		d = p.BaseDelay << (attempt - 1)
+		if d <= 0 && p.BaseDelay > 0 {
+			// Shift overflowed.
+			d = p.MaxDelay
+		}
+		if p.Jitter > 0 && d > 0 {
+			spread := float64(d) * p.Jitter
+			d = time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+func (e *PromptQLError) retryAfter() time.Duration { return e.RetryAfter }
+
+// parseRetryAfter parses a Retry-After header given as delay-seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// isMutation reports whether a GraphQL document is a mutation operation.
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
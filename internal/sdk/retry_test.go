@@ -0,0 +1,213 @@
+package sdk
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newRetryTestClient(fn func(*http.Request) (*http.Response, error)) *Client {
+	return NewClient(ClientOptions{
+		PAT:     "test-pat",
+		BaseURL: "https://test.example.com",
+		HTTPClient: &http.Client{
+			Transport: &mockRoundTripper{fn: fn},
+		},
+		Retry: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    5 * time.Millisecond,
+		},
+	})
+}
+
+func TestRetry_ServerErrorThenSuccess(t *testing.T) {
+	calls := 0
+	client := newRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return jsonResponse(503, `{"message":"unavailable"}`), nil
+		}
+		return jsonResponse(200, graphqlJSON(`{"getThreads": []}`)), nil
+	})
+
+	if _, err := client.Threads().List("proj-1", "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetry_ExhaustedReportsAttempts(t *testing.T) {
+	calls := 0
+	client := newRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(500, `{"message":"boom"}`), nil
+	})
+
+	_, err := client.Threads().List("proj-1", "user-1")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if got := Attempts(err); got != 3 {
+		t.Errorf("expected Attempts(err)=3, got %d", got)
+	}
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Errorf("expected *ServerError to be unwrappable, got %T: %v", err, err)
+	}
+}
+
+func TestRetry_NonRetryableError(t *testing.T) {
+	calls := 0
+	client := newRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(401, `{"message":"invalid token"}`), nil
+	})
+
+	_, err := client.Threads().List("proj-1", "user-1")
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+	if Attempts(err) != 1 {
+		t.Errorf("expected Attempts(err)=1, got %d", Attempts(err))
+	}
+}
+
+func TestRetry_MutationNotReplayedOnServerError(t *testing.T) {
+	calls := 0
+	client := newRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(502, `{"message":"bad gateway"}`), nil
+	})
+
+	_, err := client.Threads().SendMessage(SendMessageOptions{ThreadID: "t-1", Message: "hi"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("expected mutation to be attempted once, got %d", calls)
+	}
+}
+
+func TestRetry_MutationReplayedOnRateLimit(t *testing.T) {
+	calls := 0
+	client := newRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return jsonResponse(429, `{"message":"slow down"}`), nil
+		}
+		return jsonResponse(200, graphqlJSON(`{"sendMessage": {"thread_event_id": 7}}`)), nil
+	})
+
+	result, err := client.Threads().SendMessage(SendMessageOptions{ThreadID: "t-1", Message: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+	if result.ThreadEventID != 7 {
+		t.Errorf("expected ThreadEventID 7, got %d", result.ThreadEventID)
+	}
+}
+
+func TestRetry_RetryAfterHeader(t *testing.T) {
+	resp := jsonResponse(429, `{"message":"slow down"}`)
+	resp.Header.Set("Retry-After", "2")
+	err := checkResponse(resp)
+
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected *RateLimitError, got %T", err)
+	}
+	if rateErr.RetryAfter != 2*time.Second {
+		t.Errorf("expected RetryAfter=2s, got %v", rateErr.RetryAfter)
+	}
+
+	policy := &RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Minute}
+	if d := policy.delay(1, err); d != 2*time.Second {
+		t.Errorf("expected delay to honor Retry-After, got %v", d)
+	}
+	policy.MaxDelay = time.Second
+	if d := policy.delay(1, err); d != time.Second {
+		t.Errorf("expected delay capped at MaxDelay, got %v", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"Wed, 01 Jan 2025 00:00:30 GMT", 30 * time.Second},
+		{"Tue, 31 Dec 2024 23:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tc := range cases {
+		if got := parseRetryAfter(tc.value, now); got != tc.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRetry_BackoffGrowsAndCaps(t *testing.T) {
+	policy := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	err := &ServerError{PromptQLError{StatusCode: 500}}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := policy.delay(i+1, err); got != w {
+			t.Errorf("delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func newQueryRetryTestClient(fn func(*http.Request) (*http.Response, error)) *Client {
+	client := newQueryTestClient(fn)
+	client.retry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	return client
+}
+
+func TestRetry_QueryNotReplayedOnServerError(t *testing.T) {
+	calls := 0
+	client := newQueryRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(503, `{"message":"unavailable"}`), nil
+	})
+
+	if _, err := client.Query().Execute(ExecuteOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("expected query to be attempted once, got %d", calls)
+	}
+}
+
+func TestRetry_QueryReplayedWhenNotSent(t *testing.T) {
+	calls := 0
+	client := newQueryRetryTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}
+		return jsonResponse(200, `{"assistant_actions": []}`), nil
+	})
+
+	if _, err := client.Query().Execute(ExecuteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a failed connection to be retried, got %d attempts", calls)
+	}
+}
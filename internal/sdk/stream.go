@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ChunkType identifies the kind of delta carried by a QueryChunk.
+type ChunkType string
+
+const (
+	// ChunkAssistantAction carries incremental text for an assistant action.
+	ChunkAssistantAction ChunkType = "assistant_action_chunk"
+	// ChunkArtifactUpdate carries a new or modified artifact.
+	ChunkArtifactUpdate ChunkType = "artifact_update_chunk"
+	// ChunkError carries an error reported by the server mid-stream.
+	ChunkError ChunkType = "error_chunk"
+)
+
+// QueryChunk is a single incremental update from a streaming query.
+// Text fields of an assistant action chunk are deltas to be appended to the
+// action at Index.
+type QueryChunk struct {
+	Type       ChunkType `json:"type"`
+	Index      int       `json:"index"`
+	Message    string    `json:"message,omitempty"`
+	Plan       string    `json:"plan,omitempty"`
+	Code       string    `json:"code,omitempty"`
+	CodeOutput string    `json:"code_output,omitempty"`
+	CodeError  string    `json:"code_error,omitempty"`
+	Artifact   *Artifact `json:"artifact,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// maxChunkSize bounds a single chunk; artifacts with large tables can be big.
+const maxChunkSize = 16 << 20
+
+// QueryStream reads chunks from a streaming query response. It accepts both
+// server-sent events ("data: {...}") and newline-delimited JSON.
+//
+//	stream, err := client.Query().ExecuteStream(opts)
+//	defer stream.Close()
+//	for stream.Next() {
+//		chunk := stream.Chunk()
+//		...
+//	}
+//	if err := stream.Err(); err != nil { ... }
+//
+// Next, Chunk and Err must be called from one goroutine. Close may be called
+// from another to abort a Next in progress, though cancelling the context
+// passed to ExecuteStreamContext is the preferred way to stop a stream.
+type QueryStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	chunk   QueryChunk
+	err     error
+	done    bool // only touched by Next
+	actions int  // assistant actions started so far
+
+	closed    atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newQueryStream(body io.ReadCloser) *QueryStream {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxChunkSize)
+	return &QueryStream{body: body, scanner: scanner}
+}
+
+// Next advances to the next chunk, returning false at the end of the stream
+// or on error.
+func (s *QueryStream) Next() bool {
+	if s.done || s.closed.Load() {
+		return false
+	}
+	var data []byte
+	for s.scanner.Scan() {
+		line := bytes.TrimRight(s.scanner.Bytes(), "\r")
+		switch {
+		case len(line) == 0:
+			// Blank line terminates an SSE event.
+			if len(data) > 0 {
+				return s.emit(data)
+			}
+		case line[0] == ':':
+			// SSE comment / keep-alive.
+		case bytes.HasPrefix(line, []byte("data:")):
+			payload := bytes.TrimSpace(line[len("data:"):])
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, payload...)
+		case bytes.HasPrefix(line, []byte("event:")), bytes.HasPrefix(line, []byte("id:")), bytes.HasPrefix(line, []byte("retry:")):
+			// SSE metadata; the chunk type is carried in the payload.
+		default:
+			// NDJSON line.
+			return s.emit(bytes.TrimSpace(line))
+		}
+	}
+	if err := s.scanner.Err(); err != nil && !s.closed.Load() {
+		s.err = fmt.Errorf("reading stream: %w", err)
+		s.done = true
+		return false
+	}
+	if len(data) > 0 {
+		return s.emit(data)
+	}
+	s.done = true
+	return false
+}
+
+// emit decodes a single chunk payload.
+func (s *QueryStream) emit(data []byte) bool {
+	if string(data) == "[DONE]" {
+		s.done = true
+		return false
+	}
+	var chunk QueryChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		s.err = fmt.Errorf("decoding chunk: %w", err)
+		s.done = true
+		return false
+	}
+	if chunk.Type == ChunkAssistantAction {
+		// Actions are numbered in order, so an index can only continue one
+		// or start the next; anything else would make Apply grow without
+		// bound.
+		if chunk.Index < 0 || chunk.Index > s.actions {
+			s.err = fmt.Errorf("decoding chunk: assistant action %d after %d actions", chunk.Index, s.actions)
+			s.done = true
+			return false
+		}
+		if chunk.Index == s.actions {
+			s.actions++
+		}
+	}
+	s.chunk = chunk
+	return true
+}
+
+// Chunk returns the most recent chunk read by Next.
+func (s *QueryStream) Chunk() QueryChunk { return s.chunk }
+
+// Err returns the first error encountered while reading the stream.
+func (s *QueryStream) Err() error { return s.err }
+
+// Close releases the underlying connection. It is safe to call more than
+// once, and while Next is blocked in another goroutine; that Next then
+// returns false without an error.
+func (s *QueryStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.closed.Store(true)
+		s.closeErr = s.body.Close()
+	})
+	return s.closeErr
+}
+
+// Apply merges a chunk into the response, so a QueryResponse can be built
+// incrementally while a stream is being read. An assistant action chunk
+// that neither continues an action nor starts the next one is ignored.
+func (r *QueryResponse) Apply(chunk QueryChunk) {
+	switch chunk.Type {
+	case ChunkAssistantAction:
+		if chunk.Index < 0 || chunk.Index > len(r.AssistantActions) {
+			return
+		}
+		if chunk.Index == len(r.AssistantActions) {
+			r.AssistantActions = append(r.AssistantActions, AssistantAction{})
+		}
+		a := &r.AssistantActions[chunk.Index]
+		a.Message += chunk.Message
+		a.Plan += chunk.Plan
+		a.Code += chunk.Code
+		a.CodeOutput += chunk.CodeOutput
+		a.CodeError += chunk.CodeError
+	case ChunkArtifactUpdate:
+		if chunk.Artifact == nil {
+			return
+		}
+		for i := range r.ModifiedArtifacts {
+			if r.ModifiedArtifacts[i].Identifier == chunk.Artifact.Identifier {
+				r.ModifiedArtifacts[i] = *chunk.Artifact
+				return
+			}
+		}
+		r.ModifiedArtifacts = append(r.ModifiedArtifacts, *chunk.Artifact)
+	}
+}
@@ -1,5 +1,7 @@
 package sdk
 
+import "context"
+
 // ThreadsResource provides thread and conversation management.
 type ThreadsResource struct {
 	client *Client
@@ -16,6 +18,11 @@ type StartOptions struct {
 
 // Start starts a new thread with an initial message.
 func (r *ThreadsResource) Start(opts StartOptions) (*StartThreadResult, error) {
+	return r.StartContext(context.Background(), opts)
+}
+
+// StartContext is like Start but uses ctx for the request.
+func (r *ThreadsResource) StartContext(ctx context.Context, opts StartOptions) (*StartThreadResult, error) {
 	query := `
 		mutation StartThread($projectId: String!, $message: String!, $buildFqdn: String!, $timezone: String!, $visibility: String) {
 			startThread(projectId: $projectId, message: $message, buildFqdn: $buildFqdn, timezone: $timezone, visibility: $visibility) {
@@ -46,7 +53,7 @@ func (r *ThreadsResource) Start(opts StartOptions) (*StartThreadResult, error) {
 		variables["visibility"] = opts.Visibility
 	}
 
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +74,11 @@ type SendMessageOptions struct {
 
 // SendMessage sends a follow-up message to an existing thread.
 func (r *ThreadsResource) SendMessage(opts SendMessageOptions) (*SendMessageResult, error) {
+	return r.SendMessageContext(context.Background(), opts)
+}
+
+// SendMessageContext is like SendMessage but uses ctx for the request.
+func (r *ThreadsResource) SendMessageContext(ctx context.Context, opts SendMessageOptions) (*SendMessageResult, error) {
 	query := `
 		mutation SendMessage($threadId: String!, $message: String!, $buildFqdn: String!, $timezone: String!) {
 			sendMessage(threadId: $threadId, message: $message, buildFqdn: $buildFqdn, timezone: $timezone) {
@@ -86,7 +98,7 @@ func (r *ThreadsResource) SendMessage(opts SendMessageOptions) (*SendMessageResu
 		"timezone":  tz,
 	}
 
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +111,11 @@ func (r *ThreadsResource) SendMessage(opts SendMessageOptions) (*SendMessageResu
 
 // Get fetches a single thread by ID.
 func (r *ThreadsResource) Get(threadID string) (*Thread, error) {
+	return r.GetContext(context.Background(), threadID)
+}
+
+// GetContext is like Get but uses ctx for the request.
+func (r *ThreadsResource) GetContext(ctx context.Context, threadID string) (*Thread, error) {
 	query := `
 		query GetThread($threadId: String!) {
 			getThread(threadId: $threadId) {
@@ -112,7 +129,7 @@ func (r *ThreadsResource) Get(threadID string) (*Thread, error) {
 				visibility
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"threadId": threadID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"threadId": threadID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +142,11 @@ func (r *ThreadsResource) Get(threadID string) (*Thread, error) {
 
 // List lists threads for a project and user.
 func (r *ThreadsResource) List(projectID, userID string) ([]Thread, error) {
+	return r.ListContext(context.Background(), projectID, userID)
+}
+
+// ListContext is like List but uses ctx for the request.
+func (r *ThreadsResource) ListContext(ctx context.Context, projectID, userID string) ([]Thread, error) {
 	query := `
 		query ListThreads($projectId: String!, $userId: String!) {
 			getThreads(projectId: $projectId, userId: $userId) {
@@ -142,7 +164,7 @@ func (r *ThreadsResource) List(projectID, userID string) ([]Thread, error) {
 		"projectId": projectID,
 		"userId":    userID,
 	}
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +177,11 @@ func (r *ThreadsResource) List(projectID, userID string) ([]Thread, error) {
 
 // GetEvents fetches all events for a thread.
 func (r *ThreadsResource) GetEvents(threadID string) ([]ThreadEvent, error) {
+	return r.GetEventsContext(context.Background(), threadID)
+}
+
+// GetEventsContext is like GetEvents but uses ctx for the request.
+func (r *ThreadsResource) GetEventsContext(ctx context.Context, threadID string) ([]ThreadEvent, error) {
 	query := `
 		query GetThreadEvents($threadId: String!) {
 			getThreadEvents(threadId: $threadId) {
@@ -165,7 +192,7 @@ func (r *ThreadsResource) GetEvents(threadID string) ([]ThreadEvent, error) {
 				user_id
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"threadId": threadID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"threadId": threadID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -176,8 +203,14 @@ func (r *ThreadsResource) GetEvents(threadID string) ([]ThreadEvent, error) {
 	return result, nil
 }
 
-// SubmitFeedback submits feedback for a thread message.
+// SubmitFeedback submits feedback (FeedbackPositive or FeedbackNegative)
+// for a thread message, with optional details.
 func (r *ThreadsResource) SubmitFeedback(threadID, messageID string, feedback int, details string) (*ThreadFeedback, error) {
+	return r.SubmitFeedbackContext(context.Background(), threadID, messageID, feedback, details)
+}
+
+// SubmitFeedbackContext is like SubmitFeedback but uses ctx for the request.
+func (r *ThreadsResource) SubmitFeedbackContext(ctx context.Context, threadID, messageID string, feedback int, details string) (*ThreadFeedback, error) {
 	query := `
 		mutation SubmitFeedback($threadId: String!, $messageId: String!, $feedback: Int!, $details: String) {
 			submitThreadFeedback(threadId: $threadId, messageId: $messageId, feedback: $feedback, details: $details) {
@@ -198,7 +231,7 @@ func (r *ThreadsResource) SubmitFeedback(threadID, messageID string, feedback in
 		variables["details"] = details
 	}
 
-	data, err := r.client.GraphQL(query, variables, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, variables, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,271 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DDNToken is a DDN bearer token with its expiry.
+type DDNToken struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// validFor reports whether the token is still valid margin from now.
+func (t *DDNToken) validFor(now time.Time, margin time.Duration) bool {
+	return t != nil && t.Token != "" && now.Add(margin).Before(t.Expiry)
+}
+
+// TokenCache persists DDN tokens across processes.
+type TokenCache interface {
+	// Load returns the cached token for key, or nil if there is none.
+	Load(key string) (*DDNToken, error)
+	// Store caches token under key.
+	Store(key string, token *DDNToken) error
+	// Delete removes the token cached under key, if any.
+	Delete(key string) error
+}
+
+// FileTokenCache is a TokenCache keeping one JSON file per key in Dir.
+type FileTokenCache struct {
+	Dir string
+}
+
+func (c FileTokenCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+func (c FileTokenCache) Load(key string) (*DDNToken, error) {
+	data, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cached token: %w", err)
+	}
+	var token DDNToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("parsing cached token: %w", err)
+	}
+	return &token, nil
+}
+
+func (c FileTokenCache) Store(key string, token *DDNToken) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("creating token cache directory: %w", err)
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("marshaling token: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("writing cached token: %w", err)
+	}
+	return nil
+}
+
+func (c FileTokenCache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing cached token: %w", err)
+	}
+	return nil
+}
+
+// Token lifetimes used by the TokenManager.
+const (
+	// defaultTokenRefreshMargin is how long before expiry a token is replaced.
+	defaultTokenRefreshMargin = time.Minute
+	// defaultTokenLifetime is assumed when the server gives no usable expiry.
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// TokenManager obtains DDN tokens by exchanging the client's PAT, caches
+// them in memory (and in a TokenCache if configured) and refreshes them
+// shortly before they expire. It is safe for concurrent use: callers
+// needing the same token share one exchange, and exchanges for different
+// projects run in parallel.
+type TokenManager struct {
+	client *Client
+	cache  TokenCache
+	margin time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	tokens   map[string]*DDNToken
+	inflight map[string]*tokenCall
+}
+
+// tokenCall is a token exchange in progress.
+type tokenCall struct {
+	done  chan struct{} // closed once token and err are set
+	token *DDNToken
+	err   error
+}
+
+func newTokenManager(c *Client, cache TokenCache) *TokenManager {
+	return &TokenManager{
+		client:   c,
+		cache:    cache,
+		margin:   defaultTokenRefreshMargin,
+		now:      time.Now,
+		tokens:   map[string]*DDNToken{},
+		inflight: map[string]*tokenCall{},
+	}
+}
+
+// Token returns a valid DDN token for the project, exchanging the PAT for
+// a new one if no cached token is valid for at least another minute.
+func (m *TokenManager) Token(projectID string) (*DDNToken, error) {
+	return m.TokenContext(context.Background(), projectID)
+}
+
+// TokenContext is like Token but uses ctx for the request.
+func (m *TokenManager) TokenContext(ctx context.Context, projectID string) (*DDNToken, error) {
+	if projectID == "" {
+		return nil, fmt.Errorf("a project ID is required to obtain a DDN token")
+	}
+	key := m.cacheKey(projectID)
+
+	for {
+		m.mu.Lock()
+		if t := m.tokens[key]; t.validFor(m.now(), m.margin) {
+			m.mu.Unlock()
+			return t, nil
+		}
+		call, ok := m.inflight[key]
+		if !ok {
+			call = &tokenCall{done: make(chan struct{})}
+			m.inflight[key] = call
+			m.mu.Unlock()
+			m.fetch(ctx, projectID, key, call)
+			return call.token, call.err
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// An exchange aborted by the context of the caller that started
+		// it is tried again with ours.
+		if !errors.Is(call.err, context.Canceled) && !errors.Is(call.err, context.DeadlineExceeded) {
+			return call.token, call.err
+		}
+	}
+}
+
+// fetch completes call with a token from the TokenCache or a new one from
+// the exchange. m.mu is not held meanwhile.
+func (m *TokenManager) fetch(ctx context.Context, projectID, key string, call *tokenCall) {
+	call.token, call.err = m.obtain(ctx, projectID, key)
+	m.mu.Lock()
+	if call.err == nil {
+		m.tokens[key] = call.token
+	}
+	delete(m.inflight, key)
+	m.mu.Unlock()
+	close(call.done)
+}
+
+func (m *TokenManager) obtain(ctx context.Context, projectID, key string) (*DDNToken, error) {
+	now := m.now()
+	if m.cache != nil {
+		// A broken cache only costs a token exchange.
+		if t, err := m.cache.Load(key); err == nil && t.validFor(now, m.margin) {
+			return t, nil
+		}
+	}
+
+	resp, err := m.client.GetDDNTokenContext(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Token == "" {
+		return nil, fmt.Errorf("DDN token exchange returned no token")
+	}
+	t := &DDNToken{Token: resp.Token, Expiry: parseExpiry(resp.Expiry, now)}
+	if m.cache != nil {
+		_ = m.cache.Store(key, t)
+	}
+	return t, nil
+}
+
+// Headers returns the DDN request headers authenticating as the project's
+// DDN token, for use as ExecuteOptions.DDNHeaders.
+func (m *TokenManager) Headers(projectID string) (map[string]string, error) {
+	return m.HeadersContext(context.Background(), projectID)
+}
+
+// HeadersContext is like Headers but uses ctx for the request.
+func (m *TokenManager) HeadersContext(ctx context.Context, projectID string) (map[string]string, error) {
+	t, err := m.TokenContext(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"Authorization": "Bearer " + t.Token}, nil
+}
+
+// Invalidate drops the cached token for the project, so the next call
+// exchanges the PAT again, e.g. after the token was rejected.
+func (m *TokenManager) Invalidate(projectID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, m.cacheKey(projectID))
+	if m.cache != nil {
+		_ = m.cache.Delete(m.cacheKey(projectID))
+	}
+}
+
+// cacheKey identifies a token by project and PAT, so clients using
+// different PATs never share tokens.
+func (m *TokenManager) cacheKey(projectID string) string {
+	sum := sha256.Sum256([]byte(m.client.pat))
+	return safeKey(projectID) + "-" + hex.EncodeToString(sum[:4])
+}
+
+// safeKey maps s to a string usable as a file name.
+func safeKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// parseExpiry interprets the expiry returned by the token exchange: an
+// RFC 3339 timestamp, Unix seconds, or a lifetime in seconds.
+func parseExpiry(s string, now time.Time) time.Time {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+		if n > 1_000_000_000 {
+			return time.Unix(n, 0)
+		}
+		return now.Add(time.Duration(n) * time.Second)
+	}
+	return now.Add(defaultTokenLifetime)
+}
+
+// DDNURLForBuild returns the GraphQL endpoint of a project build given its
+// FQDN, as returned by Projects().Lookup.
+func DDNURLForBuild(buildFQDN string) string {
+	if strings.Contains(buildFQDN, "://") {
+		return buildFQDN
+	}
+	return "https://" + strings.TrimSuffix(buildFQDN, "/") + "/graphql"
+}
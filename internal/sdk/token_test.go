@@ -0,0 +1,254 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenTestClient returns a PAT-only client for project "proj-1" whose
+// token exchange hands out "ddn-1", "ddn-2", ... with the given expiry,
+// counting the exchanges in *exchanges. Query bodies are captured in *sent.
+func tokenTestClient(t *testing.T, expiry string, exchanges *int, sent *map[string]interface{}, cache TokenCache) *Client {
+	t.Helper()
+	c := NewClient(ClientOptions{
+		PAT:        "test-pat",
+		ProjectID:  "proj-1",
+		APIURL:     "https://api.test.example.com",
+		AuthURL:    "https://auth.test.example.com",
+		TokenCache: cache,
+		HTTPClient: &http.Client{Transport: &mockRoundTripper{fn: func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/ddn/promptql/token") {
+				*exchanges++
+				if got := req.Header.Get("x-hasura-project-id"); got != "proj-1" {
+					t.Errorf("expected project header proj-1, got %q", got)
+				}
+				body, _ := json.Marshal(TokenResponse{Token: "ddn-" + string(rune('0'+*exchanges)), Expiry: expiry})
+				return jsonResponse(200, string(body)), nil
+			}
+			if got := req.Header.Get("Authorization"); got != "pat test-pat" {
+				t.Errorf("expected query to authenticate with the PAT, got %q", got)
+			}
+			raw, _ := io.ReadAll(req.Body)
+			if sent != nil {
+				_ = json.Unmarshal(raw, sent)
+			}
+			return jsonResponse(200, `{"thread_id":"t1","assistant_actions":[]}`), nil
+		}}},
+	})
+	return c
+}
+
+func TestTokenManager_CachesUntilNearExpiry(t *testing.T) {
+	exchanges := 0
+	client := tokenTestClient(t, "600", &exchanges, nil, nil)
+	m := client.DDNTokens()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	first, err := m.Token("proj-1")
+	if err != nil {
+		t.Fatalf("Token() error: %v", err)
+	}
+	if first.Token != "ddn-1" || !first.Expiry.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("unexpected token %+v", first)
+	}
+
+	now = now.Add(8 * time.Minute)
+	if again, _ := m.Token("proj-1"); again.Token != "ddn-1" || exchanges != 1 {
+		t.Errorf("expected the cached token, got %q after %d exchanges", again.Token, exchanges)
+	}
+
+	// Within a minute of expiry the token is replaced.
+	now = now.Add(90 * time.Second)
+	if refreshed, _ := m.Token("proj-1"); refreshed.Token != "ddn-2" || exchanges != 2 {
+		t.Errorf("expected a refreshed token, got %q after %d exchanges", refreshed.Token, exchanges)
+	}
+
+	m.Invalidate("proj-1")
+	if t2, _ := m.Token("proj-1"); t2.Token != "ddn-3" {
+		t.Errorf("expected a new token after Invalidate, got %q", t2.Token)
+	}
+}
+
+func TestTokenManager_FileCache(t *testing.T) {
+	cache := FileTokenCache{Dir: t.TempDir()}
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	exchanges := 0
+	if _, err := tokenTestClient(t, expiry, &exchanges, nil, cache).DDNTokens().Token("proj-1"); err != nil {
+		t.Fatalf("Token() error: %v", err)
+	}
+
+	// A second client, as in a new process, reuses the token from disk.
+	other := 0
+	token, err := tokenTestClient(t, expiry, &other, nil, cache).DDNTokens().Token("proj-1")
+	if err != nil {
+		t.Fatalf("Token() error: %v", err)
+	}
+	if token.Token != "ddn-1" || other != 0 {
+		t.Errorf("expected the cached token without an exchange, got %q after %d exchanges", token.Token, other)
+	}
+}
+
+func TestTokenManager_ExchangesDoNotBlockOtherProjects(t *testing.T) {
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	var mu sync.Mutex
+	exchanges := map[string]int{}
+	client := NewClient(ClientOptions{
+		PAT:     "test-pat",
+		AuthURL: "https://auth.test.example.com",
+		HTTPClient: &http.Client{Transport: &mockRoundTripper{fn: func(req *http.Request) (*http.Response, error) {
+			project := req.Header.Get("x-hasura-project-id")
+			mu.Lock()
+			exchanges[project]++
+			mu.Unlock()
+			if project == "slow" {
+				started <- struct{}{}
+				<-release
+			}
+			return jsonResponse(200, `{"token":"ddn-`+project+`","expiry":"600"}`), nil
+		}}},
+	})
+	m := client.DDNTokens()
+
+	var wg sync.WaitGroup
+	slow := make([]*DDNToken, 2)
+	for i := range slow {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slow[i], _ = m.Token("slow")
+		}()
+	}
+	// The slow exchange is still running, but another project's is not held up.
+	<-started
+	fast, err := m.Token("fast")
+	if err != nil || fast.Token != "ddn-fast" {
+		t.Fatalf("expected the fast token while the slow exchange runs, got %+v (%v)", fast, err)
+	}
+	close(release)
+	wg.Wait()
+
+	for _, tok := range slow {
+		if tok == nil || tok.Token != "ddn-slow" {
+			t.Errorf("expected both callers to get the slow token, got %+v", tok)
+		}
+	}
+	if exchanges["slow"] != 1 {
+		t.Errorf("expected concurrent callers to share one exchange, got %d", exchanges["slow"])
+	}
+}
+
+func TestExecute_RenewsRejectedDDNToken(t *testing.T) {
+	exchanges, queries := 0, 0
+	client := NewClient(ClientOptions{
+		PAT:       "test-pat",
+		ProjectID: "proj-1",
+		APIURL:    "https://api.test.example.com",
+		AuthURL:   "https://auth.test.example.com",
+		HTTPClient: &http.Client{Transport: &mockRoundTripper{fn: func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/ddn/promptql/token") {
+				exchanges++
+				return jsonResponse(200, fmt.Sprintf(`{"token":"ddn-%d","expiry":"600"}`, exchanges)), nil
+			}
+			queries++
+			var body struct {
+				DDN struct {
+					Headers map[string]string `json:"headers"`
+				} `json:"ddn"`
+			}
+			raw, _ := io.ReadAll(req.Body)
+			_ = json.Unmarshal(raw, &body)
+			if body.DDN.Headers["Authorization"] == "Bearer ddn-1" {
+				return jsonResponse(401, `{"message":"token revoked"}`), nil
+			}
+			return jsonResponse(200, `{"assistant_actions":[]}`), nil
+		}}},
+	})
+
+	if _, err := client.Query().Ask("hi", "https://build.example.com/graphql", nil, ""); err != nil {
+		t.Fatalf("Ask() error: %v", err)
+	}
+	if exchanges != 2 || queries != 2 {
+		t.Errorf("expected the rejected token to be renewed once, got %d exchanges and %d queries", exchanges, queries)
+	}
+}
+
+func TestExecute_InjectsDDNToken(t *testing.T) {
+	exchanges := 0
+	var sent map[string]interface{}
+	client := tokenTestClient(t, "", &exchanges, &sent, nil)
+
+	if _, err := client.Query().Ask("how many users?", "https://build.example.com/graphql", nil, ""); err != nil {
+		t.Fatalf("Ask() error: %v", err)
+	}
+	ddn := sent["ddn"].(map[string]interface{})
+	headers, _ := ddn["headers"].(map[string]interface{})
+	if headers["Authorization"] != "Bearer ddn-1" {
+		t.Errorf("expected injected DDN token, got headers %v", headers)
+	}
+
+	// An explicit Authorization header is left alone.
+	if _, err := client.Query().Ask("again", "https://build.example.com/graphql", map[string]string{"authorization": "Bearer mine"}, ""); err != nil {
+		t.Fatalf("Ask() error: %v", err)
+	}
+	headers = sent["ddn"].(map[string]interface{})["headers"].(map[string]interface{})
+	if headers["authorization"] != "Bearer mine" || headers["Authorization"] != nil {
+		t.Errorf("expected caller's header to be kept, got %v", headers)
+	}
+	if exchanges != 1 {
+		t.Errorf("expected 1 token exchange, got %d", exchanges)
+	}
+}
+
+func TestExecute_APIKeyClientSkipsToken(t *testing.T) {
+	var sent map[string]interface{}
+	client := newQueryTestClient(func(req *http.Request) (*http.Response, error) {
+		if strings.Contains(req.URL.Path, "token") {
+			t.Error("unexpected token exchange for an API key client")
+		}
+		raw, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(raw, &sent)
+		return jsonResponse(200, `{"assistant_actions":[]}`), nil
+	})
+	if _, err := client.Query().Ask("hi", "https://ddn.example.com/graphql", nil, ""); err != nil {
+		t.Fatalf("Ask() error: %v", err)
+	}
+	if _, ok := sent["ddn"].(map[string]interface{})["headers"]; ok {
+		t.Error("expected no DDN headers")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-01-01T01:00:00Z", now.Add(time.Hour)},
+		{"1767229200", time.Unix(1767229200, 0)},
+		{"300", now.Add(5 * time.Minute)},
+		{"", now.Add(defaultTokenLifetime)},
+		{"soon", now.Add(defaultTokenLifetime)},
+	}
+	for _, tt := range tests {
+		if got := parseExpiry(tt.in, now); !got.Equal(tt.want) {
+			t.Errorf("parseExpiry(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDDNURLForBuild(t *testing.T) {
+	if got := DDNURLForBuild("my-build.ddn.hasura.app"); got != "https://my-build.ddn.hasura.app/graphql" {
+		t.Errorf("unexpected URL %q", got)
+	}
+	if got := DDNURLForBuild("http://localhost:3280/graphql"); got != "http://localhost:3280/graphql" {
+		t.Errorf("expected full URLs to pass through, got %q", got)
+	}
+}
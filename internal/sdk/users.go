@@ -1,5 +1,7 @@
 package sdk
 
+import "context"
+
 // UsersResource provides operations on PromptQL user accounts.
 type UsersResource struct {
 	client *Client
@@ -7,6 +9,11 @@ type UsersResource struct {
 
 // GetCurrent fetches a PromptQL user by their control-plane user ID.
 func (r *UsersResource) GetCurrent(controlPlaneUserID string) (*PromptQLUser, error) {
+	return r.GetCurrentContext(context.Background(), controlPlaneUserID)
+}
+
+// GetCurrentContext is like GetCurrent but uses ctx for the request.
+func (r *UsersResource) GetCurrentContext(ctx context.Context, controlPlaneUserID string) (*PromptQLUser, error) {
 	query := `
 		query GetPromptQLUser($controlPlaneUserId: String!) {
 			getPromptQLUser(controlPlaneUserId: $controlPlaneUserId) {
@@ -18,7 +25,7 @@ func (r *UsersResource) GetCurrent(controlPlaneUserID string) (*PromptQLUser, er
 				project_id
 			}
 		}`
-	data, err := r.client.GraphQL(query, map[string]interface{}{"controlPlaneUserId": controlPlaneUserID}, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, map[string]interface{}{"controlPlaneUserId": controlPlaneUserID}, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -31,6 +38,11 @@ func (r *UsersResource) GetCurrent(controlPlaneUserID string) (*PromptQLUser, er
 
 // List lists all PromptQL users.
 func (r *UsersResource) List() ([]PromptQLUser, error) {
+	return r.ListContext(context.Background())
+}
+
+// ListContext is like List but uses ctx for the request.
+func (r *UsersResource) ListContext(ctx context.Context) ([]PromptQLUser, error) {
 	query := `
 		query ListPromptQLUsers {
 			getPromptQLUsers {
@@ -42,7 +54,7 @@ func (r *UsersResource) List() ([]PromptQLUser, error) {
 				project_id
 			}
 		}`
-	data, err := r.client.GraphQL(query, nil, "pat")
+	data, err := r.client.GraphQLContext(ctx, query, nil, "pat")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,309 @@
+// Package search keeps a local full-text index of thread messages.
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Message is an indexed chat message.
+type Message struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// Thread is an indexed thread. Messages are in the order the chat view
+// shows them, so a result's message index can be used to jump to it.
+type Thread struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	UpdatedAt string    `json:"updated_at,omitempty"`
+	Messages  []Message `json:"messages"`
+}
+
+// Result is a message matching a search.
+type Result struct {
+	ThreadID    string
+	ThreadTitle string
+	Message     int // index into the thread's messages
+	Role        string
+	Snippet     string // excerpt around the first match
+	Score       int    // number of matching words
+}
+
+// posting locates a word in a message.
+type posting struct {
+	thread  string
+	message int
+}
+
+// Index is a full-text index of the messages of a project's threads,
+// stored as JSON. It is safe for concurrent use.
+type Index struct {
+	path string
+
+	mu      sync.Mutex
+	threads map[string]*Thread
+	words   map[string][]posting // built lazily from threads
+
+	saveMu sync.Mutex // one Save at a time, as they share the temporary file
+}
+
+// indexFile is the on-disk layout of an index.
+type indexFile struct {
+	Version int       `json:"version"`
+	Threads []*Thread `json:"threads"`
+}
+
+const indexVersion = 1
+
+// Open loads the index stored at path, returning an empty index if the
+// file does not exist or was written by an incompatible version.
+func Open(path string) (*Index, error) {
+	ix := &Index{path: path, threads: map[string]*Thread{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ix, nil
+		}
+		return nil, fmt.Errorf("reading search index: %w", err)
+	}
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing search index: %w", err)
+	}
+	if f.Version != indexVersion {
+		return ix, nil
+	}
+	for _, t := range f.Threads {
+		ix.threads[t.ID] = t
+	}
+	return ix, nil
+}
+
+// Save writes the index to disk.
+func (ix *Index) Save() error {
+	ix.saveMu.Lock()
+	defer ix.saveMu.Unlock()
+
+	ix.mu.Lock()
+	f := indexFile{Version: indexVersion, Threads: make([]*Thread, 0, len(ix.threads))}
+	for _, t := range ix.threads {
+		f.Threads = append(f.Threads, t)
+	}
+	ix.mu.Unlock()
+	sort.Slice(f.Threads, func(i, j int) bool { return f.Threads[i].ID < f.Threads[j].ID })
+
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0700); err != nil {
+		return fmt.Errorf("creating search index directory: %w", err)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("marshaling search index: %w", err)
+	}
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing search index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("writing search index: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of indexed threads.
+func (ix *Index) Len() int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return len(ix.threads)
+}
+
+// Stale reports whether a thread last updated at updatedAt needs to be
+// (re)indexed. Threads without an update time are indexed once.
+func (ix *Index) Stale(id, updatedAt string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	t, ok := ix.threads[id]
+	return !ok || t.UpdatedAt != updatedAt
+}
+
+// Update adds a thread to the index, replacing any earlier version.
+func (ix *Index) Update(t Thread) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.threads[t.ID] = &t
+	ix.words = nil
+}
+
+// Retain drops every thread whose ID is not in ids, e.g. threads deleted
+// on the server.
+func (ix *Index) Retain(ids []string) {
+	keep := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for id := range ix.threads {
+		if !keep[id] {
+			delete(ix.threads, id)
+			ix.words = nil
+		}
+	}
+}
+
+// Search returns up to limit messages containing every word of the query,
+// best matches first. The last word also matches longer words starting
+// with it, so results can be shown while the query is typed.
+func (ix *Index) Search(query string, limit int) []Result {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.build()
+
+	// Count, per message, how many times each query word occurs.
+	counts := map[posting][]int{}
+	for i, term := range terms {
+		add := func(ps []posting) {
+			for _, p := range ps {
+				c := counts[p]
+				if c == nil {
+					c = make([]int, len(terms))
+					counts[p] = c
+				}
+				c[i]++
+			}
+		}
+		if i == len(terms)-1 {
+			for word, ps := range ix.words {
+				if strings.HasPrefix(word, term) {
+					add(ps)
+				}
+			}
+		} else {
+			add(ix.words[term])
+		}
+	}
+
+	var results []Result
+	for p, c := range counts {
+		score := 0
+		for _, n := range c {
+			if n == 0 {
+				score = -1
+				break
+			}
+			score += n
+		}
+		if score < 0 {
+			continue
+		}
+		t := ix.threads[p.thread]
+		msg := t.Messages[p.message]
+		results = append(results, Result{
+			ThreadID:    t.ID,
+			ThreadTitle: t.Title,
+			Message:     p.message,
+			Role:        msg.Role,
+			Snippet:     Snippet(msg.Text, terms, 40),
+			Score:       score,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if ua, ub := ix.threads[a.ThreadID].UpdatedAt, ix.threads[b.ThreadID].UpdatedAt; ua != ub {
+			return ua > ub
+		}
+		if a.ThreadID != b.ThreadID {
+			return a.ThreadID < b.ThreadID
+		}
+		return a.Message < b.Message
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// build indexes the words of every message. The caller holds ix.mu.
+func (ix *Index) build() {
+	if ix.words != nil {
+		return
+	}
+	ix.words = map[string][]posting{}
+	for id, t := range ix.threads {
+		for i, m := range t.Messages {
+			for _, w := range Terms(m.Text) {
+				ix.words[w] = append(ix.words[w], posting{thread: id, message: i})
+			}
+		}
+	}
+}
+
+// Terms splits text into lower-case words.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Snippet returns about width runes of text on either side of the first
+// occurrence of any of the terms, on a single line.
+func Snippet(text string, terms []string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lower-casing changed the length; fall back to the start.
+		lower = runes
+	}
+	at := -1
+	for _, term := range terms {
+		if i := indexRunes(lower, []rune(term)); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	start, end := 0, min(len(runes), 2*width)
+	if at >= 0 {
+		start = max(at-width, 0)
+		end = min(at+width, len(runes))
+	}
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func indexRunes(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
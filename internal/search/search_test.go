@@ -0,0 +1,90 @@
+package search
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	ix, err := Open(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	ix.Update(Thread{ID: "t-1", Title: "Revenue", UpdatedAt: "2026-01-02T00:00:00Z", Messages: []Message{
+		{Role: "user", Text: "What was revenue last quarter?"},
+		{Role: "assistant", Text: "Revenue last quarter was $1.2M, up 8% on the previous quarter."},
+	}})
+	ix.Update(Thread{ID: "t-2", Title: "Orders", UpdatedAt: "2026-01-03T00:00:00Z", Messages: []Message{
+		{Role: "user", Text: "How many orders shipped?"},
+		{Role: "assistant", Text: "412 orders shipped last week."},
+	}})
+	return ix
+}
+
+func TestSearch_RanksAndMatchesPrefix(t *testing.T) {
+	ix := newTestIndex(t)
+
+	results := ix.Search("revenue quarter", 10)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].ThreadID != "t-1" || results[0].Message != 1 || results[0].Role != "assistant" {
+		t.Errorf("expected the assistant answer to rank first, got %+v", results[0])
+	}
+
+	// The last word matches as a prefix while it is being typed.
+	if results := ix.Search("last shi", 10); len(results) != 1 || results[0].ThreadID != "t-2" || results[0].Message != 1 {
+		t.Errorf("expected the shipped orders answer, got %+v", results)
+	}
+	if results := ix.Search("revenue orders", 10); len(results) != 0 {
+		t.Errorf("expected every word to be required, got %+v", results)
+	}
+	if results := ix.Search("  ", 10); results != nil {
+		t.Errorf("expected no results for an empty query, got %+v", results)
+	}
+}
+
+func TestIndex_UpdateRetainAndReload(t *testing.T) {
+	ix := newTestIndex(t)
+
+	if ix.Stale("t-1", "2026-01-02T00:00:00Z") {
+		t.Error("expected an unchanged thread not to be stale")
+	}
+	if !ix.Stale("t-1", "2026-01-05T00:00:00Z") || !ix.Stale("t-3", "") {
+		t.Error("expected updated and unknown threads to be stale")
+	}
+
+	ix.Update(Thread{ID: "t-1", Title: "Revenue", UpdatedAt: "2026-01-05T00:00:00Z", Messages: []Message{{Role: "user", Text: "margin by region"}}})
+	if results := ix.Search("revenue", 10); len(results) != 0 {
+		t.Errorf("expected replaced messages to be dropped, got %+v", results)
+	}
+	ix.Retain([]string{"t-1"})
+	if ix.Len() != 1 {
+		t.Errorf("expected 1 thread after Retain, got %d", ix.Len())
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := Open(ix.path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if results := loaded.Search("region", 10); len(results) != 1 || results[0].ThreadTitle != "Revenue" {
+		t.Errorf("expected the reloaded index to match, got %+v", results)
+	}
+	if loaded.Stale("t-1", "2026-01-05T00:00:00Z") {
+		t.Error("expected the reloaded thread to be up to date")
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "The quick brown fox\njumps over the lazy dog"
+	if got := Snippet(text, []string{"lazy"}, 10); got != "…over the lazy dog" {
+		t.Errorf("unexpected snippet %q", got)
+	}
+	if got := Snippet(text, []string{"quick"}, 6); got != "The quick…" {
+		t.Errorf("unexpected snippet %q", got)
+	}
+}
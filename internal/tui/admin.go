@@ -0,0 +1,702 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/diff"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// adminMode is the state of the project admin view.
+type adminMode int
+
+const (
+	adminOverview adminMode = iota
+	adminConfirmToggle
+	adminEdit
+	adminPreview
+	adminInstructions
+	adminHistory
+	adminConfirmRollback
+)
+
+// Playground settings form fields.
+const (
+	adminFieldProvider = iota
+	adminFieldPublic
+	adminFieldProjectLimit
+	adminFieldUserLimit
+	adminFieldFlags
+	adminFieldInstructions
+	adminFieldReadme
+	adminFieldCount
+)
+
+// adminField is a playground settings form field: a single-line input or,
+// for long text, a multi-line editor.
+type adminField struct {
+	label     string
+	multiline bool
+	input     textinput.Model
+	area      textarea.Model
+}
+
+func newAdminFields() []adminField {
+	fields := []adminField{
+		adminFieldProvider:     {label: "LLM provider"},
+		adminFieldPublic:       {label: "Allow public access (yes/no)"},
+		adminFieldProjectLimit: {label: "Project token usage limit"},
+		adminFieldUserLimit:    {label: "User token usage limit"},
+		adminFieldFlags:        {label: "Feature flags (JSON object)", multiline: true},
+		adminFieldInstructions: {label: "System instructions", multiline: true},
+		adminFieldReadme:       {label: "Readme", multiline: true},
+	}
+	for i := range fields {
+		if fields[i].multiline {
+			fields[i].area = textarea.New()
+			fields[i].area.CharLimit = 0
+			fields[i].area.SetHeight(5)
+			fields[i].area.ShowLineNumbers = false
+		} else {
+			fields[i].input = textinput.New()
+			fields[i].input.CharLimit = 256
+			fields[i].input.Width = 40
+		}
+	}
+	fields[adminFieldProjectLimit].input.Placeholder = "tokens (blank: unchanged)"
+	fields[adminFieldUserLimit].input.Placeholder = "tokens (blank: unchanged)"
+	return fields
+}
+
+func (f *adminField) value() string {
+	if f.multiline {
+		return f.area.Value()
+	}
+	return f.input.Value()
+}
+
+func (f *adminField) setValue(v string) {
+	if f.multiline {
+		f.area.SetValue(v)
+		return
+	}
+	f.input.SetValue(v)
+}
+
+func (f *adminField) focus() tea.Cmd {
+	if f.multiline {
+		return f.area.Focus()
+	}
+	return f.input.Focus()
+}
+
+func (f *adminField) blur() {
+	f.area.Blur()
+	f.input.Blur()
+}
+
+func (f *adminField) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	if f.multiline {
+		f.area, cmd = f.area.Update(msg)
+	} else {
+		f.input, cmd = f.input.Update(msg)
+	}
+	return cmd
+}
+
+func (f *adminField) view() string {
+	if f.multiline {
+		return f.area.View()
+	}
+	return "  " + f.input.View()
+}
+
+// openAdmin switches to the project admin view and loads the project's configuration.
+func (m Model) openAdmin() (tea.Model, tea.Cmd) {
+	if m.client == nil || m.selectedProject == nil {
+		m.err = fmt.Errorf("project settings need a PAT and a selected project")
+		return m, nil
+	}
+	m.view = viewAdmin
+	m.adminMode = adminOverview
+	m.adminScroll = 0
+	// Settings shown earlier may belong to another project.
+	m.promptqlConfig = nil
+	m.playgroundConfig = nil
+	m.instructionHistory = nil
+	m.notice = ""
+	m.loading = true
+	m.err = nil
+	return m, tea.Batch(m.spinner.Tick, m.loadAdminConfig())
+}
+
+func (m Model) viewAdmin() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Project Settings"))
+	if m.selectedProject != nil {
+		b.WriteString("  " + subtitleStyle.Render(m.selectedProject.Name))
+	}
+	b.WriteString("\n\n")
+
+	if m.loading && m.playgroundConfig == nil {
+		b.WriteString(m.spinner.View() + " Loading settings...  " + helpStyle.Render("ctrl+x: cancel"))
+		return b.String()
+	}
+
+	var body, help string
+	switch m.adminMode {
+	case adminEdit:
+		body = m.viewAdminForm()
+		help = "tab/shift+tab: switch field  |  ctrl+s: preview changes  |  esc: cancel"
+	case adminPreview:
+		body = m.viewAdminPreview()
+		help = "↑/↓: scroll  |  y: save  |  n/esc: keep editing"
+	case adminInstructions:
+		body = subtitleStyle.Render("System instructions") + "\n" + m.instructionsInput.View()
+		help = "ctrl+s: preview changes  |  esc: cancel"
+	case adminHistory, adminConfirmRollback:
+		body = m.viewInstructionHistory()
+		help = "↑/↓: select version  |  tab: compare with previous/live  |  r: roll back to version  |  pgup/pgdn: scroll  |  esc: back"
+	default:
+		body = m.viewAdminOverview()
+		help = "e: edit playground settings  |  i: edit system instructions  |  h: instruction history  |  t: enable/disable PromptQL  |  r: refresh  |  esc: back"
+	}
+	b.WriteString(scrollLines(body, m.adminScroll, max(m.height-8, 10)))
+	b.WriteString("\n")
+
+	if m.loading {
+		b.WriteString(m.spinner.View() + " Saving...  " + helpStyle.Render("ctrl+x: cancel") + "\n")
+	} else if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	} else if m.notice != "" {
+		b.WriteString(successStyle.Render(m.notice) + "\n")
+	}
+	if m.adminMode == adminConfirmToggle {
+		action := "Enable"
+		if m.promptqlConfig != nil && m.promptqlConfig.PromptQLEnabled {
+			action = "Disable"
+		}
+		b.WriteString(errorStyle.Render(action + " PromptQL for this project? y: confirm  |  n: cancel"))
+		return b.String()
+	}
+	if m.adminMode == adminConfirmRollback {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Roll back system instructions to v%d? y: confirm  |  n: cancel", m.selectedVersion()+1)))
+		return b.String()
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+func (m Model) viewAdminOverview() string {
+	var b strings.Builder
+	row := func(label, value string) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %-26s", label)) + value + "\n")
+	}
+	section := func(title string) {
+		b.WriteString("\n" + subtitleStyle.Render(title) + "\n")
+	}
+
+	section("PromptQL")
+	if c := m.promptqlConfig; c != nil {
+		row("PromptQL enabled", yesNo(c.PromptQLEnabled))
+		row("Playground enabled", yesNo(c.PlaygroundEnabled))
+	}
+
+	section("Playground")
+	c := m.playgroundConfig
+	if c == nil {
+		return b.String()
+	}
+	row("LLM provider", orDefault(c.LLMProvider))
+	llmKey := "(not set)"
+	if c.LLMApiKey != "" {
+		llmKey = maskSecret(c.LLMApiKey)
+	}
+	row("LLM API key", llmKey)
+	row("Allow public access", yesNo(c.AllowPublicAccess != nil && *c.AllowPublicAccess))
+	row("Project token usage limit", formatLimit(c.ProjectTokenUsageLimit))
+	row("User token usage limit", formatLimit(c.UserTokenUsageLimit))
+
+	if len(c.FeatureFlags) == 0 {
+		row("Feature flags", "(none)")
+	} else {
+		names := make([]string, 0, len(c.FeatureFlags))
+		for name := range c.FeatureFlags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		row("Feature flags", "")
+		for _, name := range names {
+			row("  "+name, fmt.Sprint(c.FeatureFlags[name]))
+		}
+	}
+
+	section("System instructions")
+	b.WriteString(boxStyle.Render(orDefault(c.SystemInstructions)) + "\n")
+	section("Readme")
+	b.WriteString(boxStyle.Render(orDefault(c.Readme)) + "\n")
+	return b.String()
+}
+
+func (m Model) viewAdminForm() string {
+	var b strings.Builder
+	for i := range m.adminFields {
+		f := &m.adminFields[i]
+		if i == m.adminFieldCursor {
+			b.WriteString(promptStyle.Render("> " + f.label))
+		} else {
+			b.WriteString(helpStyle.Render("  " + f.label))
+		}
+		b.WriteString("\n" + f.view() + "\n\n")
+	}
+	return b.String()
+}
+
+func (m Model) viewAdminPreview() string {
+	var b strings.Builder
+	b.WriteString(subtitleStyle.Render("Review changes before saving"))
+	b.WriteString("\n\n")
+	b.WriteString(m.adminDiff)
+	return b.String()
+}
+
+func (m Model) updateAdmin(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case adminConfigLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.promptqlConfig = msg.promptql
+		m.playgroundConfig = msg.playground
+		m.instructionHistory = msg.history
+		return m, nil
+
+	case promptqlToggledMsg:
+		m.notice = msg.message
+		return m, m.loadAdminConfig()
+
+	case playgroundSavedMsg:
+		m.loading = false
+		m.err = nil
+		m.playgroundConfig = msg.config
+		m.adminScroll = 0
+		if msg.history != nil {
+			m.instructionHistory = msg.history
+		}
+		if m.adminMode == adminHistory {
+			m.historyCursor = 0
+			m.notice = "System instructions rolled back"
+		} else {
+			m.adminMode = adminOverview
+			m.notice = "Playground settings saved"
+		}
+		if msg.historyErr != nil {
+			m.err = fmt.Errorf("settings saved, but recording instruction history failed: %w", msg.historyErr)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			if m.adminMode == adminOverview {
+				m.err = errCancelled
+			}
+			return m, nil
+		}
+		if m.loading {
+			return m, nil
+		}
+		switch m.adminMode {
+		case adminEdit:
+			return m.updateAdminForm(msg)
+		case adminInstructions:
+			return m.updateInstructionsEditor(msg)
+		case adminHistory:
+			return m.updateInstructionHistory(msg)
+		case adminConfirmRollback:
+			switch msg.String() {
+			case "y":
+				return m.rollback()
+			case "n":
+				m.adminMode = adminHistory
+			}
+			return m, nil
+		case adminConfirmToggle:
+			switch msg.String() {
+			case "y":
+				m.adminMode = adminOverview
+				m.loading = true
+				enable := m.promptqlConfig == nil || !m.promptqlConfig.PromptQLEnabled
+				return m, tea.Batch(m.spinner.Tick, m.togglePromptQL(enable))
+			case "n":
+				m.adminMode = adminOverview
+			}
+			return m, nil
+		case adminPreview:
+			switch msg.String() {
+			case "y":
+				m.loading = true
+				m.err = nil
+				return m, tea.Batch(m.spinner.Tick, m.savePlaygroundConfig(m.adminUpdate))
+			case "n":
+				return m.closePreview()
+			}
+			return m.scrollAdmin(msg), nil
+		}
+
+		switch msg.String() {
+		case "e":
+			return m.editPlaygroundConfig()
+		case "i":
+			return m.editInstructions()
+		case "h":
+			return m.openInstructionHistory()
+		case "t":
+			if m.promptqlConfig != nil {
+				m.adminMode = adminConfirmToggle
+				m.notice = ""
+			}
+			return m, nil
+		case "r":
+			m.loading = true
+			m.err = nil
+			return m, tea.Batch(m.spinner.Tick, m.loadAdminConfig())
+		}
+		return m.scrollAdmin(msg), nil
+	}
+	return m, nil
+}
+
+func (m Model) scrollAdmin(msg tea.KeyMsg) Model {
+	switch msg.String() {
+	case "j", "down":
+		m.adminScroll++
+	case "k", "up":
+		m.adminScroll = max(m.adminScroll-1, 0)
+	case "pgdown":
+		m.adminScroll += max(m.height-8, 10)
+	case "pgup":
+		m.adminScroll = max(m.adminScroll-max(m.height-8, 10), 0)
+	}
+	return m
+}
+
+func (m Model) updateAdminForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "shift+tab":
+		step := 1
+		if msg.String() == "shift+tab" {
+			step = adminFieldCount - 1
+		}
+		m.adminFields[m.adminFieldCursor].blur()
+		m.adminFieldCursor = (m.adminFieldCursor + step) % adminFieldCount
+		return m, m.adminFields[m.adminFieldCursor].focus()
+	case "ctrl+s":
+		update, err := m.playgroundUpdate()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		if update.IsEmpty() {
+			m.notice = "No changes to save"
+			return m, nil
+		}
+		return m.previewUpdate(update), nil
+	}
+	return m, m.adminFields[m.adminFieldCursor].update(msg)
+}
+
+// previewUpdate shows the changes an update would make before saving it.
+func (m Model) previewUpdate(update sdk.PlaygroundConfigUpdate) Model {
+	m.adminUpdate = update
+	m.adminDiff = describeUpdate(m.playgroundConfig, update)
+	m.adminPreviewFrom = m.adminMode
+	m.adminMode = adminPreview
+	m.adminScroll = 0
+	m.notice = ""
+	return m
+}
+
+// closePreview returns from the preview to the editor it was opened from.
+func (m Model) closePreview() (tea.Model, tea.Cmd) {
+	m.adminMode = m.adminPreviewFrom
+	m.adminScroll = 0
+	if m.adminMode == adminInstructions {
+		return m, m.instructionsInput.Focus()
+	}
+	return m, nil
+}
+
+// editPlaygroundConfig opens the settings form filled with the current values.
+func (m Model) editPlaygroundConfig() (tea.Model, tea.Cmd) {
+	c := m.playgroundConfig
+	if c == nil {
+		return m, nil
+	}
+	m.adminFields = newAdminFields()
+	for i := range m.adminFields {
+		if m.adminFields[i].multiline {
+			m.adminFields[i].area.SetWidth(max(m.width-4, 40))
+		}
+	}
+	m.adminFields[adminFieldProvider].setValue(c.LLMProvider)
+	m.adminFields[adminFieldPublic].setValue(yesNo(c.AllowPublicAccess != nil && *c.AllowPublicAccess))
+	if c.ProjectTokenUsageLimit != nil {
+		m.adminFields[adminFieldProjectLimit].setValue(strconv.Itoa(*c.ProjectTokenUsageLimit))
+	}
+	if c.UserTokenUsageLimit != nil {
+		m.adminFields[adminFieldUserLimit].setValue(strconv.Itoa(*c.UserTokenUsageLimit))
+	}
+	m.adminFields[adminFieldFlags].setValue(formatFlags(c.FeatureFlags))
+	m.adminFields[adminFieldInstructions].setValue(c.SystemInstructions)
+	m.adminFields[adminFieldReadme].setValue(c.Readme)
+
+	m.adminMode = adminEdit
+	m.adminFieldCursor = adminFieldProvider
+	m.adminScroll = 0
+	m.notice = ""
+	m.err = nil
+	return m, m.adminFields[adminFieldProvider].focus()
+}
+
+// playgroundUpdate validates the settings form and returns the fields that
+// differ from the current configuration.
+func (m Model) playgroundUpdate() (sdk.PlaygroundConfigUpdate, error) {
+	var u sdk.PlaygroundConfigUpdate
+	c := m.playgroundConfig
+	value := func(field int) string { return m.adminFields[field].value() }
+
+	if v := strings.TrimSpace(value(adminFieldProvider)); v != c.LLMProvider {
+		u.LLMProvider = &v
+	}
+
+	public, err := parseYesNo(value(adminFieldPublic))
+	if err != nil {
+		return u, fmt.Errorf("allow public access: %w", err)
+	}
+	if public != (c.AllowPublicAccess != nil && *c.AllowPublicAccess) {
+		u.AllowPublicAccess = &public
+	}
+
+	limits := []struct {
+		field   int
+		label   string
+		current *int
+		dst     **int
+	}{
+		{adminFieldProjectLimit, "project token usage limit", c.ProjectTokenUsageLimit, &u.ProjectTokenUsageLimit},
+		{adminFieldUserLimit, "user token usage limit", c.UserTokenUsageLimit, &u.UserTokenUsageLimit},
+	}
+	for _, l := range limits {
+		v := strings.TrimSpace(value(l.field))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return u, fmt.Errorf("%s must be a non-negative number", l.label)
+		}
+		if l.current == nil || *l.current != n {
+			*l.dst = &n
+		}
+	}
+
+	flags := map[string]interface{}{}
+	if v := strings.TrimSpace(value(adminFieldFlags)); v != "" {
+		if err := json.Unmarshal([]byte(v), &flags); err != nil {
+			return u, fmt.Errorf("feature flags must be a JSON object: %w", err)
+		}
+	}
+	current := c.FeatureFlags
+	if current == nil {
+		current = map[string]interface{}{}
+	}
+	if !reflect.DeepEqual(flags, current) {
+		u.FeatureFlags = flags
+	}
+
+	if v := value(adminFieldInstructions); v != c.SystemInstructions {
+		u.SystemInstructions = &v
+	}
+	if v := value(adminFieldReadme); v != c.Readme {
+		u.Readme = &v
+	}
+	return u, nil
+}
+
+// describeUpdate renders the changes an update makes to c, with unified
+// diffs for the multi-line settings.
+func describeUpdate(c *sdk.PlaygroundConfig, u sdk.PlaygroundConfigUpdate) string {
+	var b strings.Builder
+	change := func(label, from, to string) {
+		b.WriteString(fmt.Sprintf("%s: %s → %s\n", label, errorStyle.Render(from), successStyle.Render(to)))
+	}
+	textDiff := func(label, from, to string) {
+		b.WriteString("\n" + subtitleStyle.Render(label) + "\n")
+		b.WriteString(renderDiff(diff.Unified(label+" (current)", label+" (new)", from, to, 3)))
+	}
+
+	if u.LLMProvider != nil {
+		change("LLM provider", orDefault(c.LLMProvider), orDefault(*u.LLMProvider))
+	}
+	if u.AllowPublicAccess != nil {
+		change("Allow public access", yesNo(c.AllowPublicAccess != nil && *c.AllowPublicAccess), yesNo(*u.AllowPublicAccess))
+	}
+	if u.ProjectTokenUsageLimit != nil {
+		change("Project token usage limit", formatLimit(c.ProjectTokenUsageLimit), formatLimit(u.ProjectTokenUsageLimit))
+	}
+	if u.UserTokenUsageLimit != nil {
+		change("User token usage limit", formatLimit(c.UserTokenUsageLimit), formatLimit(u.UserTokenUsageLimit))
+	}
+	if u.FeatureFlags != nil {
+		textDiff("Feature flags", formatFlags(c.FeatureFlags), formatFlags(u.FeatureFlags))
+	}
+	if u.SystemInstructions != nil {
+		textDiff("System instructions", c.SystemInstructions, *u.SystemInstructions)
+	}
+	if u.Readme != nil {
+		textDiff("Readme", c.Readme, *u.Readme)
+	}
+	return b.String()
+}
+
+// renderDiff colors the lines of a unified diff.
+func renderDiff(d string) string {
+	lines := strings.Split(strings.TrimSuffix(d, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			lines[i] = helpStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = successStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = errorStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// scrollLines returns up to height lines of s starting at offset.
+func scrollLines(s string, offset, height int) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	offset = min(offset, max(len(lines)-height, 0))
+	end := min(offset+height, len(lines))
+	return strings.Join(lines[offset:end], "\n")
+}
+
+func formatFlags(flags map[string]interface{}) string {
+	if len(flags) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(flags, "", "  ")
+	if err != nil {
+		return fmt.Sprint(flags)
+	}
+	return string(data)
+}
+
+func formatLimit(n *int) string {
+	if n == nil {
+		return "(none)"
+	}
+	return strconv.Itoa(*n)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func parseYesNo(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "true", "on":
+		return true, nil
+	case "no", "n", "false", "off", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected yes or no, got %q", s)
+}
+
+func orDefault(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "(not set)"
+	}
+	return s
+}
+
+// maskSecret shows only the last four characters of a secret.
+func maskSecret(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("•", len(s))
+	}
+	return strings.Repeat("•", 8) + s[len(s)-4:]
+}
+
+// --- Commands ---
+
+func (m Model) loadAdminConfig() tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		projectID := m.selectedProject.ProjectID
+		promptql, err := m.client.Projects().GetConfigContext(ctx, projectID)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		playground, err := m.client.Projects().GetPlaygroundConfigContext(ctx, projectID)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		history, err := config.LoadInstructionHistory(projectID)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return adminConfigLoadedMsg{scope: m.scope(), promptql: promptql, playground: playground, history: history}
+	})
+}
+
+func (m Model) togglePromptQL(enable bool) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		projects := m.client.Projects()
+		var (
+			result *sdk.MessageResult
+			err    error
+		)
+		if enable {
+			result, err = projects.EnableContext(ctx, m.selectedProject.ProjectID)
+		} else {
+			result, err = projects.DisableContext(ctx, m.selectedProject.ProjectID)
+		}
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return promptqlToggledMsg{scope: m.scope(), message: result.Message}
+	})
+}
+
+func (m Model) savePlaygroundConfig(update sdk.PlaygroundConfigUpdate) tea.Cmd {
+	projectID := m.selectedProject.ProjectID
+	before := m.playgroundConfig.SystemInstructions
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		cfg, err := m.client.Projects().UpdatePlaygroundConfigContext(ctx, projectID, update)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		if update.SystemInstructions == nil {
+			return playgroundSavedMsg{scope: m.scope(), config: cfg}
+		}
+		history, err := recordInstructions(projectID, before, cfg.SystemInstructions, 0)
+		return playgroundSavedMsg{scope: m.scope(), config: cfg, history: history, historyErr: err}
+	})
+}
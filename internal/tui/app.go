@@ -1,8 +1,11 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -10,7 +13,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/connect"
 	"github.com/sandalsoft/promptql-tui/internal/sdk"
+	"github.com/sandalsoft/promptql-tui/internal/search"
 )
 
 type view int
@@ -20,6 +25,11 @@ const (
 	viewProjects
 	viewThreads
 	viewChat
+	viewPrompts
+	viewKeys
+	viewAdmin
+	viewProfiles
+	viewSearch
 )
 
 type setupField int
@@ -34,8 +44,12 @@ const (
 
 // ChatMessage represents a message displayed in the chat view.
 type ChatMessage struct {
-	Role    string // "user" or "assistant"
-	Content string
+	Role      string // "user" or "assistant"
+	Content   string
+	Artifacts []sdk.Artifact
+	EventID   int    // thread event the message came from; 0 in direct query mode
+	MessageID string // set for user and assistant messages of a thread
+	Feedback  int    // sdk.FeedbackPositive or sdk.FeedbackNegative once submitted
 }
 
 // Model is the root Bubble Tea model for the TUI.
@@ -50,25 +64,129 @@ type Model struct {
 	loading bool
 	spinner spinner.Model
 
+	// Requests in flight
+	requests    *requests
+	scopeGen    int    // bumped when the active project or thread changes
+	pendingText string // message being sent, put back in the input if cancelled
+	pendingAt   int    // index of its chat message
+
+	// Offline cache
+	refreshing bool      // showing cached data while a fresh copy loads
+	offline    bool      // the last request could not reach the API
+	cachedAt   time.Time // when the data shown was cached; zero if fresh
+
 	// Setup view
 	setupInputs []textinput.Model
 	setupCursor int
 
+	// Profiles view
+	profileCursor   int
+	profileCreating bool
+	profileInput    textinput.Model
+
 	// Projects view
-	projects       []sdk.UserProject
-	projectCursor  int
+	projects        []sdk.UserProject
+	projectCursor   int
 	selectedProject *sdk.UserProject
-	buildFQDN      string
+	buildFQDN       string
 
 	// Threads view
-	threads      []sdk.Thread
-	threadCursor int
-	activeThread *sdk.Thread
+	threads         []sdk.Thread
+	threadCursor    int // position in visibleThreads, 0 is "New Thread"
+	activeThread    *sdk.Thread
+	threadFilter    threadFilter
+	threadSearch    textinput.Model
+	threadSearching bool
+
+	// Prompts view
+	prompts      []sdk.SamplePrompt
+	promptCursor int
+	promptMode   promptMode
+	promptEditID string // empty when creating a prompt
+	promptTitle  textinput.Model
+	promptBody   textarea.Model
+	promptQuery  string // name given to /prompt while the prompts load
+
+	// API keys view
+	apiKeys        []sdk.RuntimeAPIKey
+	keyCursor      int
+	keyMode        keyMode
+	keyInputs      []textinput.Model
+	keyFieldCursor int
+	revealedKey    *sdk.GeneratedAPIKey // plaintext key, kept only while shown
+
+	// Project admin view
+	promptqlConfig   *sdk.PromptQLConfig
+	playgroundConfig *sdk.PlaygroundConfig
+	adminMode        adminMode
+	adminFields      []adminField
+	adminFieldCursor int
+	adminUpdate      sdk.PlaygroundConfigUpdate // pending changes shown in the preview
+	adminDiff        string                     // rendered preview of adminUpdate
+	adminPreviewFrom adminMode                  // editor to return to from the preview
+	adminScroll      int
+
+	// System instructions editor and history
+	instructionsInput  textarea.Model
+	instructionHistory *config.InstructionHistory
+	historyCursor      int  // position in the history list, newest first
+	historyAgainstLive bool // diff the selected version against the live one
+
+	// Full-text search
+	searchIndex   *search.Index
+	searchProject string // project the index belongs to
+	searchPending int    // threads still to be indexed
+	searchErr     error  // last indexing error
+	indexGen      int    // bumped when threads are reloaded, to stop older indexing runs
+	indexCtx      context.Context
+	indexCancel   context.CancelFunc // aborts the fetches of the indexing run
+	searchInput   textinput.Model
+	searchResults []search.Result
+	searchCursor  int
 
 	// Chat view
 	chatInput textarea.Model
 	messages  []ChatMessage
 	threadID  string
+	notice    string // transient confirmation shown below the messages
+
+	newThreadVisibility string // set with /visibility before a thread starts
+
+	// Composer: the chat input's sent message history and per-thread drafts
+	composer      *config.Composer // of the active project; nil until loaded
+	composerSaves *composerSaves   // orders the writes of its snapshots
+	recallDepth   int              // how far back in the history the input is; 0 when not recalling
+	recallDraft   string           // the text being written when recall started
+	slashCursor   int              // selected entry of the slash command popup
+
+	// Chat scrolling
+	chatTop      int  // first message line shown while scrolled
+	chatScrolled bool // scrolled away from the latest message
+
+	// Message selection and feedback
+	selecting     bool // the cursor is in the message history, not the input
+	msgCursor     int
+	feedbackValue int // rating awaiting optional details; 0 when not rating
+	feedbackInput textinput.Model
+
+	// Export prompt
+	exporting   bool
+	exportInput textinput.Model
+
+	// Table focus mode
+	tableFocus *artifactTable
+	tableIdx   int // index into m.tableArtifacts()
+
+	// Direct query
+	conversation *sdk.Conversation
+	stream       *sdk.QueryStream
+	streamResult *sdk.QueryResponse
+	streamMsgIdx int
+
+	// Search jump: the chat opened at a matching message
+	jumping     bool
+	jumpMessage int
+	jumpTerms   []string
 }
 
 // New creates a new TUI model.
@@ -102,42 +220,33 @@ func New(cfg *config.Config) Model {
 	tz.CharLimit = 64
 	inputs[fieldTimezone] = tz
 
-	// Pre-fill from config
-	if cfg.PAT != "" {
-		inputs[fieldPAT].SetValue(cfg.PAT)
-	}
-	if cfg.APIKey != "" {
-		inputs[fieldAPIKey].SetValue(cfg.APIKey)
-	}
-	if cfg.DDNURL != "" {
-		inputs[fieldDDNURL].SetValue(cfg.DDNURL)
-	}
-	if cfg.Timezone != "" {
-		inputs[fieldTimezone].SetValue(cfg.Timezone)
-	}
+	fillSetupInputs(inputs, cfg)
 
-	// Chat input
-	ta := textarea.New()
-	ta.Placeholder = "Ask PromptQL a question..."
-	ta.CharLimit = 4096
-	ta.SetHeight(3)
-	ta.ShowLineNumbers = false
+	promptTitle, promptBody := newPromptInputs()
 
 	m := Model{
-		cfg:         cfg,
-		spinner:     s,
-		setupInputs: inputs,
-		chatInput:   ta,
+		cfg:               cfg,
+		promptTitle:       promptTitle,
+		promptBody:        promptBody,
+		keyInputs:         newKeyInputs(),
+		instructionsInput: newInstructionsInput(),
+		profileInput:      newProfileInput(),
+		threadSearch:      newThreadSearchInput(),
+		searchInput:       newSearchInput(),
+		spinner:           s,
+		setupInputs:       inputs,
+		chatInput:         newChatInput(cfg.SendKey),
+		exportInput:       newExportInput(),
+		feedbackInput:     newFeedbackInput(),
+		conversation:      newConversation(),
+		requests:          &requests{},
+		composerSaves:     &composerSaves{latest: map[string]uint64{}},
 	}
 
 	// Skip setup if already configured
 	if cfg.HasCredentials() {
 		m.view = viewProjects
-		m.client = sdk.NewClient(sdk.ClientOptions{
-			PAT:       cfg.PAT,
-			APIKey:    cfg.APIKey,
-			ProjectID: cfg.ProjectID,
-		})
+		m.client = connect.NewClient(cfg)
 		m.loading = true
 	} else {
 		m.view = viewSetup
@@ -147,6 +256,24 @@ func New(cfg *config.Config) Model {
 	return m
 }
 
+// fillSetupInputs pre-fills the setup form from the active profile.
+func fillSetupInputs(inputs []textinput.Model, cfg *config.Config) {
+	inputs[fieldPAT].SetValue(cfg.PAT)
+	inputs[fieldAPIKey].SetValue(cfg.APIKey)
+	inputs[fieldDDNURL].SetValue(cfg.DDNURL)
+	inputs[fieldTimezone].SetValue(cfg.Timezone)
+}
+
+// newConversation returns an empty direct-query history. Older turns are
+// folded into a short recap once the history grows past the limits.
+func newConversation() *sdk.Conversation {
+	return &sdk.Conversation{Limits: sdk.HistoryLimits{
+		MaxInteractions: 20,
+		MaxBytes:        256 << 10,
+		Summarize:       sdk.SummarizeText(4000),
+	}}
+}
+
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{m.spinner.Tick}
 	if m.loading && m.view == viewProjects {
@@ -156,12 +283,23 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if sm, ok := msg.(scopedMsg); ok && sm.msgScope() != m.scope() {
+		// The user moved to another project or thread meanwhile.
+		return m, nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
-			return m, tea.Quit
-		case "esc":
+			// Keep the unsent message for next time.
+			m, save := m.stashDraft()
+			return m, tea.Sequence(save, tea.Quit)
+		}
+		if m.revealedKey != nil {
+			return m.updateKeyReveal(msg)
+		}
+		if msg.String() == "esc" {
 			return m.handleEsc()
 		}
 
@@ -169,6 +307,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.chatInput.SetWidth(msg.Width - 4)
+		m.promptBody.SetWidth(msg.Width - 4)
+		if m.tableFocus != nil {
+			m.tableFocus.layout(m.chatWidth(), m.tableHeight())
+		}
 		return m, nil
 
 	case spinner.TickMsg:
@@ -177,8 +319,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case errMsg:
+		if sdk.IsNetworkError(msg.err) {
+			m.offline = true
+			if m.refreshing {
+				// Keep browsing the cached copy.
+				m.refreshing = false
+				return m, nil
+			}
+		}
+		m.refreshing = false
 		m.err = msg.err
 		m.loading = false
+		m.pendingText = ""
+		return m, nil
+
+	case composerSavedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("saving message history: %w", msg.err)
+		}
+		return m, nil
+
+	case searchIndexedMsg:
+		// Indexing continues in the background whatever the view.
+		return m.handleSearchIndexed(msg)
+
+	case apiKeyGeneratedMsg:
+		return m.handleAPIKeyGenerated(msg), nil
+
+	case apiKeySavedMsg:
+		m.err = nil
+		m.notice = "Saved as your API key"
+		return m, nil
+
+	case clipboardMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("copying to clipboard: %w", msg.err)
+			return m, nil
+		}
+		m.err = nil
+		m.notice = "Copied to clipboard"
 		return m, nil
 	}
 
@@ -191,12 +370,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateThreads(msg)
 	case viewChat:
 		return m.updateChat(msg)
+	case viewPrompts:
+		return m.updatePrompts(msg)
+	case viewKeys:
+		return m.updateKeys(msg)
+	case viewAdmin:
+		return m.updateAdmin(msg)
+	case viewProfiles:
+		return m.updateProfiles(msg)
+	case viewSearch:
+		return m.updateSearch(msg)
 	}
 
 	return m, nil
 }
 
 func (m Model) View() string {
+	if m.revealedKey != nil {
+		return m.viewKeyReveal()
+	}
+
 	var content string
 	switch m.view {
 	case viewSetup:
@@ -207,6 +400,16 @@ func (m Model) View() string {
 		content = m.viewThreads()
 	case viewChat:
 		content = m.viewChat()
+	case viewPrompts:
+		content = m.viewPrompts()
+	case viewKeys:
+		content = m.viewKeys()
+	case viewAdmin:
+		content = m.viewAdmin()
+	case viewProfiles:
+		content = m.viewProfiles()
+	case viewSearch:
+		content = m.viewSearch()
 	}
 
 	return content
@@ -217,6 +420,10 @@ func (m Model) View() string {
 func (m Model) viewSetup() string {
 	var b strings.Builder
 	b.WriteString(titleStyle.Render("PromptQL TUI Setup"))
+	multiProfile := len(m.cfg.ProfileNames()) > 1
+	if multiProfile {
+		b.WriteString("  " + subtitleStyle.Render("profile: "+m.cfg.Name()))
+	}
 	b.WriteString("\n\n")
 
 	labels := []string{"PAT (Personal Access Token)", "API Key", "DDN URL", "Timezone"}
@@ -236,7 +443,11 @@ func (m Model) viewSetup() string {
 		b.WriteString("\n\n")
 	}
 
-	b.WriteString(helpStyle.Render("tab/shift+tab: navigate  |  enter: save & continue  |  ctrl+c: quit"))
+	if multiProfile {
+		b.WriteString(helpStyle.Render("tab/shift+tab: navigate  |  enter: save & continue  |  esc: profiles  |  ctrl+c: quit"))
+	} else {
+		b.WriteString(helpStyle.Render("tab/shift+tab: navigate  |  enter: save & continue  |  ctrl+c: quit"))
+	}
 	return b.String()
 }
 
@@ -285,17 +496,14 @@ func (m Model) saveSetup() (tea.Model, tea.Cmd) {
 		m.cfg.Timezone = "UTC"
 	}
 
-	m.client = sdk.NewClient(sdk.ClientOptions{
-		PAT:    m.cfg.PAT,
-		APIKey: m.cfg.APIKey,
-	})
+	m.client = connect.NewClient(m.cfg)
 
-	return m, func() tea.Msg {
-		if err := m.cfg.Save(); err != nil {
-			return errMsg{err}
+	return m, m.saveConfig(func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{m.scope(), err}
 		}
-		return configSavedMsg{}
-	}
+		return configSavedMsg{m.scope()}
+	})
 }
 
 // --- Projects View ---
@@ -303,22 +511,28 @@ func (m Model) saveSetup() (tea.Model, tea.Cmd) {
 func (m Model) viewProjects() string {
 	var b strings.Builder
 	b.WriteString(titleStyle.Render("PromptQL Projects"))
+	if len(m.cfg.ProfileNames()) > 1 {
+		b.WriteString("  " + subtitleStyle.Render("profile: "+m.cfg.Name()))
+	}
+	if status := m.cacheStatus(); status != "" {
+		b.WriteString("  " + helpStyle.Render(status))
+	}
 	b.WriteString("\n")
 
 	if m.loading {
-		b.WriteString(m.spinner.View() + " Loading projects...")
+		b.WriteString(m.spinner.View() + " Loading projects...  " + helpStyle.Render("ctrl+x: cancel"))
 		return b.String()
 	}
 
 	if m.err != nil {
 		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
-		b.WriteString(helpStyle.Render("r: retry  |  s: setup  |  ctrl+c: quit"))
+		b.WriteString(helpStyle.Render("r: retry  |  s: setup  |  p: profiles  |  ctrl+c: quit"))
 		return b.String()
 	}
 
 	if len(m.projects) == 0 {
 		b.WriteString(helpStyle.Render("No projects found.") + "\n\n")
-		b.WriteString(helpStyle.Render("s: setup  |  ctrl+c: quit"))
+		b.WriteString(helpStyle.Render("s: setup  |  p: profiles  |  ctrl+c: quit"))
 		return b.String()
 	}
 
@@ -370,15 +584,19 @@ func (m Model) viewProjects() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: select  |  s: setup  |  ctrl+c: quit"))
+	b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: select  |  s: setup  |  p: profiles  |  ctrl+c: quit"))
 	return b.String()
 }
 
 func (m Model) updateProjects(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case projectsLoadedMsg:
+		var ok bool
+		if m, ok = m.applyLoad(msg.cachedAt); !ok {
+			return m, nil
+		}
 		m.projects = msg.projects
-		m.loading = false
+		m.projectCursor = min(m.projectCursor, max(len(m.projects)-1, 0))
 		m.err = nil
 		return m, nil
 
@@ -391,12 +609,20 @@ func (m Model) updateProjects(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.cfg.ProjectID = msg.result.ProjectID
 		m.loading = false
-		// Now load threads
+		// Now load threads, keeping only the sort order of the last project
+		m.threadFilter = threadFilter{sort: m.threadFilter.sort}
+		m.threadSearch.SetValue("")
+		m.threadCursor = 0
 		m.view = viewThreads
 		m.loading = true
 		return m, tea.Batch(m.spinner.Tick, m.loadThreads())
 
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			m.err = errCancelled
+			return m, nil
+		}
 		if m.loading {
 			return m, nil
 		}
@@ -424,6 +650,8 @@ func (m Model) updateProjects(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.view = viewSetup
 			m.setupInputs[0].Focus()
 			return m, nil
+		case "p":
+			return m.openProfiles()
 		}
 	}
 
@@ -432,7 +660,9 @@ func (m Model) updateProjects(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) selectProject() (tea.Model, tea.Cmd) {
 	p := m.projects[m.projectCursor]
+	m = m.enterScope()
 	m.selectedProject = &p
+	m.prompts = nil
 	m.loading = true
 	m.err = nil
 
@@ -452,10 +682,13 @@ func (m Model) viewThreads() string {
 	if projectName != "" {
 		b.WriteString("  " + subtitleStyle.Render(projectName))
 	}
+	if status := m.cacheStatus(); status != "" {
+		b.WriteString("  " + helpStyle.Render(status))
+	}
 	b.WriteString("\n")
 
 	if m.loading {
-		b.WriteString(m.spinner.View() + " Loading threads...")
+		b.WriteString(m.spinner.View() + " Loading threads...  " + helpStyle.Render("ctrl+x: cancel"))
 		return b.String()
 	}
 
@@ -465,48 +698,113 @@ func (m Model) viewThreads() string {
 		return b.String()
 	}
 
-	b.WriteString(subtitleStyle.Render("Select a thread or start a new one"))
-	b.WriteString("\n\n")
-
-	// "New Thread" option always first
-	if m.threadCursor == 0 {
-		b.WriteString(selectedItemStyle.Render("> + New Thread"))
+	visible := m.visibleThreads()
+	if len(m.threads) == 0 {
+		b.WriteString(subtitleStyle.Render("Select a thread or start a new one"))
 	} else {
-		b.WriteString(normalItemStyle.Render("  + New Thread"))
+		b.WriteString(subtitleStyle.Render(describeThreadFilter(m.threadFilter, len(visible), len(m.threads))))
+	}
+	b.WriteString("\n")
+	if m.threadSearching || m.threadFilter.query != "" {
+		b.WriteString(m.threadSearch.View())
+		b.WriteString("\n")
 	}
 	b.WriteString("\n")
 
-	for i, t := range m.threads {
+	rows := threadRows(visible, m.threadFilter.sort, time.Now())
+
+	// Keep the cursor visible: header takes ~5 lines, footer ~4
+	maxVisible := max(m.height-9, 3)
+	maxVisible = min(maxVisible, len(rows))
+	cursorRow := 0
+	for i, r := range rows {
+		if r.heading == "" && r.cursor == m.threadCursor {
+			cursorRow = i
+		}
+	}
+	scrollOffset := 0
+	if cursorRow >= maxVisible {
+		scrollOffset = cursorRow - maxVisible + 1
+	}
+	// Show the heading of a group when its first thread is at the top
+	if scrollOffset > 0 && scrollOffset == cursorRow && rows[cursorRow-1].heading != "" {
+		scrollOffset--
+	}
+	end := min(scrollOffset+maxVisible, len(rows))
+
+	if scrollOffset > 0 {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more above", scrollOffset)))
+		b.WriteString("\n")
+	}
+	for _, r := range rows[scrollOffset:end] {
+		if r.heading != "" {
+			b.WriteString(promptStyle.Render(r.heading))
+			b.WriteString("\n")
+			continue
+		}
 		cursor := "  "
 		style := normalItemStyle
-		if i+1 == m.threadCursor {
+		if r.cursor == m.threadCursor {
 			cursor = "> "
 			style = selectedItemStyle
 		}
-		title := t.Title
-		if title == "" {
-			title = t.ThreadID
+		if r.thread == nil {
+			b.WriteString(style.Render(cursor + "+ New Thread"))
+			b.WriteString("\n")
+			continue
+		}
+		t := *r.thread
+		ts := t.UpdatedAt
+		if m.threadFilter.sort == sortCreated || ts == "" {
+			ts = t.CreatedAt
+		}
+		meta := ""
+		if ts != "" {
+			meta = formatThreadTime(ts)
 		}
-		ts := ""
-		if t.UpdatedAt != "" {
-			ts = helpStyle.Render(fmt.Sprintf("  (%s)", t.UpdatedAt[:min(19, len(t.UpdatedAt))]))
+		if t.Visibility != "" {
+			meta = strings.TrimSpace(meta + "  " + t.Visibility)
 		}
-		b.WriteString(style.Render(fmt.Sprintf("%s%s", cursor, title)) + ts)
+		if meta != "" {
+			meta = helpStyle.Render(fmt.Sprintf("  (%s)", meta))
+		}
+		b.WriteString(style.Render(cursor+threadTitle(t)) + meta)
+		b.WriteString("\n")
+	}
+	if end < len(rows) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more below", len(rows)-end)))
+		b.WriteString("\n")
+	}
+	if len(visible) == 0 && m.threadFilter.filtered() {
+		b.WriteString(helpStyle.Render("  No threads match. esc: clear search and filters"))
 		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: select  |  n: new thread  |  esc: back  |  ctrl+c: quit"))
+	if m.threadSearching {
+		b.WriteString(helpStyle.Render("type to search  |  ↑/↓: navigate  |  enter: done  |  esc: clear search"))
+		return b.String()
+	}
+	b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: select  |  n: new thread  |  p: sample prompts  |  a: API keys  |  c: project settings  |  esc: back  |  ctrl+c: quit"))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("/: search titles  |  f: search messages  |  s: sort  |  d: date range  |  v: visibility  |  r: refresh"))
 	return b.String()
 }
 
 func (m Model) updateThreads(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case threadsLoadedMsg:
+		var ok bool
+		if m, ok = m.applyLoad(msg.cachedAt); !ok {
+			return m, nil
+		}
 		m.threads = msg.threads
-		m.loading = false
 		m.err = nil
-		return m, nil
+		m.threadCursor = min(m.threadCursor, len(m.visibleThreads()))
+		if !msg.cachedAt.IsZero() {
+			return m, nil
+		}
+		return m.indexThreads(msg.threads)
 
 	case threadStartedMsg:
 		m.loading = false
@@ -521,20 +819,43 @@ func (m Model) updateThreads(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Add initial messages from thread events
 		for _, evt := range msg.result.ThreadEvents {
-			if content := extractEventContent(evt); content != "" {
-				m.messages = append(m.messages, ChatMessage{
-					Role:    "assistant",
-					Content: content,
-				})
-			}
+			m.appendEvent(evt.ThreadEventID, evt.Event())
 		}
 		return m, nil
 
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			if !m.offline && len(m.threads) == 0 {
+				m.err = errCancelled
+			}
+			return m, nil
+		}
 		if m.loading {
 			return m, nil
 		}
-		maxIdx := len(m.threads) // 0 = new thread, 1..N = threads
+		visible := m.visibleThreads()
+		maxIdx := len(visible) // 0 = new thread, 1..N = threads
+		if m.threadSearching {
+			switch msg.String() {
+			case "down":
+				m.threadCursor = min(m.threadCursor+1, maxIdx)
+			case "up":
+				m.threadCursor = max(m.threadCursor-1, 0)
+			case "enter":
+				m.threadSearching = false
+				m.threadSearch.Blur()
+			default:
+				var cmd tea.Cmd
+				m.threadSearch, cmd = m.threadSearch.Update(msg)
+				if q := strings.TrimSpace(m.threadSearch.Value()); q != m.threadFilter.query {
+					m.threadFilter.query = q
+					m = m.resetThreadCursor()
+				}
+				return m, cmd
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "j", "down":
 			if m.threadCursor < maxIdx {
@@ -548,23 +869,32 @@ func (m Model) updateThreads(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "enter":
 			if m.threadCursor == 0 {
-				// New thread - go to chat
-				m.view = viewChat
-				m.threadID = ""
-				m.activeThread = nil
-				m.messages = []ChatMessage{}
-				m.chatInput.Focus()
-				return m, nil
+				return m.openNewChat()
 			}
 			// Resume existing thread
-			return m.resumeThread(m.threads[m.threadCursor-1])
+			return m.resumeThread(visible[m.threadCursor-1])
+		case "/":
+			m.threadSearching = true
+			return m, m.threadSearch.Focus()
+		case "f":
+			return m.openSearch()
+		case "s":
+			m.threadFilter.sort = (m.threadFilter.sort + 1) % threadSortCount
+			return m.resetThreadCursor(), nil
+		case "d":
+			m.threadFilter.dateRange = (m.threadFilter.dateRange + 1) % threadRangeCount
+			return m.resetThreadCursor(), nil
+		case "v":
+			m.threadFilter.visibility = nextVisibility(m.threadFilter.visibility, m.threads)
+			return m.resetThreadCursor(), nil
 		case "n":
-			m.view = viewChat
-			m.threadID = ""
-			m.activeThread = nil
-			m.messages = []ChatMessage{}
-			m.chatInput.Focus()
-			return m, nil
+			return m.openNewChat()
+		case "p":
+			return m.openPrompts()
+		case "a":
+			return m.openKeys()
+		case "c":
+			return m.openAdmin()
 		case "r":
 			m.loading = true
 			m.err = nil
@@ -575,15 +905,56 @@ func (m Model) updateThreads(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// resetThreadCursor moves the cursor to the first thread of the list after
+// the filter or order changed, or to "New Thread" if nothing matches.
+func (m Model) resetThreadCursor() Model {
+	m.threadCursor = min(1, len(m.visibleThreads()))
+	return m
+}
+
+// clearThreadFilter drops the search and filters, keeping the sort order.
+func (m Model) clearThreadFilter() Model {
+	m.threadFilter = threadFilter{sort: m.threadFilter.sort}
+	m.threadSearch.SetValue("")
+	m.threadSearching = false
+	m.threadSearch.Blur()
+	m.threadCursor = 0
+	return m
+}
+
+// openNewChat switches to an empty chat for a new thread or conversation.
+func (m Model) openNewChat() (tea.Model, tea.Cmd) {
+	m = m.enterScope()
+	m, save := m.stashDraft()
+	m.view = viewChat
+	m.threadID = ""
+	m.activeThread = nil
+	m.newThreadVisibility = ""
+	m.messages = []ChatMessage{}
+	m.jumping = false
+	m.selecting = false
+	m.chatScrolled = false
+	m.conversation.Reset()
+	m.chatInput.Focus()
+	m, load := m.openComposer()
+	return m, tea.Batch(save, load)
+}
+
 func (m Model) resumeThread(t sdk.Thread) (tea.Model, tea.Cmd) {
+	m = m.enterScope()
+	m, save := m.stashDraft()
 	m.activeThread = &t
 	m.threadID = t.ThreadID
 	m.view = viewChat
 	m.loading = true
 	m.messages = []ChatMessage{}
+	m.jumping = false
+	m.selecting = false
+	m.chatScrolled = false
 	m.chatInput.Focus()
+	m, load := m.openComposer()
 
-	return m, tea.Batch(m.spinner.Tick, m.loadEvents(t.ThreadID))
+	return m, tea.Batch(m.spinner.Tick, m.loadEvents(t.ThreadID), save, load)
 }
 
 // --- Chat View ---
@@ -597,120 +968,258 @@ func (m Model) viewChat() string {
 	}
 	b.WriteString(titleStyle.Render("Chat"))
 	b.WriteString("  " + subtitleStyle.Render(threadTitle))
+	if status := m.cacheStatus(); status != "" {
+		b.WriteString("  " + helpStyle.Render(status))
+	}
 	b.WriteString("\n\n")
 
 	if m.loading && len(m.messages) == 0 {
-		b.WriteString(m.spinner.View() + " Loading...")
+		b.WriteString(m.spinner.View() + " Loading...  " + helpStyle.Render("ctrl+x: cancel"))
 		return b.String()
 	}
 
-	// Messages
-	maxMsgHeight := m.height - 12
-	if maxMsgHeight < 5 {
-		maxMsgHeight = 5
-	}
-	msgLines := []string{}
-	for _, msg := range m.messages {
-		switch msg.Role {
-		case "user":
-			msgLines = append(msgLines, userMsgStyle.Render("You: ")+msg.Content)
-		case "assistant":
-			msgLines = append(msgLines, assistantMsgStyle.Render("PromptQL: ")+msg.Content)
+	if m.tableFocus != nil {
+		b.WriteString(m.tableFocus.view(m.chatWidth(), m.tableHeight()))
+		b.WriteString("\n")
+		if m.exporting {
+			b.WriteString(m.viewExportPrompt())
+			return b.String()
 		}
-		msgLines = append(msgLines, "")
-	}
-
-	// Scroll to show recent messages
-	if len(msgLines) > maxMsgHeight {
-		msgLines = msgLines[len(msgLines)-maxMsgHeight:]
+		b.WriteString(helpStyle.Render("↑/↓/pgup/pgdn: rows  |  ←/→: columns  |  s: sort  |  enter: expand cell  |  [/]: prev/next table  |  ctrl+e: export  |  esc: back"))
+		return b.String()
 	}
 
-	b.WriteString(strings.Join(msgLines, "\n"))
+	b.WriteString(m.viewMessages())
 
 	if m.loading {
-		b.WriteString("\n" + m.spinner.View() + " Thinking...")
+		status := " Thinking..."
+		if m.stream != nil && m.streamMsgIdx >= 0 {
+			status = " Receiving..."
+		}
+		b.WriteString("\n" + m.spinner.View() + status + "  " + helpStyle.Render("ctrl+x: cancel"))
 	}
 
 	if m.err != nil {
 		b.WriteString("\n" + errorStyle.Render("Error: "+m.err.Error()))
+	} else if m.notice != "" {
+		b.WriteString("\n" + successStyle.Render(m.notice))
 	}
 
 	b.WriteString("\n\n")
+	if m.exporting {
+		b.WriteString(m.viewExportPrompt())
+		return b.String()
+	}
+	if m.feedbackValue != 0 {
+		b.WriteString(m.viewFeedbackPrompt())
+		return b.String()
+	}
+	if m.selecting {
+		b.WriteString(helpStyle.Render("↑/↓: select message  |  +: helpful  |  -: unhelpful  |  esc: back to input"))
+		return b.String()
+	}
 	b.WriteString(promptStyle.Render("Message: "))
 	b.WriteString("\n")
 	b.WriteString(m.chatInput.View())
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("ctrl+s: send  |  esc: back to threads  |  ctrl+c: quit"))
+	if suggestions := m.slashSuggestions(); len(suggestions) > 0 {
+		b.WriteString(m.viewSlashSuggestions(suggestions))
+		return b.String()
+	}
+	help := "ctrl+f: rate messages  |  esc: back to threads  |  ctrl+c: quit"
+	if len(m.tableArtifacts()) > 0 {
+		help = "ctrl+f: rate messages  |  ctrl+t: explore tables  |  esc: back to threads  |  ctrl+c: quit"
+	}
+	b.WriteString(helpStyle.Render(m.composerHelp()) + "\n")
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+func (m Model) viewExportPrompt() string {
+	var b strings.Builder
+	b.WriteString(promptStyle.Render("Export to: "))
+	b.WriteString(m.exportInput.View())
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(".csv/.json: selected artifact  |  .md: conversation transcript  |  enter: export  |  esc: cancel"))
 	return b.String()
 }
 
+// chatWidth is the usable width for chat content.
+func (m Model) chatWidth() int {
+	if m.width <= 0 {
+		return 80
+	}
+	return m.width
+}
+
 func (m Model) updateChat(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case eventsLoadedMsg:
-		m.loading = false
+		var ok bool
+		if m, ok = m.applyLoad(msg.cachedAt); !ok {
+			return m, nil
+		}
 		m.err = nil
+		m.messages = []ChatMessage{}
+		m.msgCursor = 0
+		m.selecting = false
 		for _, evt := range msg.events {
-			if content := extractEventContent(evt); content != "" {
-				role := "assistant"
-				if isUserEvent(evt) {
-					role = "user"
-				}
-				m.messages = append(m.messages, ChatMessage{
-					Role:    role,
-					Content: content,
-				})
-			}
+			m.appendEvent(evt.ThreadEventID, evt.Event())
 		}
-		return m, nil
+		if m.jumping {
+			m = m.scrollToMessage(m.jumpMessage)
+		}
+		if !msg.cachedAt.IsZero() {
+			return m, nil
+		}
+		return m, m.indexOpenThread()
 
 	case threadStartedMsg:
 		m.loading = false
 		m.err = nil
+		m.pendingText = ""
 		m.threadID = msg.result.ThreadID
 		m.activeThread = &sdk.Thread{
 			ThreadID: msg.result.ThreadID,
 			Title:    msg.result.Title,
 		}
 		for _, evt := range msg.result.ThreadEvents {
-			if content := extractEventContent(evt); content != "" {
-				m.messages = append(m.messages, ChatMessage{
-					Role:    "assistant",
-					Content: content,
-				})
+			// The user's message is already shown; only add the replies.
+			if _, ok := evt.Event().(*sdk.UserMessageEvent); ok {
+				continue
 			}
+			m.appendEvent(evt.ThreadEventID, evt.Event())
 		}
 		return m, nil
 
 	case messageSentMsg:
 		m.loading = false
 		m.err = nil
-		if content := extractSendMessageContent(msg.result); content != "" {
-			m.messages = append(m.messages, ChatMessage{
-				Role:    "assistant",
-				Content: content,
-			})
+		m.pendingText = ""
+		if msg.result != nil {
+			// The user's message is already shown; only add the reply.
+			if _, ok := msg.result.Event().(*sdk.UserMessageEvent); !ok {
+				m.appendEvent(msg.result.ThreadEventID, msg.result.Event())
+			}
 		}
 		return m, nil
 
-	case queryResultMsg:
+	case queryStreamMsg:
+		m.stream = msg.stream
+		m.streamResult = &sdk.QueryResponse{}
+		m.streamMsgIdx = -1
+		return m, waitForChunk(msg.scope, msg.stream)
+
+	case queryChunkMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		if msg.chunk.Type == sdk.ChunkError {
+			m.err = fmt.Errorf("%s", msg.chunk.Error)
+			return m, waitForChunk(msg.scope, msg.stream)
+		}
+		m.streamResult.Apply(msg.chunk)
+		content := formatQueryResponse(m.streamResult)
+		if m.streamMsgIdx < 0 {
+			if content == "" && len(m.streamResult.ModifiedArtifacts) == 0 {
+				return m, waitForChunk(msg.scope, msg.stream)
+			}
+			m.messages = append(m.messages, ChatMessage{Role: "assistant"})
+			m.streamMsgIdx = len(m.messages) - 1
+		}
+		m.messages[m.streamMsgIdx].Content = content
+		m.messages[m.streamMsgIdx].Artifacts = m.streamResult.ModifiedArtifacts
+		return m, waitForChunk(msg.scope, msg.stream)
+
+	case queryStreamDoneMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		m.stream = nil
 		m.loading = false
-		m.err = nil
-		if content := extractQueryResult(msg.result); content != "" {
-			m.messages = append(m.messages, ChatMessage{
-				Role:    "assistant",
-				Content: content,
-			})
+		m.pendingText = ""
+		if msg.err != nil {
+			m.err = msg.err
+			m.conversation.DropPending()
+			return m, nil
+		}
+		m.conversation.Record(m.streamResult)
+		return m, nil
+
+	case feedbackSentMsg:
+		return m.handleFeedbackSent(msg), nil
+
+	case composerLoadedMsg:
+		return m.handleComposerLoaded(msg), nil
+
+	case editorDoneMsg:
+		return m.handleEditorDone(msg), nil
+
+	case promptsLoadedMsg:
+		// Loaded for /prompt.
+		m.prompts = msg.prompts
+		if m.prompts == nil {
+			m.prompts = []sdk.SamplePrompt{}
+		}
+		return m.insertPrompt(m.promptQuery), nil
+
+	case exportDoneMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("export failed: %w", msg.err)
+			return m, nil
+		}
+		m.notice = "Exported to " + msg.path
+		return m, nil
+
+	case tea.MouseMsg:
+		if m.tableFocus == nil {
+			m, _ = m.updateChatScroll(msg)
 		}
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.exporting {
+			return m.updateExport(msg)
+		}
+		if m.tableFocus == nil {
+			if scrolled, ok := m.updateChatScroll(msg); ok {
+				return scrolled, nil
+			}
+		}
+		if m.selecting {
+			return m.updateSelection(msg)
+		}
+		if m.tableFocus != nil {
+			// ctrl+e is the textarea's end of line, so export only takes
+			// it while a table has the keys.
+			if msg.String() == "ctrl+e" {
+				return m.openExport(), nil
+			}
+			return m.updateTableFocus(msg)
+		}
+		if msg.String() == "ctrl+t" {
+			return m.focusTable(len(m.tableArtifacts()) - 1), nil
+		}
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			m.notice = "Cancelled"
+			return m, m.chatInput.Focus()
+		}
 		if m.loading {
 			return m, nil
 		}
-		switch msg.String() {
-		case "ctrl+s":
+		if _, ok := m.slashLine(); ok {
+			if next, cmd, ok := m.updateSlash(msg); ok {
+				return next, cmd
+			}
+		}
+		if m.isSendKey(msg.String()) {
 			return m.sendMessage()
 		}
+		if msg.String() == "ctrl+f" {
+			return m.openSelection(), nil
+		}
+		return m.updateComposer(msg)
 	}
 
 	var cmd tea.Cmd
@@ -718,33 +1227,113 @@ func (m Model) updateChat(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// tableArtifacts returns every table artifact in the chat, oldest first.
+func (m Model) tableArtifacts() []sdk.Artifact {
+	var tables []sdk.Artifact
+	for _, msg := range m.messages {
+		for _, a := range msg.Artifacts {
+			if a.ArtifactType == sdk.ArtifactTypeTable {
+				tables = append(tables, a)
+			}
+		}
+	}
+	return tables
+}
+
+// focusTable enters table focus mode on the table artifact at idx.
+func (m Model) focusTable(idx int) Model {
+	tables := m.tableArtifacts()
+	if idx < 0 || idx >= len(tables) {
+		return m
+	}
+	t, err := newArtifactTable(tables[idx])
+	if err != nil {
+		m.err = err
+		return m
+	}
+	m.tableFocus = t
+	m.tableIdx = idx
+	m.chatInput.Blur()
+	m.tableFocus.layout(m.chatWidth(), m.tableHeight())
+	return m
+}
+
+func (m Model) updateTableFocus(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "[":
+		return m.focusTable(m.tableIdx - 1), nil
+	case "]":
+		return m.focusTable(m.tableIdx + 1), nil
+	case "q":
+		m.tableFocus = nil
+		m.chatInput.Focus()
+		return m, nil
+	}
+	m.tableFocus.update(msg.String(), m.tablePageSize())
+	m.tableFocus.layout(m.chatWidth(), m.tableHeight())
+	return m, nil
+}
+
+// tablePageSize is the number of table rows visible in focus mode.
+func (m Model) tablePageSize() int {
+	return max(m.height-8, 5)
+}
+
+// tableHeight is the number of lines the focused table is rendered in.
+func (m Model) tableHeight() int {
+	return m.tablePageSize() + 3
+}
+
 func (m Model) sendMessage() (tea.Model, tea.Cmd) {
 	text := strings.TrimSpace(m.chatInput.Value())
 	if text == "" {
 		return m, nil
 	}
+	if strings.HasPrefix(text, "//") {
+		// Escaped so it is not taken for a slash command.
+		text = text[1:]
+	}
+	if m.offline {
+		m.err = errOffline
+		return m, nil
+	}
+	if m.refreshing {
+		m.notice = "Still loading the thread..."
+		return m, nil
+	}
 
+	m.pendingText, m.pendingAt = text, len(m.messages)
 	m.messages = append(m.messages, ChatMessage{
 		Role:    "user",
 		Content: text,
 	})
-	m.chatInput.Reset()
+	m = m.setDraft("")
 	m.loading = true
+	m.jumping = false
 	m.err = nil
+	m.notice = ""
+	m, save := m.recordSent(text)
 
 	// If we have a PAT and project selected, use threads API
 	if m.client != nil && m.selectedProject != nil {
 		if m.threadID == "" {
 			// Start new thread
-			return m, tea.Batch(m.spinner.Tick, m.startThread(text))
+			return m, tea.Batch(m.spinner.Tick, m.startThread(text), save)
 		}
 		// Send to existing thread
-		return m, tea.Batch(m.spinner.Tick, m.sendThreadMessage(text))
+		return m, tea.Batch(m.spinner.Tick, m.sendThreadMessage(text), save)
 	}
 
-	// If we only have an API key, use the query endpoint
+	// If we only have an API key, use the query endpoint, sending the
+	// conversation so far with every question
 	if m.cfg.APIKey != "" && m.cfg.DDNURL != "" {
-		return m, tea.Batch(m.spinner.Tick, m.executeQuery(text))
+		m.conversation.DropPending()
+		m.conversation.AddUserMessage(text)
+		opts := m.conversation.Apply(sdk.ExecuteOptions{
+			DDNURL:   m.cfg.DDNURL,
+			Timezone: m.cfg.Timezone,
+		})
+		return m, tea.Batch(m.spinner.Tick, m.streamQuery(opts), save)
 	}
 
 	m.err = fmt.Errorf("no project selected or API key + DDN URL configured")
@@ -755,14 +1344,115 @@ func (m Model) sendMessage() (tea.Model, tea.Cmd) {
 func (m Model) handleEsc() (tea.Model, tea.Cmd) {
 	switch m.view {
 	case viewChat:
+		if m.exporting {
+			return m.closeExport(), nil
+		}
+		if m.tableFocus != nil {
+			m.tableFocus = nil
+			m.chatInput.Focus()
+			return m, nil
+		}
+		if m.feedbackValue != 0 {
+			return m.cancelFeedback(), nil
+		}
+		if m.selecting {
+			return m.closeSelection(), nil
+		}
+		if _, ok := m.slashLine(); ok {
+			return m.setDraft(""), nil
+		}
+		m = m.enterScope()
+		m, save := m.stashDraft()
 		m.view = viewThreads
 		m.chatInput.Blur()
 		m.err = nil
-		return m, nil
+		return m, save
 	case viewThreads:
+		if m.threadSearching {
+			m.threadSearching = false
+			m.threadSearch.Blur()
+			m.threadSearch.SetValue("")
+			m.threadFilter.query = ""
+			return m.resetThreadCursor(), nil
+		}
+		if m.threadFilter.filtered() {
+			return m.clearThreadFilter(), nil
+		}
+		m = m.enterScope()
 		m.view = viewProjects
 		m.err = nil
 		return m, nil
+	case viewPrompts:
+		if m.loading {
+			return m, nil
+		}
+		if m.promptMode != promptList {
+			m.promptMode = promptList
+			m.err = nil
+			return m, nil
+		}
+		m.view = viewThreads
+		m.err = nil
+		return m, nil
+	case viewKeys:
+		if m.loading {
+			return m, nil
+		}
+		switch m.keyMode {
+		case keyGenerate, keyConfirmRevoke:
+			m.keyMode = keyList
+			m.err = nil
+			return m, nil
+		}
+		m.view = viewThreads
+		m.err = nil
+		return m, nil
+	case viewAdmin:
+		if m.loading {
+			return m, nil
+		}
+		switch m.adminMode {
+		case adminEdit, adminConfirmToggle, adminInstructions, adminHistory:
+			m.adminMode = adminOverview
+			m.adminScroll = 0
+			m.err = nil
+			return m, nil
+		case adminPreview:
+			return m.closePreview()
+		case adminConfirmRollback:
+			m.adminMode = adminHistory
+			return m, nil
+		}
+		m.view = viewThreads
+		m.notice = ""
+		m.err = nil
+		return m, nil
+	case viewSetup:
+		if len(m.cfg.ProfileNames()) > 1 {
+			m.setupInputs[m.setupCursor].Blur()
+			return m.openProfiles()
+		}
+		return m, nil
+	case viewSearch:
+		m.view = viewThreads
+		m.searchInput.Blur()
+		m.err = nil
+		return m, nil
+	case viewProfiles:
+		if m.profileCreating {
+			m.profileCreating = false
+			m.profileInput.Blur()
+			m.err = nil
+			return m, nil
+		}
+		m.err = nil
+		if m.client == nil {
+			m.view = viewSetup
+			m.setupInputs[m.setupCursor].Focus()
+			return m, nil
+		}
+		m.view = viewProjects
+		return m, nil
 	case viewProjects:
 		m.view = viewSetup
 		m.setupInputs[0].Focus()
@@ -774,193 +1464,209 @@ func (m Model) handleEsc() (tea.Model, tea.Cmd) {
 
 // --- Commands ---
 
+// saveConfig saves the config, reporting the outcome with done. Saving
+// credentials to a vault that is still locked, as on a fresh install, asks
+// for its passphrase on the terminal, so the TUI is suspended meanwhile.
+func (m Model) saveConfig(done func(err error) tea.Msg) tea.Cmd {
+	if v, ok := m.cfg.SecretStore().(*config.Vault); ok && v.Locked() {
+		return tea.Exec(configSave{m.cfg}, func(err error) tea.Msg { return done(err) })
+	}
+	return func() tea.Msg { return done(m.cfg.Save()) }
+}
+
+// configSave saves the config from tea.Exec, with the terminal released.
+type configSave struct{ cfg *config.Config }
+
+func (s configSave) Run() error        { return s.cfg.Save() }
+func (configSave) SetStdin(io.Reader)  {}
+func (configSave) SetStdout(io.Writer) {}
+func (configSave) SetStderr(io.Writer) {}
+
+// loadProjects shows the cached projects, if any, while fetching them.
 func (m Model) loadProjects() tea.Cmd {
-	return func() tea.Msg {
-		projects, err := m.client.Projects().ListUserProjects()
+	return tea.Batch(m.cachedProjects(), m.cancelable(func(ctx context.Context) tea.Msg {
+		projects, err := m.client.Projects().ListUserProjectsContext(ctx)
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return projectsLoadedMsg{projects}
-	}
+		return projectsLoadedMsg{scope: m.scope(), projects: projects}
+	}))
 }
 
 func (m Model) lookupProject(projectID string) tea.Cmd {
-	return func() tea.Msg {
-		result, err := m.client.Projects().Lookup(sdk.LookupOptions{
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		result, err := m.client.Projects().LookupContext(ctx, sdk.LookupOptions{
 			ProjectID: projectID,
 		})
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return lookupResultMsg{result}
-	}
+		return lookupResultMsg{scope: m.scope(), result: result}
+	})
 }
 
 func (m Model) lookupProjectByName(projectName string, fqdn string) tea.Cmd {
-	return func() tea.Msg {
-		result, err := m.client.Projects().Lookup(sdk.LookupOptions{
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		opts := sdk.LookupOptions{
 			ProjectName: projectName,
 			FQDN:        fqdn,
-		})
+		}
+		result, err := m.client.Projects().LookupContext(ctx, opts)
+		if sdk.IsNetworkError(err) {
+			// Offline: carry on with the last lookup so cached threads can be browsed.
+			if cached, cacheErr := m.client.Projects().LookupContext(sdk.CacheOnly(context.Background(), nil), opts); cacheErr == nil {
+				result, err = cached, nil
+			}
+		}
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return lookupResultMsg{result}
-	}
+		return lookupResultMsg{scope: m.scope(), result: result}
+	})
 }
 
+// loadThreads shows the cached threads, if any, while fetching them.
 func (m Model) loadThreads() tea.Cmd {
-	return func() tea.Msg {
-		if m.selectedProject == nil {
-			return errMsg{fmt.Errorf("no project selected")}
-		}
-		threads, err := m.client.Threads().List(m.selectedProject.ProjectID, "")
+	if m.selectedProject == nil {
+		return func() tea.Msg { return errMsg{m.scope(), fmt.Errorf("no project selected")} }
+	}
+	projectID := m.selectedProject.ProjectID
+	return tea.Batch(m.cachedThreads(projectID), m.cancelable(func(ctx context.Context) tea.Msg {
+		threads, err := m.client.Threads().ListContext(ctx, projectID, "")
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return threadsLoadedMsg{threads}
-	}
+		return threadsLoadedMsg{scope: m.scope(), threads: threads}
+	}))
 }
 
+// loadEvents shows the cached events, if any, while fetching them.
 func (m Model) loadEvents(threadID string) tea.Cmd {
-	return func() tea.Msg {
-		events, err := m.client.Threads().GetEvents(threadID)
+	return tea.Batch(m.cachedEvents(threadID), m.cancelable(func(ctx context.Context) tea.Msg {
+		events, err := m.client.Threads().GetEventsContext(ctx, threadID)
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return eventsLoadedMsg{events}
-	}
+		return eventsLoadedMsg{scope: m.scope(), events: events}
+	}))
 }
 
 func (m Model) startThread(message string) tea.Cmd {
-	return func() tea.Msg {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
 		if m.selectedProject == nil {
-			return errMsg{fmt.Errorf("no project selected")}
+			return errMsg{m.scope(), fmt.Errorf("no project selected")}
 		}
-		result, err := m.client.Threads().Start(sdk.StartOptions{
-			ProjectID: m.selectedProject.ProjectID,
-			Message:   message,
-			BuildFQDN: m.buildFQDN,
-			Timezone:  m.cfg.Timezone,
+		result, err := m.client.Threads().StartContext(ctx, sdk.StartOptions{
+			ProjectID:  m.selectedProject.ProjectID,
+			Message:    message,
+			BuildFQDN:  m.buildFQDN,
+			Timezone:   m.cfg.Timezone,
+			Visibility: m.newThreadVisibility,
 		})
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return threadStartedMsg{result}
-	}
+		return threadStartedMsg{scope: m.scope(), result: result}
+	})
 }
 
 func (m Model) sendThreadMessage(message string) tea.Cmd {
-	return func() tea.Msg {
-		result, err := m.client.Threads().SendMessage(sdk.SendMessageOptions{
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		result, err := m.client.Threads().SendMessageContext(ctx, sdk.SendMessageOptions{
 			ThreadID:  m.threadID,
 			Message:   message,
 			BuildFQDN: m.buildFQDN,
 			Timezone:  m.cfg.Timezone,
 		})
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return messageSentMsg{result}
-	}
+		return messageSentMsg{scope: m.scope(), result: result}
+	})
 }
 
-func (m Model) executeQuery(question string) tea.Cmd {
-	return func() tea.Msg {
-		result, err := m.client.Query().Ask(
-			question,
-			m.cfg.DDNURL,
-			nil,
-			m.cfg.Timezone,
-		)
+func (m Model) streamQuery(opts sdk.ExecuteOptions) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		stream, err := m.client.Query().ExecuteStreamContext(ctx, opts)
 		if err != nil {
-			return errMsg{err}
+			return errMsg{m.scope(), err}
 		}
-		return queryResultMsg{result}
-	}
+		return queryStreamMsg{scope: m.scope(), stream: stream}
+	})
 }
 
-// --- Helpers ---
-
-func extractEventContent(evt sdk.ThreadEvent) string {
-	if evt.EventData == nil {
-		return ""
-	}
-	// Try common event data shapes
-	if text, ok := evt.EventData["assistant_message"].(map[string]interface{}); ok {
-		if content, ok := text["text"].(string); ok {
-			return content
-		}
-	}
-	if text, ok := evt.EventData["user_message"].(map[string]interface{}); ok {
-		if content, ok := text["text"].(string); ok {
-			return content
+// waitForChunk blocks until the next chunk arrives on the stream.
+func waitForChunk(s scope, stream *sdk.QueryStream) tea.Cmd {
+	return func() tea.Msg {
+		if stream.Next() {
+			return queryChunkMsg{scope: s, stream: stream, chunk: stream.Chunk()}
 		}
+		err := stream.Err()
+		stream.Close()
+		return queryStreamDoneMsg{scope: s, stream: stream, err: err}
 	}
-	if text, ok := evt.EventData["message"].(string); ok {
-		return text
-	}
-	if text, ok := evt.EventData["text"].(string); ok {
-		return text
-	}
-	return ""
 }
 
-func isUserEvent(evt sdk.ThreadEvent) bool {
-	if evt.EventData == nil {
-		return false
-	}
-	if _, ok := evt.EventData["user_message"]; ok {
-		return true
-	}
-	if role, ok := evt.EventData["role"].(string); ok {
-		return role == "user"
-	}
-	return false
-}
+// --- Helpers ---
 
-func extractSendMessageContent(result *sdk.SendMessageResult) string {
-	if result == nil || result.EventData == nil {
-		return ""
-	}
-	if text, ok := result.EventData["assistant_message"].(map[string]interface{}); ok {
-		if content, ok := text["text"].(string); ok {
-			return content
+// appendEvent adds the chat message for a thread event, if it has one, and
+// applies title updates to the active thread.
+func (m *Model) appendEvent(eventID int, evt sdk.Event) {
+	var msg ChatMessage
+	switch e := evt.(type) {
+	case *sdk.UserMessageEvent:
+		msg = ChatMessage{Role: "user", Content: e.Text, MessageID: e.MessageID}
+	case *sdk.AssistantMessageEvent:
+		msg = ChatMessage{Role: "assistant", Content: e.Text, MessageID: e.MessageID}
+	case *sdk.PlanStepEvent:
+		msg = ChatMessage{Role: "assistant", Content: e.Plan}
+	case *sdk.CodeExecutionEvent:
+		msg = ChatMessage{Role: "assistant", Content: formatQueryResponse(&sdk.QueryResponse{
+			AssistantActions: []sdk.AssistantAction{{Code: e.Code, CodeOutput: e.Output, CodeError: e.Error}},
+		})}
+	case *sdk.ErrorEvent:
+		msg = ChatMessage{Role: "assistant", Content: "Error: " + e.Message}
+	case *sdk.ArtifactCreatedEvent:
+		// Attach artifacts to the assistant message they belong to.
+		if n := len(m.messages); n > 0 && m.messages[n-1].Role == "assistant" {
+			m.messages[n-1].Artifacts = append(m.messages[n-1].Artifacts, e.Artifact)
+			return
 		}
+		msg = ChatMessage{Role: "assistant", Artifacts: []sdk.Artifact{e.Artifact}}
+	case *sdk.TitleUpdateEvent:
+		if m.activeThread != nil && e.Title != "" {
+			m.activeThread.Title = e.Title
+		}
+		return
+	default:
+		return
 	}
-	if text, ok := result.EventData["message"].(string); ok {
-		return text
-	}
-	if text, ok := result.EventData["text"].(string); ok {
-		return text
+	if msg.Content != "" || len(msg.Artifacts) > 0 {
+		msg.EventID = eventID
+		m.messages = append(m.messages, msg)
 	}
-	return ""
 }
 
-func extractQueryResult(result map[string]interface{}) string {
-	if result == nil {
+// formatQueryResponse renders assistant actions as chat text, with code and
+// its output in fenced blocks.
+func formatQueryResponse(resp *sdk.QueryResponse) string {
+	if resp == nil {
 		return ""
 	}
-	// Try to find the assistant response in common shapes
-	if interactions, ok := result["interactions"].([]interface{}); ok {
-		for i := len(interactions) - 1; i >= 0; i-- {
-			if inter, ok := interactions[i].(map[string]interface{}); ok {
-				if role, ok := inter["role"].(string); ok && role == "assistant" {
-					if msg, ok := inter["assistant_message"].(map[string]interface{}); ok {
-						if text, ok := msg["text"].(string); ok {
-							return text
-						}
-					}
-				}
-			}
+	var parts []string
+	for _, a := range resp.AssistantActions {
+		if a.Message != "" {
+			parts = append(parts, a.Message)
+		}
+		if a.Code != "" {
+			parts = append(parts, "```\n"+strings.TrimRight(a.Code, "\n")+"\n```")
+		}
+		if a.CodeOutput != "" {
+			parts = append(parts, "Output:\n"+strings.TrimRight(a.CodeOutput, "\n"))
+		}
+		if a.CodeError != "" {
+			parts = append(parts, "Error:\n"+strings.TrimRight(a.CodeError, "\n"))
 		}
 	}
-	if msg, ok := result["message"].(string); ok {
-		return msg
-	}
-	if text, ok := result["text"].(string); ok {
-		return text
-	}
-	// Last resort: stringify the whole response
-	return fmt.Sprintf("%v", result)
+	return strings.Join(parts, "\n\n")
 }
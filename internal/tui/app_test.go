@@ -1,13 +1,27 @@
 package tui
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/sandalsoft/promptql-tui/internal/config"
 	"github.com/sandalsoft/promptql-tui/internal/sdk"
+	"github.com/sandalsoft/promptql-tui/internal/search"
 )
 
 // ---------------------------------------------------------------------------
@@ -299,3 +313,2011 @@ func TestNewThreadSelection(t *testing.T) {
 		t.Error("expected nil activeThread for new thread")
 	}
 }
+
+func threadAt(id, title, visibility string, updated time.Time) sdk.Thread {
+	return sdk.Thread{ThreadID: id, Title: title, Visibility: visibility, UpdatedAt: updated.UTC().Format(time.RFC3339), CreatedAt: updated.Add(-time.Hour).UTC().Format(time.RFC3339)}
+}
+
+func newThreadListTestModel() Model {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.loading = false
+	m.height = 40
+	now := time.Now()
+	m.threads = []sdk.Thread{
+		threadAt("t-old", "Quarterly revenue", "shared", now.AddDate(0, 0, -40)),
+		threadAt("t-today", "Orders by region", "private", now),
+		threadAt("t-week", "Churned customers", "private", now.AddDate(0, 0, -3)),
+	}
+	return m
+}
+
+func TestThreads_SortedAndGroupedByDate(t *testing.T) {
+	m := newThreadListTestModel()
+
+	var ids []string
+	for _, th := range m.visibleThreads() {
+		ids = append(ids, th.ThreadID)
+	}
+	if strings.Join(ids, ",") != "t-today,t-week,t-old" {
+		t.Errorf("expected newest first, got %v", ids)
+	}
+	out := ansi.Strip(m.View())
+	today, week, older := strings.Index(out, "Today"), strings.Index(out, "Last 7 days"), strings.Index(out, "Older")
+	if today < 0 || week < today || older < week {
+		t.Errorf("expected Today, Last 7 days and Older groups in order, got:\n%s", out)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model := updated.(Model)
+	if model.threadFilter.sort != sortTitle || model.visibleThreads()[0].ThreadID != "t-week" {
+		t.Errorf("expected title order starting with Churned customers, got %v", model.visibleThreads())
+	}
+	if out := ansi.Strip(model.View()); strings.Contains(out, "Today") {
+		t.Error("expected no date groups when sorted by title")
+	}
+}
+
+func TestThreads_IncrementalSearch(t *testing.T) {
+	m := newThreadListTestModel()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	for _, r := range "qrev" {
+		updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model := updated.(Model)
+	visible := model.visibleThreads()
+	if len(visible) != 1 || visible[0].ThreadID != "t-old" {
+		t.Fatalf("expected fuzzy match on Quarterly revenue, got %v", visible)
+	}
+	if model.threadCursor != 1 {
+		t.Errorf("expected cursor on the first match, got %d", model.threadCursor)
+	}
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "1 of 3 threads") {
+		t.Errorf("expected match count in the subtitle, got:\n%s", out)
+	}
+
+	// enter leaves the search box; enter again opens the match
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.view != viewChat || model.threadID != "t-old" {
+		t.Errorf("expected to resume t-old, got view %d thread %q", model.view, model.threadID)
+	}
+}
+
+func TestThreads_FiltersAndEscClears(t *testing.T) {
+	m := newThreadListTestModel()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}}) // today
+	model := updated.(Model)
+	if visible := model.visibleThreads(); len(visible) != 1 || visible[0].ThreadID != "t-today" {
+		t.Errorf("expected only today's thread, got %v", visible)
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}}) // last 7 days
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	model = updated.(Model)
+	if model.threadFilter.visibility != "private" || len(model.visibleThreads()) != 2 {
+		t.Errorf("expected 2 private threads from the last week, got %v", model.visibleThreads())
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.view != viewThreads || model.threadFilter.filtered() || len(model.visibleThreads()) != 3 {
+		t.Errorf("expected esc to clear the filters first, got view %d filter %+v", model.view, model.threadFilter)
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).view != viewProjects {
+		t.Error("expected a second esc to go back to projects")
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, s string
+		want     bool
+	}{
+		{"ordreg", "Orders by region", true},
+		{"ORD reg", "orders by region", true},
+		{"", "anything", true},
+		{"regord", "Orders by region", false},
+		{"x", "Orders", false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.query, tt.s); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.s, got, tt.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Full-Text Search
+// ---------------------------------------------------------------------------
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSearch_IndexesThreadsInBatches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+	fetched := 0
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			fetched++
+			body := `{"data":{"getThreadEvents":[` +
+				`{"thread_event_id":1,"event_data":{"user_message":{"text":"What was revenue?"}}},` +
+				`{"thread_event_id":2,"event_data":{"assistant_message":{"text":"Revenue was $1.2M."}}}]}}`
+			return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})},
+	})
+
+	var threads []sdk.Thread
+	for i := range indexBatchSize + 2 {
+		threads = append(threads, sdk.Thread{ThreadID: fmt.Sprintf("t-%d", i), UpdatedAt: "2026-01-01T00:00:00Z"})
+	}
+	updated, cmd := m.Update(threadsLoadedMsg{threads: threads})
+	for cmd != nil {
+		msg := cmd()
+		if msg == nil {
+			break
+		}
+		updated, cmd = updated.(Model).Update(msg)
+	}
+	model := updated.(Model)
+
+	if fetched != len(threads) || model.searchIndex == nil || model.searchIndex.Len() != len(threads) || model.searchPending != 0 {
+		t.Fatalf("expected all %d threads indexed, fetched %d, pending %d", len(threads), fetched, model.searchPending)
+	}
+	if results := model.searchIndex.Search("revenue", 0); len(results) != 2*len(threads) {
+		t.Errorf("expected both messages of every thread to match, got %d", len(results))
+	}
+
+	// Reloading unchanged threads fetches nothing.
+	fetched = 0
+	_, cmd = model.Update(threadsLoadedMsg{threads: threads})
+	if msg := cmd().(searchIndexedMsg); fetched != 0 || len(msg.pending) != 0 {
+		t.Errorf("expected no refetch of unchanged threads, fetched %d", fetched)
+	}
+}
+
+func TestSearch_StoppingIndexingAbortsFetchAndKeepsProgress(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+	fetched := 0
+	blocked := make(chan struct{})
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			fetched++
+			if fetched > 1 {
+				close(blocked)
+				<-req.Context().Done()
+				return nil, req.Context().Err()
+			}
+			body := `{"data":{"getThreadEvents":[{"thread_event_id":1,"event_data":{"user_message":{"text":"What was revenue?"}}}]}}`
+			return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})},
+	})
+
+	var threads []sdk.Thread
+	for i := range indexBatchSize {
+		threads = append(threads, sdk.Thread{ThreadID: fmt.Sprintf("t-%d", i), UpdatedAt: "2026-01-01T00:00:00Z"})
+	}
+	updated, cmd := m.Update(threadsLoadedMsg{threads: threads})
+	done := make(chan tea.Msg)
+	go func() { done <- cmd() }()
+	<-blocked
+	updated.(Model).stopIndexing()
+	<-done
+
+	if fetched != 2 {
+		t.Errorf("expected the run to stop at the blocked fetch, fetched %d", fetched)
+	}
+	path, _ := config.SearchIndexPath("p-1")
+	ix, err := search.Open(path)
+	if err != nil || ix.Len() != 1 {
+		t.Errorf("expected the thread indexed before stopping to be saved, got %v (%v)", ix, err)
+	}
+}
+
+func TestSearch_OpensChatAtMatch(t *testing.T) {
+	ix, err := search.Open(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatalf("search.Open() error: %v", err)
+	}
+	var events []sdk.ThreadEvent
+	var messages []search.Message
+	for i := range 30 {
+		text := fmt.Sprintf("filler answer %d", i)
+		if i == 3 {
+			text = "the needle is in the warehouse table"
+		}
+		events = append(events, sdk.ThreadEvent{ThreadEventID: i, EventData: []byte(fmt.Sprintf(`{"assistant_message":{"text":%q}}`, text))})
+		messages = append(messages, search.Message{Role: "assistant", Text: text})
+	}
+	ix.Update(search.Thread{ID: "t-1", Title: "Inventory", Messages: messages})
+
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.loading = false
+	m.height = 24
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+	m.searchIndex, m.searchProject = ix, "p-1"
+	m.threads = []sdk.Thread{{ThreadID: "t-1", Title: "Inventory"}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	for _, r := range "needle" {
+		updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model := updated.(Model)
+	if model.view != viewSearch || len(model.searchResults) != 1 {
+		t.Fatalf("expected one result in the search view, got view %d results %+v", model.view, model.searchResults)
+	}
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "Inventory") || !strings.Contains(out, "needle is in the warehouse") {
+		t.Errorf("expected the thread title and snippet, got:\n%s", out)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(Model).Update(eventsLoadedMsg{scope: updated.(Model).scope(), events: events})
+	model = updated.(Model)
+	if model.view != viewChat || model.threadID != "t-1" || !model.jumping || model.jumpMessage != 3 {
+		t.Fatalf("expected the chat to open at message 3, got view %d thread %q jump %v/%d", model.view, model.threadID, model.jumping, model.jumpMessage)
+	}
+	out := ansi.Strip(model.View())
+	if !strings.Contains(out, "needle") || strings.Contains(out, "filler answer 29") {
+		t.Errorf("expected the chat scrolled to the match, got:\n%s", out)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Offline Cache
+// ---------------------------------------------------------------------------
+
+func TestOffline_CachedThenFresh(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.loading = true
+
+	cachedAt := time.Now().Add(-5 * time.Minute)
+	updated, _ := m.Update(threadsLoadedMsg{threads: []sdk.Thread{{ThreadID: "t-old", Title: "Old"}}, cachedAt: cachedAt})
+	model := updated.(Model)
+	if model.loading || !model.refreshing || len(model.threads) != 1 {
+		t.Fatal("expected the cached threads to show while refreshing")
+	}
+	if !strings.Contains(model.View(), "refreshing") {
+		t.Error("expected the view to show that the list is refreshing")
+	}
+
+	updated, _ = model.Update(threadsLoadedMsg{threads: []sdk.Thread{{ThreadID: "t-1"}, {ThreadID: "t-2"}}})
+	model = updated.(Model)
+	if model.refreshing || model.offline || !model.cachedAt.IsZero() || len(model.threads) != 2 {
+		t.Fatal("expected the fresh threads to replace the cached ones")
+	}
+
+	// A cached copy arriving late must not overwrite the fresh list.
+	updated, _ = model.Update(threadsLoadedMsg{threads: []sdk.Thread{{ThreadID: "t-old"}}, cachedAt: cachedAt})
+	if model = updated.(Model); len(model.threads) != 2 {
+		t.Error("expected a late cached result to be ignored")
+	}
+}
+
+func TestOffline_NetworkErrorKeepsCachedData(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewChat
+	m.activeThread = &sdk.Thread{ThreadID: "t-1"}
+	m.loading = true
+
+	cachedAt := time.Now().Add(-2 * time.Hour)
+	updated, _ := m.Update(eventsLoadedMsg{events: []sdk.ThreadEvent{{ThreadEventID: 1, EventData: []byte(`{"user_message":{"text":"hello"}}`)}}, cachedAt: cachedAt})
+	model := updated.(Model)
+
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	updated, _ = model.Update(errMsg{err: netErr})
+	model = updated.(Model)
+	if !model.offline || model.err != nil || len(model.messages) != 1 {
+		t.Fatalf("expected offline browsing of the cached thread, got offline=%v err=%v", model.offline, model.err)
+	}
+	if !strings.Contains(model.View(), "cached 2h ago") {
+		t.Error("expected the view to show the age of the cached data")
+	}
+
+	model.chatInput.SetValue("are you there?")
+	updated, cmd := model.sendMessage()
+	model = updated.(Model)
+	if cmd != nil || !errors.Is(model.err, errOffline) || len(model.messages) != 1 {
+		t.Error("expected sending to be blocked while offline")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Request Cancellation
+// ---------------------------------------------------------------------------
+
+// requestMsgs runs the commands of a batch, skipping spinner ticks, and
+// returns their messages.
+func requestMsgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, c := range msg {
+			if c == nil {
+				continue
+			}
+			if _, tick := c().(spinner.TickMsg); !tick {
+				msgs = append(msgs, requestMsgs(c)...)
+			}
+		}
+		return msgs
+	case nil:
+		return nil
+	default:
+		return []tea.Msg{msg}
+	}
+}
+
+func TestCancel_SendRestoresMessage(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:     "test-pat",
+		BaseURL: "https://test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})},
+	})
+	m.view = viewChat
+	m.loading = false
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+	m.threadID = "t-1"
+	m.messages = []ChatMessage{{Role: "assistant", Content: "hello"}}
+	m.chatInput.SetValue("a slow question")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model := updated.(Model)
+	if !model.loading || len(model.messages) != 2 {
+		t.Fatal("expected the message to be sent")
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "ctrl+x: cancel") {
+		t.Error("expected the cancel key to be shown while waiting")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(Model)
+	if model.loading || len(model.messages) != 1 || model.chatInput.Value() != "a slow question" {
+		t.Fatalf("expected the message back in the input, got loading=%v messages=%d input=%q", model.loading, len(model.messages), model.chatInput.Value())
+	}
+	if msgs := requestMsgs(cmd); len(msgs) != 0 {
+		t.Errorf("expected the cancelled request to send nothing, got %v", msgs)
+	}
+}
+
+func TestCancel_ThreadsLoad(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			body := `{"data":{"getThreads":[{"thread_id":"t-late"}]}}`
+			return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})},
+	})
+	m.view = viewThreads
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+	m.loading = true
+	cmd := m.loadThreads()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model := updated.(Model)
+	if model.loading || !errors.Is(model.err, errCancelled) {
+		t.Fatalf("expected the load to be cancelled, got loading=%v err=%v", model.loading, model.err)
+	}
+	for _, msg := range requestMsgs(cmd) {
+		if _, ok := msg.(threadsLoadedMsg); ok {
+			t.Error("expected the late threads to be dropped")
+		}
+	}
+
+	// Requests made after the cancel are not affected.
+	if msgs := requestMsgs(model.loadThreads()); len(msgs) != 1 {
+		t.Errorf("expected a new load to succeed, got %v", msgs)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Stale Results
+// ---------------------------------------------------------------------------
+
+func TestScope_DropsResultsOfPreviousThread(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewThreads
+	m.loading = false
+	m.selectedProject = &sdk.UserProject{Name: "sales", ProjectID: "p-1"}
+
+	updated, _ := m.resumeThread(sdk.Thread{ThreadID: "t-1"})
+	first := updated.(Model).scope()
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, _ = updated.(Model).resumeThread(sdk.Thread{ThreadID: "t-2"})
+	model := updated.(Model)
+
+	stale := []sdk.ThreadEvent{{ThreadEventID: 1, EventData: []byte(`{"user_message":{"text":"from t-1"}}`)}}
+	updated, _ = model.Update(eventsLoadedMsg{scope: first, events: stale})
+	updated, _ = updated.(Model).Update(errMsg{first, errors.New("t-1 failed")})
+	model = updated.(Model)
+	if len(model.messages) != 0 || model.err != nil || !model.loading {
+		t.Fatalf("expected results for t-1 to be dropped, got %d messages, err %v", len(model.messages), model.err)
+	}
+
+	fresh := []sdk.ThreadEvent{{ThreadEventID: 1, EventData: []byte(`{"user_message":{"text":"from t-2"}}`)}}
+	updated, _ = model.Update(eventsLoadedMsg{scope: model.scope(), events: fresh})
+	model = updated.(Model)
+	if len(model.messages) != 1 || model.messages[0].Content != "from t-2" || model.loading {
+		t.Errorf("expected the events of t-2, got %+v", model.messages)
+	}
+}
+
+func TestScope_DropsThreadsOfPreviousProject(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.loading = false
+	m.projects = []sdk.UserProject{{Name: "sales"}, {Name: "ops"}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	first := updated.(Model).scope()
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model := updated.(Model)
+	if model.selectedProject.Name != "ops" {
+		t.Fatalf("expected ops to be selected, got %q", model.selectedProject.Name)
+	}
+
+	updated, _ = model.Update(lookupResultMsg{scope: first, result: &sdk.LookupProjectResult{ProjectID: "p-sales"}})
+	if model = updated.(Model); model.view != viewProjects || model.cfg.ProjectID == "p-sales" {
+		t.Error("expected the lookup of the previous project to be dropped")
+	}
+	updated, _ = model.Update(lookupResultMsg{scope: model.scope(), result: &sdk.LookupProjectResult{ProjectID: "p-ops"}})
+	if model = updated.(Model); model.view != viewThreads || model.selectedProject.ProjectID != "p-ops" {
+		t.Error("expected the lookup of ops to open its threads")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Streaming Direct Query
+// ---------------------------------------------------------------------------
+
+func TestQueryStream_RendersChunks(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m.loading = true
+	stream := &sdk.QueryStream{}
+
+	updated, _ := m.Update(queryStreamMsg{stream: stream})
+	model := updated.(Model)
+
+	for _, delta := range []string{"Hello", ", world"} {
+		chunk := sdk.QueryChunk{Type: sdk.ChunkAssistantAction, Message: delta}
+		updated, _ = model.Update(queryChunkMsg{stream: stream, chunk: chunk})
+		model = updated.(Model)
+	}
+
+	if len(model.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(model.messages))
+	}
+	if model.messages[0].Content != "Hello, world" {
+		t.Errorf("expected streamed content 'Hello, world', got %q", model.messages[0].Content)
+	}
+	if !model.loading {
+		t.Error("expected loading=true while stream is open")
+	}
+
+	updated, _ = model.Update(queryStreamDoneMsg{stream: stream})
+	model = updated.(Model)
+	if model.loading {
+		t.Error("expected loading=false after stream ends")
+	}
+}
+
+// blockingBody is a response body whose reads block until its request is
+// cancelled. It records whether it was closed.
+type blockingBody struct {
+	ctx    context.Context
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (b *blockingBody) Read([]byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *blockingBody) Close() error {
+	b.once.Do(func() { close(b.closed) })
+	return nil
+}
+
+func newStreamTestModel(rt roundTripFunc) Model {
+	m := New(&config.Config{APIKey: "test-key"})
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		APIKey:     "test-key",
+		BaseURL:    "https://test.example.com",
+		HTTPClient: &http.Client{Transport: rt},
+	})
+	m.view = viewChat
+	m.loading = true
+	return m
+}
+
+func TestQueryStream_CancelEndsReadAndClosesStream(t *testing.T) {
+	var body *blockingBody
+	m := newStreamTestModel(func(req *http.Request) (*http.Response, error) {
+		body = &blockingBody{ctx: req.Context(), closed: make(chan struct{})}
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	})
+
+	opened, ok := m.streamQuery(sdk.ExecuteOptions{})().(queryStreamMsg)
+	if !ok {
+		t.Fatal("expected the stream to open")
+	}
+	updated, cmd := m.Update(opened)
+	model := updated.(Model)
+
+	// The chunk is awaited while the user cancels, as in the running program.
+	done := make(chan tea.Msg)
+	go func() { done <- cmd() }()
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(Model)
+	if model.stream != nil || model.loading {
+		t.Error("expected the stream to be dropped")
+	}
+
+	select {
+	case msg := <-done:
+		if _, ok := msg.(queryStreamDoneMsg); !ok {
+			t.Errorf("expected the read to end, got %T", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected cancelling to end the read")
+	}
+	select {
+	case <-body.closed:
+	default:
+		t.Error("expected the stream to be closed")
+	}
+}
+
+func TestQueryStream_CancelledWhileOpeningClosesStream(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var body *blockingBody
+	m := newStreamTestModel(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		body = &blockingBody{ctx: req.Context(), closed: make(chan struct{})}
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	})
+
+	done := make(chan tea.Msg)
+	cmd := m.streamQuery(sdk.ExecuteOptions{})
+	go func() { done <- cmd() }()
+	<-started
+	m.cancelRequest()
+	close(release)
+
+	if msg := <-done; msg != nil {
+		t.Errorf("expected the cancelled stream to be dropped, got %T", msg)
+	}
+	select {
+	case <-body.closed:
+	default:
+		t.Error("expected the dropped stream to be closed")
+	}
+}
+
+func TestQueryStream_IgnoresStaleStream(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m.stream = &sdk.QueryStream{}
+
+	chunk := sdk.QueryChunk{Type: sdk.ChunkAssistantAction, Message: "late"}
+	updated, _ := m.Update(queryChunkMsg{stream: &sdk.QueryStream{}, chunk: chunk})
+	model := updated.(Model)
+	if len(model.messages) != 0 {
+		t.Errorf("expected chunk from stale stream to be ignored, got %d messages", len(model.messages))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Thread Events
+// ---------------------------------------------------------------------------
+
+func TestEventsLoaded_Roles(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m.loading = true
+	m.activeThread = &sdk.Thread{ThreadID: "t-1"}
+
+	events := []sdk.ThreadEvent{
+		{ThreadEventID: 1, EventData: []byte(`{"user_message":{"text":"how many users?"}}`)},
+		{ThreadEventID: 2, EventData: []byte(`{"assistant_message":{"text":"42"}}`)},
+		{ThreadEventID: 3, EventData: []byte(`{"title":"User count"}`)},
+		{ThreadEventID: 4, EventData: []byte(`{"unrelated":true}`)},
+	}
+	updated, _ := m.Update(eventsLoadedMsg{events: events})
+	model := updated.(Model)
+
+	if len(model.messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(model.messages))
+	}
+	if model.messages[0].Role != "user" || model.messages[1].Role != "assistant" {
+		t.Errorf("unexpected roles: %q, %q", model.messages[0].Role, model.messages[1].Role)
+	}
+	if model.activeThread.Title != "User count" {
+		t.Errorf("expected title update to apply, got %q", model.activeThread.Title)
+	}
+}
+
+func TestMessageSent_SkipsEchoedUserMessage(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewChat
+	m.loading = true
+	m.threadID = "t-1"
+	m.messages = []ChatMessage{{Role: "user", Content: "how many users?"}}
+
+	for _, data := range []string{
+		`{"user_message":{"text":"how many users?","message_id":"m-1"}}`,
+		`{"assistant_message":{"text":"42","message_id":"m-2"}}`,
+	} {
+		updated, _ := m.Update(messageSentMsg{result: &sdk.SendMessageResult{EventData: []byte(data)}})
+		m = updated.(Model)
+	}
+	if len(m.messages) != 2 || m.messages[1].Role != "assistant" {
+		t.Errorf("expected only the reply to be added, got %+v", m.messages)
+	}
+}
+
+func TestDirectQuery_AccumulatesHistory(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat", APIKey: "key", DDNURL: "https://ddn.example.com/graphql"}
+	m := New(cfg)
+	m.view = viewChat
+	m.loading = false
+
+	send := func(model Model, text string) Model {
+		model.chatInput.SetValue(text)
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+		return updated.(Model)
+	}
+	answer := func(model Model, text string) Model {
+		stream := &sdk.QueryStream{}
+		updated, _ := model.Update(queryStreamMsg{stream: stream})
+		model = updated.(Model)
+		chunk := sdk.QueryChunk{Type: sdk.ChunkAssistantAction, Message: text}
+		updated, _ = model.Update(queryChunkMsg{stream: stream, chunk: chunk})
+		model = updated.(Model)
+		updated, _ = model.Update(queryStreamDoneMsg{stream: stream})
+		return updated.(Model)
+	}
+
+	m = send(m, "How many users?")
+	m = answer(m, "42")
+	m = send(m, "And last week?")
+
+	history := m.conversation.History()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 interactions in history, got %d", len(history))
+	}
+	if len(history[0].AssistantActions) != 1 || history[0].AssistantActions[0].Message != "42" {
+		t.Errorf("expected first answer recorded in history, got %+v", history[0].AssistantActions)
+	}
+	if history[1].UserMessage.Text != "And last week?" {
+		t.Errorf("expected follow-up as latest turn, got %q", history[1].UserMessage.Text)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Chat Viewport
+// ---------------------------------------------------------------------------
+
+func newScrollTestModel(n int) Model {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewChat
+	m.loading = false
+	m.width, m.height = 80, 24
+	for i := range n {
+		m.messages = append(m.messages, ChatMessage{Role: "assistant", Content: fmt.Sprintf("answer %d", i)})
+	}
+	return m
+}
+
+func TestChat_ScrollsHistory(t *testing.T) {
+	m := newScrollTestModel(30)
+	if out := ansi.Strip(m.View()); !strings.Contains(out, "answer 29") || strings.Contains(out, "answer 20") {
+		t.Fatalf("expected the latest messages, got:\n%s", out)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	model := updated.(Model)
+	if out := ansi.Strip(model.View()); strings.Contains(out, "answer 29") || !strings.Contains(out, "more below") {
+		t.Errorf("expected pgup to scroll back, got:\n%s", out)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlHome})
+	model = updated.(Model)
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "answer 0") {
+		t.Errorf("expected ctrl+home to show the first message, got:\n%s", out)
+	}
+
+	// New messages do not move the history while it is scrolled.
+	model.messages = append(model.messages, ChatMessage{Role: "assistant", Content: "answer 30"})
+	updated, _ = model.Update(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	model = updated.(Model)
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "answer 1") || strings.Contains(out, "answer 30") {
+		t.Errorf("expected the wheel to scroll a few lines, got:\n%s", out)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlEnd})
+	model = updated.(Model)
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "answer 30") || model.chatScrolled {
+		t.Errorf("expected ctrl+end to follow the latest message, got:\n%s", out)
+	}
+}
+
+func TestChat_RendersMarkdownWrapped(t *testing.T) {
+	m := newScrollTestModel(0)
+	m.width = 40
+	m.messages = []ChatMessage{
+		{Role: "user", Content: "Please summarize the revenue of every region for the last two quarters."},
+		{Role: "assistant", Content: "# Revenue\n\nRevenue grew in **every** region:\n\n- North\n- South\n\n```sql\nSELECT region, sum(amount) FROM orders GROUP BY region\n```"},
+	}
+
+	lines, _ := m.chatLines()
+	out := ansi.Strip(strings.Join(lines, "\n"))
+	for _, line := range lines {
+		if w := ansi.StringWidth(line); w > 40 {
+			t.Errorf("line wider than the chat (%d): %q", w, ansi.Strip(line))
+		}
+	}
+	if strings.Contains(out, "# Revenue") || strings.Contains(out, "**") || strings.Contains(out, "```") {
+		t.Errorf("expected markdown syntax to be rendered, got:\n%s", out)
+	}
+	if !strings.Contains(out, "• North") || !strings.Contains(out, "SELECT region") {
+		t.Errorf("expected the list and code block, got:\n%s", out)
+	}
+	if !strings.Contains(out, "You: Please summarize") || !strings.Contains(out, "two quarters.") {
+		t.Errorf("expected the user message to wrap, got:\n%s", out)
+	}
+}
+
+func TestChat_MarkdownCacheSkipsStreamingAndKeepsRecent(t *testing.T) {
+	m := newScrollTestModel(0)
+	m.width = 40
+	m.stream = &sdk.QueryStream{}
+	m.streamMsgIdx = 0
+	m.messages = []ChatMessage{{Role: "assistant", Content: "streaming **partial** answer"}}
+	m.chatLines()
+	markdown.Lock()
+	_, cached := markdown.rendered[markdownKey{"streaming **partial** answer", 40}]
+	markdown.Unlock()
+	if cached {
+		t.Error("expected the streaming message not to be cached")
+	}
+
+	renderMarkdown("kept", 40, true)
+	for i := range markdownCacheSize {
+		renderMarkdown(fmt.Sprintf("filler %d", i), 40, true)
+		if i%100 == 0 {
+			renderMarkdown("kept", 40, true)
+		}
+	}
+	markdown.Lock()
+	_, kept := markdown.rendered[markdownKey{"kept", 40}]
+	_, first := markdown.rendered[markdownKey{"filler 0", 40}]
+	size := markdown.recent.Len()
+	markdown.Unlock()
+	if !kept || first || size != markdownCacheSize {
+		t.Errorf("expected the least recently shown entry to be dropped, kept=%v first=%v size=%d", kept, first, size)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Message Feedback
+// ---------------------------------------------------------------------------
+
+func TestFeedback_RateSelectedMessage(t *testing.T) {
+	var sent map[string]any
+	m := New(&config.Config{PAT: "test-pat"})
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:     "test-pat",
+		BaseURL: "https://test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Variables map[string]any `json:"variables"`
+			}
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			sent = body.Variables
+			resp := `{"data":{"submitThreadFeedback":{"thread_id":"t-1","message_id":"m-2","feedback":-1}}}`
+			return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(resp))}, nil
+		})},
+	})
+	m.view = viewChat
+	m.threadID = "t-1"
+	m.activeThread = &sdk.Thread{ThreadID: "t-1"}
+	m.loading = true
+
+	updated, _ := m.Update(eventsLoadedMsg{events: []sdk.ThreadEvent{
+		{ThreadEventID: 1, EventData: []byte(`{"user_message":{"text":"how many users?","message_id":"m-1"}}`)},
+		{ThreadEventID: 2, EventData: []byte(`{"assistant_message":{"text":"42","message_id":"m-2"}}`)},
+	}})
+	model := updated.(Model)
+	if got := model.messages[1]; got.EventID != 2 || got.MessageID != "m-2" {
+		t.Fatalf("expected the message to keep its IDs, got %+v", got)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	model = updated.(Model)
+	if !model.selecting || model.msgCursor != 1 {
+		t.Fatal("expected ctrl+f to select the latest message")
+	}
+
+	// Only PromptQL messages can be rated.
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	model = updated.(Model)
+	if model.err == nil || model.feedbackValue != 0 {
+		t.Error("expected rating a user message to be rejected")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}})
+	model = updated.(Model)
+	if model.feedbackValue != sdk.FeedbackNegative || model.err != nil {
+		t.Fatal("expected - to ask for details on a negative rating")
+	}
+	for _, r := range "wrong table" {
+		updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected enter to submit the feedback")
+	}
+	updated, _ = updated.(Model).Update(cmd())
+	model = updated.(Model)
+
+	if sent["threadId"] != "t-1" || sent["messageId"] != "m-2" || sent["feedback"] != float64(-1) || sent["details"] != "wrong table" {
+		t.Errorf("unexpected feedback variables %v", sent)
+	}
+	if model.messages[1].Feedback != sdk.FeedbackNegative {
+		t.Error("expected the submitted rating to be recorded on the message")
+	}
+	if out := ansi.Strip(model.View()); !strings.Contains(out, "PromptQL:  👎 rated unhelpful") {
+		t.Errorf("expected the rating next to the message, got:\n%s", out)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model = updated.(Model); model.selecting || model.view != viewChat {
+		t.Error("expected esc to leave selection mode first")
+	}
+}
+
+func TestFeedback_NotInDirectQuery(t *testing.T) {
+	m := New(&config.Config{PAT: "test-pat"})
+	m.view = viewChat
+	m.loading = false
+	m.messages = []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	if model := updated.(Model); model.err == nil || model.feedbackValue != 0 || cmd != nil {
+		t.Error("expected feedback without a thread to be rejected")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Artifact Tables
+// ---------------------------------------------------------------------------
+
+func testTableArtifact() sdk.Artifact {
+	return sdk.Artifact{
+		Identifier:   "users",
+		Title:        "Users",
+		ArtifactType: sdk.ArtifactTypeTable,
+		Data:         []byte(`[{"name":"carol","age":9},{"name":"alice","age":30},{"name":"bob","age":100}]`),
+	}
+}
+
+func TestArtifactTable_SortAndNavigate(t *testing.T) {
+	table, err := newArtifactTable(testTableArtifact())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	table.update("l", 10)
+	table.update("s", 10)
+	if got := table.selectedCell(); got != "9" {
+		t.Errorf("expected numeric ascending sort to select 9, got %q", got)
+	}
+	table.update("s", 10)
+	if got := table.selectedCell(); got != "100" {
+		t.Errorf("expected descending sort to select 100, got %q", got)
+	}
+	table.update("s", 10)
+	if got := table.selectedCell(); got != "9" {
+		t.Errorf("expected original order after third sort, got %q", got)
+	}
+
+	table.update("G", 10)
+	table.update("h", 10)
+	if got := table.selectedCell(); got != "bob" {
+		t.Errorf("expected last row first column 'bob', got %q", got)
+	}
+	table.update("j", 10)
+	if table.cursorRow != 2 {
+		t.Errorf("expected cursor clamped at last row, got %d", table.cursorRow)
+	}
+}
+
+func TestArtifactTable_ViewFitsWidth(t *testing.T) {
+	table, err := newArtifactTable(testTableArtifact())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, line := range strings.Split(table.view(20, 10), "\n") {
+		if w := ansi.StringWidth(line); w > 20 {
+			t.Errorf("line exceeds width 20 (%d): %q", w, line)
+		}
+	}
+	if !strings.Contains(renderArtifactPreview(testTableArtifact(), 80), "alice") {
+		t.Error("expected preview to include row data")
+	}
+}
+
+func TestArtifactTable_LayoutScrollsViewDoesNot(t *testing.T) {
+	table, err := newArtifactTable(testTableArtifact())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	table.update("G", 1)
+	table.update("l", 1)
+	before := *table
+	_ = table.view(8, 4)
+	if table.rowOffset != before.rowOffset || table.colOffset != before.colOffset {
+		t.Fatal("expected view to leave the offsets alone")
+	}
+
+	table.layout(8, 4)
+	if table.rowOffset != 2 || table.colOffset != 1 {
+		t.Errorf("expected layout to scroll to the cursor, got row %d col %d", table.rowOffset, table.colOffset)
+	}
+	if view := table.view(8, 4); !strings.Contains(view, "100") {
+		t.Errorf("expected the cursor cell to be shown, got:\n%s", view)
+	}
+}
+
+func TestEventsLoaded_AttachesArtifacts(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m.activeThread = &sdk.Thread{ThreadID: "t-1"}
+
+	events := []sdk.ThreadEvent{
+		{ThreadEventID: 1, EventData: []byte(`{"assistant_message":{"text":"Here are the users"}}`)},
+		{ThreadEventID: 2, EventData: []byte(`{"type":"artifact_created","artifact":{"identifier":"users","title":"Users","artifact_type":"table","data":[{"name":"alice"}]}}`)},
+	}
+	updated, _ := m.Update(eventsLoadedMsg{events: events})
+	model := updated.(Model)
+
+	if len(model.messages) != 1 || len(model.messages[0].Artifacts) != 1 {
+		t.Fatalf("expected artifact attached to assistant message, got %+v", model.messages)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	model = updated.(Model)
+	if model.tableFocus == nil {
+		t.Fatal("expected ctrl+t to focus the table")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.tableFocus != nil || model.view != viewChat {
+		t.Error("expected esc to leave table focus but stay in chat")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Export
+// ---------------------------------------------------------------------------
+
+func TestExport_WritesArtifactCSV(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m.messages = []ChatMessage{{Role: "assistant", Content: "Here", Artifacts: []sdk.Artifact{testTableArtifact()}}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	model := updated.(Model)
+	if !model.exporting {
+		t.Fatal("expected ctrl+e to open the export prompt")
+	}
+	if model.exportInput.Value() != "users.csv" {
+		t.Errorf("expected suggested path 'users.csv', got %q", model.exportInput.Value())
+	}
+
+	path := filepath.Join(t.TempDir(), "out", "users.csv")
+	model.exportInput.SetValue(path)
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.exporting || cmd == nil {
+		t.Fatal("expected enter to close the prompt and start the export")
+	}
+
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if model.err != nil {
+		t.Fatalf("unexpected export error: %v", model.err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "name,age\ncarol,9\n") {
+		t.Errorf("unexpected CSV contents: %q", data)
+	}
+	if !strings.Contains(model.notice, path) {
+		t.Errorf("expected notice to mention %q, got %q", path, model.notice)
+	}
+}
+
+func TestExport_CtrlEInChatInputIsLineEnd(t *testing.T) {
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m = typeText(m, "hello")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	model := updated.(Model)
+	if model.exporting {
+		t.Fatal("expected ctrl+e in the chat input not to open the export prompt")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if got := updated.(Model).chatInput.Value(); got != "hello!" {
+		t.Errorf("expected ctrl+e to move to the end of the line, got %q", got)
+	}
+}
+
+func TestExport_RejectsUnknownExtension(t *testing.T) {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewChat
+	m = m.openExport()
+	m.exportInput.SetValue("out.xlsx")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model := updated.(Model)
+	if model.err == nil || !model.exporting {
+		t.Error("expected an error and the prompt to stay open")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sample Prompts
+// ---------------------------------------------------------------------------
+
+func newPromptsTestModel() Model {
+	cfg := &config.Config{PAT: "test-pat"}
+	m := New(cfg)
+	m.view = viewThreads
+	m.loading = false
+	m.selectedProject = &sdk.UserProject{Name: "proj", ProjectID: "p-1"}
+	return m
+}
+
+func TestPrompts_OpenFromThreads(t *testing.T) {
+	m := newPromptsTestModel()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	model := updated.(Model)
+	if model.view != viewPrompts || !model.loading || cmd == nil {
+		t.Fatalf("expected prompts view loading, got view=%d loading=%v", model.view, model.loading)
+	}
+
+	prompts := []sdk.SamplePrompt{{ID: "1", DisplayText: "Orders", FullPrompt: "How many orders\nshipped today?"}}
+	updated, _ = model.Update(promptsLoadedMsg{prompts: prompts})
+	model = updated.(Model)
+	if len(model.prompts) != 1 || model.loading {
+		t.Fatalf("expected prompts loaded, got %+v", model.prompts)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.view != viewChat || model.threadID != "" {
+		t.Fatalf("expected a new chat, got view=%d thread=%q", model.view, model.threadID)
+	}
+	if model.chatInput.Value() != "How many orders\nshipped today?" {
+		t.Errorf("expected chat input prefilled with full prompt, got %q", model.chatInput.Value())
+	}
+}
+
+func TestPrompts_CreateAndDelete(t *testing.T) {
+	m := newPromptsTestModel()
+	m.view = viewPrompts
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	model := updated.(Model)
+	if model.promptMode != promptEdit || model.promptEditID != "" {
+		t.Fatal("expected n to open the editor for a new prompt")
+	}
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if model.err == nil || cmd != nil {
+		t.Error("expected empty prompt to be rejected")
+	}
+
+	model.promptTitle.SetValue("Top customers")
+	model.promptBody.SetValue("List the top 10 customers\nby revenue")
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if !model.loading || cmd == nil {
+		t.Fatal("expected ctrl+s to save")
+	}
+
+	updated, _ = model.Update(promptSavedMsg{prompt: &sdk.SamplePrompt{ID: "9", DisplayText: "Top customers"}})
+	model = updated.(Model)
+	if model.promptMode != promptList || len(model.prompts) != 1 {
+		t.Fatalf("expected saved prompt in list, got mode=%d prompts=%+v", model.promptMode, model.prompts)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model = updated.(Model)
+	if model.promptMode != promptConfirmDelete {
+		t.Fatal("expected d to ask for confirmation")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.promptMode != promptList || model.view != viewPrompts {
+		t.Fatal("expected esc to cancel deletion")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model = updated.(Model)
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expected y to delete")
+	}
+	updated, _ = model.Update(promptDeletedMsg{id: "9"})
+	model = updated.(Model)
+	if len(model.prompts) != 0 {
+		t.Errorf("expected prompt removed, got %+v", model.prompts)
+	}
+}
+
+func TestPrompts_LoadCanBeCancelled(t *testing.T) {
+	m := newPromptsTestModel()
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})},
+	})
+	updated, cmd := m.openPrompts()
+	model := updated.(Model)
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.view != viewPrompts || !model.loading {
+		t.Fatal("expected esc to be ignored while the prompts load")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(Model)
+	if model.loading || !errors.Is(model.err, errCancelled) {
+		t.Fatalf("expected the load to be cancelled, got loading=%v err=%v", model.loading, model.err)
+	}
+	if msgs := requestMsgs(cmd); len(msgs) != 0 {
+		t.Errorf("expected the cancelled load to send nothing, got %v", msgs)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model = updated.(Model); model.view != viewThreads || model.loading {
+		t.Errorf("expected esc to leave once the load is cancelled, got view %d loading %v", model.view, model.loading)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// API Keys
+// ---------------------------------------------------------------------------
+
+func TestKeys_GenerateRevealOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newPromptsTestModel()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	model := updated.(Model)
+	if model.view != viewKeys || cmd == nil {
+		t.Fatalf("expected API keys view, got %d", model.view)
+	}
+	updated, _ = model.Update(apiKeysLoadedMsg{})
+	model = updated.(Model)
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	model = updated.(Model)
+	if model.keyMode != keyGenerate {
+		t.Fatal("expected g to open the generate form")
+	}
+	model.keyInputs[keyFieldName].SetValue("ci")
+	model.keyInputs[keyFieldSQLTimeout].SetValue("0")
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.err == nil || cmd != nil {
+		t.Fatal("expected a zero timeout to be rejected")
+	}
+
+	model.keyInputs[keyFieldSQLTimeout].SetValue("30")
+	opts, err := model.keyFormOptions()
+	if err != nil || opts.Name != "ci" || opts.SQLTimeout == nil || *opts.SQLTimeout != 30 || opts.PromptQLTimeout != nil {
+		t.Fatalf("unexpected options %+v (%v)", opts, err)
+	}
+
+	key := &sdk.GeneratedAPIKey{RuntimeAPIKey: sdk.RuntimeAPIKey{ID: 3, Name: "ci"}, APIKey: "pql_secret"}
+	updated, _ = model.Update(apiKeyGeneratedMsg{projectID: "p-1", key: key})
+	model = updated.(Model)
+	if model.revealedKey == nil || !strings.Contains(model.View(), "pql_secret") {
+		t.Fatal("expected the plaintext key to be shown")
+	}
+
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model = updated.(Model)
+	if model.cfg.APIKey != "pql_secret" || cmd == nil {
+		t.Fatal("expected s to save the key as the configured API key")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if model.err != nil {
+		t.Fatalf("unexpected save error: %v", model.err)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.keyMode != keyList || model.revealedKey != nil {
+		t.Fatal("expected leaving the reveal screen to discard the key")
+	}
+	if strings.Contains(model.View(), "pql_secret") {
+		t.Error("expected the plaintext key not to be shown again")
+	}
+	if len(model.apiKeys) != 1 || model.apiKeys[0].Name != "ci" {
+		t.Errorf("expected the new key in the list, got %+v", model.apiKeys)
+	}
+}
+
+func TestKeys_GeneratedKeyShownAfterLeavingTheView(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newPromptsTestModel()
+	m.view = viewKeys
+	m.keyMode = keyGenerate
+	m.loading = true
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model := updated.(Model)
+	if model.view != viewKeys || model.keyMode != keyGenerate {
+		t.Fatalf("expected esc to wait for the key, got view %d mode %d", model.view, model.keyMode)
+	}
+	// e.g. another project was opened by the time the key arrives
+	model.view = viewThreads
+	model.scopeGen++
+
+	key := &sdk.GeneratedAPIKey{RuntimeAPIKey: sdk.RuntimeAPIKey{ID: 3, Name: "ci"}, APIKey: "pql_secret"}
+	updated, _ = model.Update(apiKeyGeneratedMsg{projectID: "p-1", key: key})
+	model = updated.(Model)
+	if !strings.Contains(model.View(), "pql_secret") {
+		t.Fatal("expected the key to be shown outside the keys view")
+	}
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model = updated.(Model)
+	if model.cfg.APIKey != "pql_secret" || cmd == nil {
+		t.Fatal("expected s to save the key from the reveal screen")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if model.notice != "Saved as your API key" {
+		t.Errorf("expected the save to be confirmed, got %q (%v)", model.notice, model.err)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.revealedKey != nil || model.view != viewThreads {
+		t.Error("expected closing the key to go back to the threads view")
+	}
+}
+
+func TestKeys_LoadCanBeCancelled(t *testing.T) {
+	m := newPromptsTestModel()
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})},
+	})
+	updated, cmd := m.openKeys()
+	model := updated.(Model)
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.view != viewKeys || !model.loading {
+		t.Fatal("expected esc to be ignored while the keys load")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(Model)
+	if model.loading || !errors.Is(model.err, errCancelled) {
+		t.Fatalf("expected the load to be cancelled, got loading=%v err=%v", model.loading, model.err)
+	}
+	if msgs := requestMsgs(cmd); len(msgs) != 0 {
+		t.Errorf("expected the cancelled load to send nothing, got %v", msgs)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model = updated.(Model); model.view != viewThreads || model.loading {
+		t.Errorf("expected esc to leave once the load is cancelled, got view %d loading %v", model.view, model.loading)
+	}
+}
+
+func TestKeys_RevokeNeedsConfirmation(t *testing.T) {
+	m := newPromptsTestModel()
+	m.view = viewKeys
+	m.apiKeys = []sdk.RuntimeAPIKey{{ID: 1, Name: "old"}}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model := updated.(Model)
+	if model.keyMode != keyConfirmRevoke || cmd != nil {
+		t.Fatal("expected d to ask for confirmation")
+	}
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	model = updated.(Model)
+	if model.keyMode != keyList || cmd != nil {
+		t.Fatal("expected n to cancel")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model = updated.(Model)
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model = updated.(Model)
+	if !model.loading || cmd == nil {
+		t.Fatal("expected y to revoke the key")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Project Settings
+// ---------------------------------------------------------------------------
+
+func newAdminTestModel() Model {
+	m := newPromptsTestModel()
+	m.view = viewAdmin
+	m.height = 40
+	limit := 1000
+	m.promptqlConfig = &sdk.PromptQLConfig{PromptQLEnabled: true, PlaygroundEnabled: true}
+	m.playgroundConfig = &sdk.PlaygroundConfig{
+		LLMProvider:            "openai",
+		LLMApiKey:              "sk-abcdef123456",
+		ProjectTokenUsageLimit: &limit,
+		FeatureFlags:           map[string]interface{}{"beta": true},
+		SystemInstructions:     "Be concise.\nUse tables.\n",
+	}
+	return m
+}
+
+func TestAdmin_OpenFromThreads(t *testing.T) {
+	m := newPromptsTestModel()
+	m.height = 40
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	model := updated.(Model)
+	if model.view != viewAdmin || !model.loading || cmd == nil {
+		t.Fatalf("expected project settings loading, got view=%d", model.view)
+	}
+
+	cfg := newAdminTestModel()
+	updated, _ = model.Update(adminConfigLoadedMsg{promptql: cfg.promptqlConfig, playground: cfg.playgroundConfig})
+	model = updated.(Model)
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "openai") || !strings.Contains(view, "beta") {
+		t.Errorf("expected settings in view, got:\n%s", view)
+	}
+	if strings.Contains(view, "sk-abcdef123456") || !strings.Contains(view, "3456") {
+		t.Error("expected the LLM API key to be masked")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).view != viewThreads {
+		t.Error("expected esc to return to threads")
+	}
+}
+
+func TestAdmin_OpenDropsAnotherProjectsSettings(t *testing.T) {
+	m := newAdminTestModel()
+	m.instructionHistory = &config.InstructionHistory{ProjectID: "p-1"}
+	m.view = viewThreads
+	m.selectedProject = &sdk.UserProject{Name: "ops", ProjectID: "p-2"}
+	m.client = sdk.NewClient(sdk.ClientOptions{
+		PAT:             "test-pat",
+		ControlPlaneURL: "https://cp.test.example.com",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})},
+	})
+
+	updated, _ := m.openAdmin()
+	model := updated.(Model)
+	if model.promptqlConfig != nil || model.playgroundConfig != nil || model.instructionHistory != nil {
+		t.Fatal("expected the previous project's settings to be dropped")
+	}
+	if strings.Contains(ansi.Strip(model.View()), "openai") {
+		t.Error("expected the previous project's settings not to be shown")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	model = updated.(Model)
+	if model.loading || !errors.Is(model.err, errCancelled) {
+		t.Fatalf("expected the load to be cancelled, got loading=%v err=%v", model.loading, model.err)
+	}
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if model = updated.(Model); model.adminMode != adminOverview || cmd != nil {
+		t.Error("expected nothing to edit without settings")
+	}
+}
+
+func TestAdmin_TogglePromptQLNeedsConfirmation(t *testing.T) {
+	m := newAdminTestModel()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	model := updated.(Model)
+	if model.adminMode != adminConfirmToggle || cmd != nil {
+		t.Fatal("expected t to ask for confirmation")
+	}
+	if !strings.Contains(model.View(), "Disable PromptQL") {
+		t.Error("expected the confirmation to offer disabling PromptQL")
+	}
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model = updated.(Model)
+	if !model.loading || cmd == nil || model.adminMode != adminOverview {
+		t.Fatal("expected y to toggle PromptQL")
+	}
+}
+
+func TestAdmin_EditPreviewAndSave(t *testing.T) {
+	m := newAdminTestModel()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	model := updated.(Model)
+	if model.adminMode != adminEdit || model.adminFields[adminFieldProvider].value() != "openai" {
+		t.Fatal("expected e to open the form with the current settings")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if model.adminMode != adminEdit || model.notice == "" {
+		t.Fatal("expected an unchanged form not to be saved")
+	}
+
+	model.adminFields[adminFieldProjectLimit].setValue("lots")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if model.err == nil || model.adminMode != adminEdit {
+		t.Fatal("expected a non-numeric limit to be rejected")
+	}
+
+	model.adminFields[adminFieldProjectLimit].setValue("1000")
+	model.adminFields[adminFieldPublic].setValue("yes")
+	model.adminFields[adminFieldInstructions].setValue("Be concise.\nUse charts.\n")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if model.adminMode != adminPreview {
+		t.Fatalf("expected a preview, got mode=%d err=%v", model.adminMode, model.err)
+	}
+	u := model.adminUpdate
+	if u.AllowPublicAccess == nil || !*u.AllowPublicAccess || u.SystemInstructions == nil {
+		t.Fatalf("expected changed fields in update, got %+v", u)
+	}
+	if u.LLMProvider != nil || u.ProjectTokenUsageLimit != nil || u.FeatureFlags != nil || u.Readme != nil {
+		t.Errorf("expected unchanged fields to be omitted, got %+v", u)
+	}
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "-Use tables.") || !strings.Contains(view, "+Use charts.") {
+		t.Errorf("expected a diff of the system instructions, got:\n%s", view)
+	}
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model = updated.(Model)
+	if !model.loading || cmd == nil {
+		t.Fatal("expected y to save")
+	}
+	saved := *model.playgroundConfig
+	saved.SystemInstructions = *u.SystemInstructions
+	updated, _ = model.Update(playgroundSavedMsg{config: &saved})
+	model = updated.(Model)
+	if model.adminMode != adminOverview || model.playgroundConfig.SystemInstructions != "Be concise.\nUse charts.\n" {
+		t.Error("expected the saved settings to be shown")
+	}
+}
+
+func TestInstructions_EditPreviewReturnsToEditor(t *testing.T) {
+	m := newAdminTestModel()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	model := updated.(Model)
+	if model.adminMode != adminInstructions || model.instructionsInput.Value() != "Be concise.\nUse tables.\n" {
+		t.Fatal("expected i to open the editor with the live instructions")
+	}
+
+	model.instructionsInput.SetValue("Be concise.\nUse charts.\n")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(Model)
+	if model.adminMode != adminPreview || model.adminUpdate.SystemInstructions == nil {
+		t.Fatalf("expected a preview of the new instructions, got mode=%d", model.adminMode)
+	}
+	if model.adminUpdate.LLMProvider != nil || model.adminUpdate.FeatureFlags != nil {
+		t.Errorf("expected only the instructions to change, got %+v", model.adminUpdate)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.adminMode != adminInstructions || model.instructionsInput.Value() != "Be concise.\nUse charts.\n" {
+		t.Error("expected esc to return to the editor with the edits kept")
+	}
+}
+
+func TestInstructions_RecordHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	h, err := recordInstructions("p-1", "v1", "v2", 0)
+	if err != nil {
+		t.Fatalf("recordInstructions() error: %v", err)
+	}
+	if len(h.Versions) != 2 || h.Versions[0].Instructions != "v1" || h.Versions[1].Instructions != "v2" {
+		t.Fatalf("expected the replaced and new versions, got %+v", h.Versions)
+	}
+
+	h, err = recordInstructions("p-1", "v2", "v1", 1)
+	if err != nil {
+		t.Fatalf("recordInstructions() error: %v", err)
+	}
+	if len(h.Versions) != 3 || h.Latest().RestoredFrom != 1 {
+		t.Errorf("expected the rollback appended as a new version, got %+v", h.Versions)
+	}
+}
+
+func TestInstructions_HistoryDiffAndRollback(t *testing.T) {
+	m := newAdminTestModel()
+	m.instructionHistory = &config.InstructionHistory{ProjectID: "p-1", Versions: []config.InstructionVersion{
+		{Instructions: "Be thorough.\nUse tables.\n"},
+		{Instructions: "Be concise.\nUse tables.\n"},
+	}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	model := updated.(Model)
+	if model.adminMode != adminHistory {
+		t.Fatal("expected h to open the history")
+	}
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "[live]") || !strings.Contains(view, "-Be thorough.") || !strings.Contains(view, "+Be concise.") {
+		t.Errorf("expected the newest version diffed against the previous one, got:\n%s", view)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	model = updated.(Model)
+	if model.adminMode != adminHistory || model.notice == "" {
+		t.Fatal("expected rolling back to the live version to be refused")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(Model)
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	model = updated.(Model)
+	if model.adminMode != adminConfirmRollback || !strings.Contains(model.View(), "v1") {
+		t.Fatal("expected r to ask for confirmation")
+	}
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	model = updated.(Model)
+	if !model.loading || cmd == nil || model.adminMode != adminHistory {
+		t.Fatal("expected y to roll back")
+	}
+
+	saved := *model.playgroundConfig
+	saved.SystemInstructions = "Be thorough.\nUse tables.\n"
+	history := *model.instructionHistory
+	history.Versions = append(history.Versions, config.InstructionVersion{Instructions: saved.SystemInstructions, RestoredFrom: 1})
+	updated, _ = model.Update(playgroundSavedMsg{config: &saved, history: &history})
+	model = updated.(Model)
+	if model.adminMode != adminHistory || model.historyCursor != 0 || len(model.instructionHistory.Versions) != 3 {
+		t.Error("expected the rollback to show as the newest version")
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "v3") || !strings.Contains(ansi.Strip(model.View()), "(rollback to v1)") {
+		t.Errorf("expected the rollback to name the version it restored, got:\n%s", ansi.Strip(model.View()))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Profiles
+// ---------------------------------------------------------------------------
+
+func TestProfiles_SwitchRebuildsClient(t *testing.T) {
+	cfg := &config.Config{PAT: "prod-pat"}
+	if err := cfg.AddProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Profiles["staging"] = config.Profile{PAT: "staging-pat", BaseURL: "https://staging.example.com"}
+	m := New(cfg)
+	m.loading = false
+	m.projects = []sdk.UserProject{{Name: "prod-project"}}
+	m.selectedProject = &m.projects[0]
+	oldClient := m.client
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	model := updated.(Model)
+	if model.view != viewProfiles || !strings.Contains(model.View(), "staging") {
+		t.Fatal("expected p to open the profile switcher")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(Model)
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.cfg.Name() != "staging" || model.cfg.PAT != "staging-pat" {
+		t.Fatalf("expected staging to be active, got %q", model.cfg.Name())
+	}
+	if model.client == oldClient || model.view != viewProjects || !model.loading || cmd == nil {
+		t.Error("expected a new client loading the staging projects")
+	}
+	if model.projects != nil || model.selectedProject != nil {
+		t.Error("expected state from the previous profile to be cleared")
+	}
+}
+
+func TestProfiles_NewProfileOpensSetup(t *testing.T) {
+	m := New(&config.Config{PAT: "prod-pat"})
+	m.loading = false
+	updated, _ := m.openProfiles()
+	model := updated.(Model)
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	model = updated.(Model)
+	model.profileInput.SetValue("default")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.err == nil || !model.profileCreating {
+		t.Fatal("expected a duplicate profile name to be rejected")
+	}
+
+	model.profileInput.SetValue("dev")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.view != viewSetup || model.client != nil || model.cfg.Name() != "dev" {
+		t.Fatalf("expected setup for the new profile, got view=%d profile=%q", model.view, model.cfg.Name())
+	}
+	if model.setupInputs[fieldPAT].Value() != "" {
+		t.Error("expected the setup form to be cleared for the new profile")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).view != viewProfiles {
+		t.Error("expected esc from setup to return to the profile switcher")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Composer
+// ---------------------------------------------------------------------------
+
+// composerModel returns a model in the chat of thread-1, with composer state
+// for proj-1 loaded.
+func composerModel(cfg *config.Config) Model {
+	m := New(cfg)
+	m.loading = false
+	m.selectedProject = &sdk.UserProject{ProjectID: "proj-1", Name: "proj"}
+	m.view = viewChat
+	m.threadID = "thread-1"
+	m.composer = &config.Composer{ProjectID: "proj-1"}
+	m.chatInput.Focus()
+	return m
+}
+
+func typeText(m Model, text string) Model {
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+	return updated.(Model)
+}
+
+func TestComposer_RecallHistory(t *testing.T) {
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m.composer.History = []string{"first", "second"}
+	m = typeText(m, "wip")
+
+	steps := []struct {
+		key  tea.KeyType
+		want string
+	}{
+		{tea.KeyUp, "second"},
+		{tea.KeyUp, "first"},
+		{tea.KeyUp, "first"},
+		{tea.KeyDown, "second"},
+		{tea.KeyDown, "wip"},
+	}
+	for i, step := range steps {
+		updated, _ := m.Update(tea.KeyMsg{Type: step.key})
+		m = updated.(Model)
+		if got := m.chatInput.Value(); got != step.want {
+			t.Fatalf("step %d: expected %q in the input, got %q", i, step.want, got)
+		}
+	}
+	if m.recallDepth != 0 {
+		t.Errorf("expected recall to end at the draft, got depth %d", m.recallDepth)
+	}
+}
+
+func TestComposer_DraftsKeptPerThread(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m = typeText(m, "half written")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.view != viewThreads || m.chatInput.Value() != "" {
+		t.Fatalf("expected to leave the chat with an empty input, got %q", m.chatInput.Value())
+	}
+	if msg, ok := cmd().(composerSavedMsg); !ok || msg.err != nil {
+		t.Fatalf("expected the draft to be saved, got %#v", msg)
+	}
+
+	updated, _ = m.openNewChat()
+	m = updated.(Model)
+	if m.chatInput.Value() != "" {
+		t.Errorf("expected a new thread to start empty, got %q", m.chatInput.Value())
+	}
+
+	// A fresh session loads the draft from disk.
+	m = New(&config.Config{PAT: "test-pat"})
+	m.selectedProject = &sdk.UserProject{ProjectID: "proj-1", Name: "proj"}
+	updated, cmd = m.resumeThread(sdk.Thread{ThreadID: "thread-1"})
+	m = updated.(Model)
+	for _, msg := range requestMsgs(cmd) {
+		if loaded, ok := msg.(composerLoadedMsg); ok {
+			updated, _ = m.Update(loaded)
+			m = updated.(Model)
+		}
+	}
+	if got := m.chatInput.Value(); got != "half written" {
+		t.Errorf("expected the thread's draft to be restored, got %q", got)
+	}
+}
+
+func TestComposer_StaleSaveDoesNotOverwriteNewer(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	m := composerModel(&config.Config{PAT: "test-pat"})
+
+	m.composer.SetDraft("thread-1", "older")
+	older := m.saveComposer()
+	m.composer.SetDraft("thread-1", "newer")
+	newer := m.saveComposer()
+
+	// The commands run in either order; the newer snapshot wins.
+	for _, cmd := range []tea.Cmd{newer, older} {
+		if msg, ok := cmd().(composerSavedMsg); !ok || msg.err != nil {
+			t.Fatalf("expected the composer to be saved, got %#v", msg)
+		}
+	}
+	c, err := config.LoadComposer("proj-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Drafts["thread-1"]; got != "newer" {
+		t.Errorf("expected the newer draft on disk, got %q", got)
+	}
+}
+
+func TestComposer_SendKey(t *testing.T) {
+	tests := []struct {
+		sendKey string
+		send    tea.KeyMsg
+		newline tea.KeyMsg
+	}{
+		{"", tea.KeyMsg{Type: tea.KeyCtrlJ}, tea.KeyMsg{Type: tea.KeyEnter}},
+		{config.SendKeyEnter, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter, Alt: true}},
+	}
+	for _, tt := range tests {
+		m := composerModel(&config.Config{SendKey: tt.sendKey})
+		m = typeText(m, "line one")
+		updated, _ := m.Update(tt.newline)
+		m = typeText(updated.(Model), "line two")
+		if got := m.chatInput.Value(); got != "line one\nline two" {
+			t.Fatalf("send key %q: expected a newline in the input, got %q", tt.sendKey, got)
+		}
+
+		updated, _ = m.Update(tt.send)
+		m = updated.(Model)
+		if len(m.messages) != 1 || m.messages[0].Content != "line one\nline two" {
+			t.Fatalf("send key %q: expected the message to be sent, got %+v", tt.sendKey, m.messages)
+		}
+		if m.chatInput.Value() != "" || len(m.composer.History) != 1 {
+			t.Errorf("send key %q: expected the input cleared and the message in the history", tt.sendKey)
+		}
+	}
+}
+
+func TestComposer_EditorResult(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand(); strings.Join(got, " ") != "code --wait" {
+		t.Errorf("expected $EDITOR with its arguments, got %q", got)
+	}
+
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	path := filepath.Join(t.TempDir(), "draft.md")
+	if err := os.WriteFile(path, []byte("a long\nprompt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(editorDoneMsg{path: path})
+	m = updated.(Model)
+	if got := m.chatInput.Value(); got != "a long\nprompt" {
+		t.Errorf("expected the edited text in the input, got %q", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the temporary file to be removed")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Slash Commands
+// ---------------------------------------------------------------------------
+
+func runSlashLine(m Model, line string) (Model, tea.Cmd) {
+	m = m.setDraft(line)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updated.(Model), cmd
+}
+
+func TestSlash_CompletionPopup(t *testing.T) {
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m = typeText(m, "/ex")
+	if view := m.View(); !strings.Contains(view, "/export [path]") || strings.Contains(view, "/new") {
+		t.Fatalf("expected the popup to list only /export, got:\n%s", view)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	if got := m.chatInput.Value(); got != "/export " {
+		t.Errorf("expected tab to complete the command, got %q", got)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.view != viewChat || m.chatInput.Value() != "" {
+		t.Error("expected esc to dismiss the command, staying in the chat")
+	}
+}
+
+func TestSlash_RunsSelectedCommand(t *testing.T) {
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m.activeThread = &sdk.Thread{ThreadID: "thread-1", Title: "Quarterly revenue"}
+	// Matches /title and /tz; enter runs the first one.
+	m = typeText(m, "/t")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !strings.Contains(m.notice, "Quarterly revenue") || m.chatInput.Value() != "" {
+		t.Errorf("expected enter to run /title, got notice %q and input %q", m.notice, m.chatInput.Value())
+	}
+
+	m, _ = runSlashLine(m, "/nope")
+	if m.err == nil || !strings.Contains(m.err.Error(), "unknown command /nope") {
+		t.Errorf("expected an unknown command error, got %v", m.err)
+	}
+}
+
+func TestSlash_RetryFailedMessage(t *testing.T) {
+	m := composerModel(&config.Config{APIKey: "key", DDNURL: "https://ddn.example.com/graphql"})
+	m.selectedProject = nil
+	m.threadID = ""
+	m.messages = []ChatMessage{{Role: "user", Content: "how many users?"}}
+	m.err = errors.New("connection reset")
+
+	m, cmd := runSlashLine(m, "/retry")
+	if len(m.messages) != 1 || m.messages[0].Content != "how many users?" {
+		t.Fatalf("expected the failed message to be sent once more, got %+v", m.messages)
+	}
+	if !m.loading || m.pendingText != "how many users?" || cmd == nil || m.err != nil {
+		t.Error("expected the message to be sent again")
+	}
+}
+
+func TestSlash_PromptLoadsAndInserts(t *testing.T) {
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m.client = sdk.NewClient(sdk.ClientOptions{PAT: "test-pat"})
+
+	m, cmd := runSlashLine(m, "/prompt revenue")
+	if cmd == nil || m.promptQuery != "revenue" {
+		t.Fatal("expected the sample prompts to be loaded first")
+	}
+	updated, _ := m.Update(promptsLoadedMsg{scope: m.scope(), prompts: []sdk.SamplePrompt{
+		{ID: "1", DisplayText: "Revenue by region", FullPrompt: "Show revenue by region for 2025"},
+		{ID: "2", DisplayText: "Top customers", FullPrompt: "List the top customers"},
+	}})
+	m = updated.(Model)
+	if got := m.chatInput.Value(); got != "Show revenue by region for 2025" {
+		t.Errorf("expected the matching prompt in the input, got %q", got)
+	}
+
+	m, _ = runSlashLine(m.setDraft(""), "/prompt")
+	if got := m.chatInput.Value(); got != "/prompt " || len(m.slashSuggestions()) != 2 {
+		t.Errorf("expected the prompts to be offered, got input %q", got)
+	}
+}
+
+func TestSlash_TimezoneAndVisibility(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := composerModel(&config.Config{PAT: "test-pat", Timezone: "UTC"})
+
+	m, _ = runSlashLine(m, "/tz Mars/Olympus")
+	if m.err == nil || m.cfg.Timezone != "UTC" {
+		t.Error("expected an unknown timezone to be rejected")
+	}
+	m, _ = runSlashLine(m, "/tz Europe/Berlin")
+	if m.err != nil || m.cfg.Timezone != "Europe/Berlin" {
+		t.Errorf("expected the timezone to be set, got %q (%v)", m.cfg.Timezone, m.err)
+	}
+
+	m.activeThread = &sdk.Thread{ThreadID: "thread-1", Visibility: "private"}
+	m, _ = runSlashLine(m, "/visibility public")
+	if m.err == nil {
+		t.Error("expected the visibility of a started thread to be fixed")
+	}
+	updated, _ := m.openNewChat()
+	m, _ = runSlashLine(updated.(Model), "/visibility public")
+	if m.newThreadVisibility != "public" {
+		t.Errorf("expected the new thread's visibility to be set, got %q", m.newThreadVisibility)
+	}
+}
+
+func TestSlash_RegistryIsExtensible(t *testing.T) {
+	saved := slices.Clone(slashCommands)
+	defer func() { slashCommands = saved }()
+
+	registerSlashCommand(slashCommand{name: "echo", args: "<text>", help: "test command", run: func(m Model, arg string) (tea.Model, tea.Cmd) {
+		m.notice = "echo " + arg
+		return m, nil
+	}})
+	m := composerModel(&config.Config{PAT: "test-pat"})
+	m, _ = runSlashLine(m, "/echo hi")
+	if m.notice != "echo hi" {
+		t.Errorf("expected the registered command to run, got notice %q", m.notice)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a command twice to panic")
+		}
+	}()
+	registerSlashCommand(slashCommand{name: "echo"})
+}
+
+func TestSlash_DoubleSlashSendsText(t *testing.T) {
+	m := composerModel(&config.Config{APIKey: "key", DDNURL: "https://ddn.example.com/graphql"})
+	m.selectedProject = nil
+	m = m.setDraft("//etc/hosts is a path")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	if len(m.messages) != 1 || m.messages[0].Content != "/etc/hosts is a path" {
+		t.Errorf("expected the escaped text to be sent, got %+v", m.messages)
+	}
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errCancelled is shown in lists whose loading was cancelled.
+var errCancelled = errors.New("request cancelled; press r to retry")
+
+// requests holds the context of the API requests in flight, so the user can
+// abort them. It is shared by every copy of the model.
+type requests struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// context returns the context for a new request.
+func (r *requests) context() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx == nil {
+		r.ctx, r.cancel = context.WithCancel(context.Background())
+	}
+	return r.ctx
+}
+
+// cancelAll aborts every request in flight. Later requests get a new context.
+func (r *requests) cancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.ctx, r.cancel = nil, nil
+}
+
+// cancelable runs fn in a command with a context that cancelRequest aborts.
+// The result of an aborted request is dropped, so it cannot land in
+// whatever view is shown by then; a stream it opened is closed, as nothing
+// else will read it.
+func (m Model) cancelable(fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx := m.requests.context()
+	return func() tea.Msg {
+		msg := fn(ctx)
+		if ctx.Err() != nil {
+			if s, ok := msg.(queryStreamMsg); ok {
+				s.stream.Close()
+			}
+			return nil
+		}
+		return msg
+	}
+}
+
+// cancelRequest aborts the requests in flight. A message being sent is
+// taken back out of the chat and put back in the input box. A stream is
+// left to waitForChunk to close: cancelling its context ends the read.
+func (m Model) cancelRequest() Model {
+	m.requests.cancelAll()
+	m.loading = false
+	m.refreshing = false
+	m.stream = nil
+	if m.promptQuery != "" {
+		// The prompts /prompt was waiting for will not arrive.
+		m.promptQuery = ""
+		m.notice = ""
+	}
+	if m.pendingText != "" {
+		m.messages = m.messages[:min(m.pendingAt, len(m.messages))]
+		m.conversation.DropPending()
+		m.chatInput.SetValue(m.pendingText)
+		m.pendingText = ""
+	}
+	return m
+}
+
+// canCancel reports whether there is a request the cancel key would abort.
+func (m Model) canCancel() bool {
+	return m.loading || m.refreshing || m.stream != nil
+}
+
+// scope returns the scope of commands dispatched now.
+func (m Model) scope() scope {
+	return scope{gen: m.scopeGen}
+}
+
+// enterScope is called when the active project or thread changes. It
+// aborts the requests made for the previous one and makes Update drop any
+// of their results still on the way.
+func (m Model) enterScope() Model {
+	m.scopeGen++
+	if m.canCancel() || m.pendingText != "" {
+		m = m.cancelRequest()
+	}
+	return m
+}
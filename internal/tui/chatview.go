@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// chatHeight is the number of message lines visible in the chat, which
+// shrinks as the input grows and while the slash command popup replaces
+// the two help lines.
+func (m Model) chatHeight() int {
+	popup := 0
+	if n := len(m.slashSuggestions()); n > 0 {
+		popup = min(n, maxSlashSuggestions) + 1 - 2
+	}
+	return max(m.height-10-m.chatInput.Height()-popup, 5)
+}
+
+// chatLines renders the chat history wrapped to the chat width. starts
+// holds the first line of every message, followed by the line count.
+func (m Model) chatLines() (lines []string, starts []int) {
+	width := m.chatWidth()
+	if m.selecting {
+		width -= 2
+	}
+	for i, msg := range m.messages {
+		starts = append(starts, len(lines))
+		msgLines := m.messageLines(i, msg, width)
+		if m.selecting {
+			marker := "  "
+			if i == m.msgCursor {
+				marker = selectedItemStyle.Render("▌ ")
+			}
+			for j := range msgLines {
+				msgLines[j] = marker + msgLines[j]
+			}
+		}
+		lines = append(lines, msgLines...)
+		lines = append(lines, "")
+	}
+	return lines, append(starts, len(lines))
+}
+
+// messageLines renders one message: user text as is, assistant text as
+// markdown, then previews of its artifacts.
+func (m Model) messageLines(i int, msg ChatMessage, width int) []string {
+	jump := m.jumping && i == m.jumpMessage
+	var lines []string
+	switch msg.Role {
+	case "user":
+		content := msg.Content
+		if jump {
+			content = highlightTerms(content, m.jumpTerms)
+		}
+		lines = strings.Split(ansi.Wrap(userMsgStyle.Render("You: ")+content, width, ""), "\n")
+	case "assistant":
+		if msg.Content != "" || msg.Feedback != 0 {
+			lines = append(lines, assistantMsgStyle.Render("PromptQL:")+feedbackLabel(msg.Feedback))
+		}
+		if msg.Content != "" {
+			streaming := m.stream != nil && i == m.streamMsgIdx
+			body := strings.Split(renderMarkdown(msg.Content, width, !streaming), "\n")
+			if jump {
+				body = highlightLines(body, m.jumpTerms)
+			}
+			lines = append(lines, body...)
+		}
+	}
+	for _, a := range msg.Artifacts {
+		lines = append(lines, strings.Split(renderArtifactPreview(a, width), "\n")...)
+	}
+	return lines
+}
+
+// highlightLines marks the search terms in the rendered lines that contain
+// one, dropping their other styling.
+func highlightLines(lines []string, terms []string) []string {
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		if h := highlightTerms(plain, terms); h != plain {
+			lines[i] = h
+		}
+	}
+	return lines
+}
+
+// chatOffset is the first line shown out of total: the end of the chat
+// unless the user scrolled away from it.
+func (m Model) chatOffset(total int) int {
+	bottom := max(total-m.chatHeight(), 0)
+	if !m.chatScrolled {
+		return bottom
+	}
+	return min(m.chatTop, bottom)
+}
+
+// scrollChat moves the chat by delta lines. Scrolling back to the end
+// follows new messages again.
+func (m Model) scrollChat(delta int) Model {
+	lines, _ := m.chatLines()
+	return m.setChatOffset(m.chatOffset(len(lines))+delta, len(lines))
+}
+
+func (m Model) setChatOffset(offset, total int) Model {
+	bottom := max(total-m.chatHeight(), 0)
+	m.chatTop = max(min(offset, bottom), 0)
+	m.chatScrolled = m.chatTop < bottom
+	return m
+}
+
+// scrollToMessage shows message i at the top of the chat.
+func (m Model) scrollToMessage(i int) Model {
+	_, starts := m.chatLines()
+	if i < 0 || i >= len(starts)-1 {
+		return m
+	}
+	return m.setChatOffset(starts[i], starts[len(starts)-1])
+}
+
+// revealMessage scrolls as little as needed to show message i, or its
+// start if it does not fit.
+func (m Model) revealMessage(i int) Model {
+	lines, starts := m.chatLines()
+	if i < 0 || i >= len(starts)-1 {
+		return m
+	}
+	offset := m.chatOffset(len(lines))
+	start, end := starts[i], starts[i+1]-1 // without the blank line
+	if end > offset+m.chatHeight() {
+		offset = end - m.chatHeight()
+	}
+	offset = min(offset, start)
+	return m.setChatOffset(offset, len(lines))
+}
+
+// updateChatScroll handles the keys and mouse wheel events that scroll the
+// chat history, reporting whether msg was one of them.
+func (m Model) updateChatScroll(msg tea.Msg) (Model, bool) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "pgup":
+			return m.scrollChat(-m.chatHeight() + 1), true
+		case "pgdown":
+			return m.scrollChat(m.chatHeight() - 1), true
+		case "ctrl+home":
+			m.chatTop, m.chatScrolled = 0, true
+			return m.scrollChat(0), true
+		case "ctrl+end":
+			m.chatScrolled = false
+			return m, true
+		}
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			return m, false
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			return m.scrollChat(-3), true
+		case tea.MouseButtonWheelDown:
+			return m.scrollChat(3), true
+		}
+	}
+	return m, false
+}
+
+// viewMessages renders the visible part of the chat history.
+func (m Model) viewMessages() string {
+	lines, _ := m.chatLines()
+	offset := m.chatOffset(len(lines))
+	end := min(offset+m.chatHeight(), len(lines))
+	out := strings.Join(lines[offset:end], "\n")
+	if below := len(lines) - end; below > 0 && m.chatScrolled {
+		out += "\n" + helpStyle.Render("↓ more below  (pgdn, ctrl+end: latest)")
+	}
+	return out
+}
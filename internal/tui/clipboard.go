@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/atotto/clipboard"
+	osc52 "github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// clipboardMsg reports the outcome of a copy to the clipboard.
+type clipboardMsg struct {
+	err error
+}
+
+func init() {
+	registerSlashCommand(slashCommand{name: "copy", help: "copy the last answer to the clipboard", run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if msg := m.messages[i]; msg.Role == "assistant" && msg.Content != "" {
+				return m, copyToClipboard(msg.Content)
+			}
+		}
+		m.err = fmt.Errorf("no answer to copy yet")
+		return m, nil
+	}})
+}
+
+// copyToClipboard copies text to the system clipboard. Where no clipboard
+// utility is available (e.g. over SSH) it falls back to the terminal's
+// OSC 52 escape sequence.
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err == nil {
+			return clipboardMsg{}
+		}
+		_, err := osc52.New(text).WriteTo(os.Stderr)
+		return clipboardMsg{err: err}
+	}
+}
@@ -0,0 +1,293 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/config"
+)
+
+// Bounds of the chat input height, which grows with the draft.
+const (
+	minComposerHeight = 3
+	maxComposerHeight = 10
+)
+
+// composerSavedMsg reports the outcome of saving the composer state.
+type composerSavedMsg struct {
+	err error
+}
+
+// composerSaves orders the writes of composer snapshots. Each save runs in
+// its own command, so a snapshot is skipped if a newer one of the same
+// project was taken meanwhile; that one is written instead. It is shared by
+// every copy of the model.
+type composerSaves struct {
+	mu     sync.Mutex
+	seq    uint64
+	latest map[string]uint64 // newest snapshot by project
+}
+
+// take numbers a new snapshot of the project's composer.
+func (s *composerSaves) take(projectID string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.latest[projectID] = s.seq
+	return s.seq
+}
+
+// save writes snapshot seq unless a newer one was taken.
+func (s *composerSaves) save(seq uint64, c *config.Composer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest[c.ProjectID] != seq {
+		return nil
+	}
+	return c.Save()
+}
+
+// editorDoneMsg is sent when the external editor opened on the draft exits.
+type editorDoneMsg struct {
+	path string
+	err  error
+}
+
+func newChatInput(sendKey string) textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Ask PromptQL a question..."
+	ta.CharLimit = 16384
+	ta.SetHeight(minComposerHeight)
+	ta.ShowLineNumbers = false
+	if sendKey == config.SendKeyEnter {
+		ta.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("alt+enter", "ctrl+j"))
+	}
+	return ta
+}
+
+// isSendKey reports whether key sends the chat message. ctrl+s always does;
+// terminals that tell ctrl+enter apart from enter report it as ctrl+j.
+func (m Model) isSendKey(key string) bool {
+	if m.cfg.SendKey == config.SendKeyEnter {
+		return key == "ctrl+s" || key == "enter"
+	}
+	return key == "ctrl+s" || key == "ctrl+j"
+}
+
+// composerHelp describes the keys of the chat input.
+func (m Model) composerHelp() string {
+	send := "ctrl+enter/ctrl+s: send  |  enter: newline"
+	if m.cfg.SendKey == config.SendKeyEnter {
+		send = "enter: send  |  alt+enter: newline"
+	}
+	return send + "  |  ↑/↓: history  |  ctrl+o: editor  |  /: commands"
+}
+
+// fitChatInput grows the chat input with the lines of the draft.
+func (m Model) fitChatInput() Model {
+	m.chatInput.SetHeight(min(max(m.chatInput.LineCount(), minComposerHeight), maxComposerHeight))
+	return m
+}
+
+// setDraft replaces the text in the chat input.
+func (m Model) setDraft(text string) Model {
+	m.chatInput.SetValue(text)
+	m.chatInput.CursorEnd()
+	return m.fitChatInput()
+}
+
+// composerProject is the project whose composer state the chat uses; empty
+// in direct query mode.
+func (m Model) composerProject() string {
+	if m.selectedProject == nil {
+		return ""
+	}
+	return m.selectedProject.ProjectID
+}
+
+// openComposer restores the draft of the thread being opened, loading the
+// project's composer state first if needed.
+func (m Model) openComposer() (Model, tea.Cmd) {
+	m.recallDepth = 0
+	if m.composer == nil || m.composer.ProjectID != m.composerProject() {
+		m.composer = nil
+		return m.setDraft(""), m.loadComposer(m.composerProject())
+	}
+	return m.setDraft(m.composer.Drafts[m.threadID]), nil
+}
+
+func (m Model) handleComposerLoaded(msg composerLoadedMsg) Model {
+	if msg.err != nil {
+		m.err = fmt.Errorf("loading message history: %w", msg.err)
+		m.composer = &config.Composer{ProjectID: msg.projectID}
+		return m
+	}
+	m.composer = msg.composer
+	if m.chatInput.Value() == "" {
+		m = m.setDraft(m.composer.Drafts[m.threadID])
+	}
+	return m
+}
+
+// stashDraft keeps the unsent text of the chat being left as the draft of
+// its thread, then clears the input.
+func (m Model) stashDraft() (Model, tea.Cmd) {
+	if m.view != viewChat || m.composer == nil {
+		return m, nil
+	}
+	text := m.chatInput.Value()
+	if text == m.composer.Drafts[m.threadID] {
+		return m.setDraft(""), nil
+	}
+	m.composer.SetDraft(m.threadID, text)
+	return m.setDraft(""), m.saveComposer()
+}
+
+// recordSent adds a sent message to the history and drops the draft of the
+// thread it was sent to.
+func (m Model) recordSent(text string) (Model, tea.Cmd) {
+	m.recallDepth = 0
+	if m.composer == nil {
+		return m, nil
+	}
+	m.composer.AddHistory(text)
+	m.composer.SetDraft(m.threadID, "")
+	return m, m.saveComposer()
+}
+
+// recallHistory replaces the input with an older (delta 1) or newer
+// (delta -1) sent message. Going past the newest brings back the text that
+// was being written. It reports whether the key was used.
+func (m Model) recallHistory(delta int) (Model, bool) {
+	if m.composer == nil {
+		return m, false
+	}
+	depth := m.recallDepth + delta
+	switch {
+	case depth < 0:
+		return m, false
+	case depth > len(m.composer.History):
+		return m, true
+	}
+	if m.recallDepth == 0 {
+		m.recallDraft = m.chatInput.Value()
+	}
+	m.recallDepth = depth
+	if depth == 0 {
+		return m.setDraft(m.recallDraft), true
+	}
+	return m.setDraft(m.composer.History[len(m.composer.History)-depth]), true
+}
+
+// updateComposer handles a key in the chat input. Up on the first line and
+// down on the last one go through the message history.
+func (m Model) updateComposer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up":
+		if m.chatInput.Line() == 0 {
+			if recalled, ok := m.recallHistory(1); ok {
+				return recalled, nil
+			}
+		}
+	case "down":
+		if m.chatInput.Line() == m.chatInput.LineCount()-1 {
+			if recalled, ok := m.recallHistory(-1); ok {
+				return recalled, nil
+			}
+		}
+	case "ctrl+o":
+		return m.openEditor()
+	}
+
+	before := m.chatInput.Value()
+	var cmd tea.Cmd
+	m.chatInput, cmd = m.chatInput.Update(msg)
+	if m.chatInput.Value() != before {
+		// An edited message is a new draft.
+		m.recallDepth = 0
+		m.slashCursor = 0
+	}
+	return m.fitChatInput(), cmd
+}
+
+// editorCommand returns the user's editor: $VISUAL, then $EDITOR, then vi.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
+
+// openEditor suspends the TUI to edit the draft in the user's editor.
+func (m Model) openEditor() (tea.Model, tea.Cmd) {
+	f, err := os.CreateTemp("", "promptql-*.md")
+	if err != nil {
+		m.err = fmt.Errorf("opening editor: %w", err)
+		return m, nil
+	}
+	path := f.Name()
+	_, err = f.WriteString(m.chatInput.Value())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.err = fmt.Errorf("opening editor: %w", err)
+		return m, nil
+	}
+
+	args := editorCommand()
+	c := exec.Command(args[0], append(args[1:], path)...)
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorDoneMsg{path: path, err: err}
+	})
+}
+
+// handleEditorDone puts the edited draft back in the input.
+func (m Model) handleEditorDone(msg editorDoneMsg) Model {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		m.err = fmt.Errorf("editor failed: %w", msg.err)
+		return m
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.err = fmt.Errorf("reading edited message: %w", err)
+		return m
+	}
+	m.err = nil
+	m.recallDepth = 0
+	m.chatInput.Focus()
+	return m.setDraft(strings.TrimRight(string(data), "\n"))
+}
+
+// --- Commands ---
+
+func (m Model) loadComposer(projectID string) tea.Cmd {
+	return func() tea.Msg {
+		c, err := config.LoadComposer(projectID)
+		return composerLoadedMsg{scope: m.scope(), projectID: projectID, composer: c, err: err}
+	}
+}
+
+// saveComposer writes a copy of the composer state, so the model can keep
+// changing it meanwhile.
+func (m Model) saveComposer() tea.Cmd {
+	c := *m.composer
+	c.History = slices.Clone(c.History)
+	c.Drafts = maps.Clone(c.Drafts)
+	seq := m.composerSaves.take(c.ProjectID)
+	return func() tea.Msg {
+		return composerSavedMsg{err: m.composerSaves.save(seq, &c)}
+	}
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// exportDoneMsg reports the outcome of writing an export file.
+type exportDoneMsg struct {
+	path string
+	err  error
+}
+
+func init() {
+	registerSlashCommand(slashCommand{
+		name: "export",
+		args: "[path]",
+		help: "export the latest artifact (.csv/.json) or the conversation (.md)",
+		run: func(m Model, path string) (tea.Model, tea.Cmd) {
+			if path == "" {
+				return m.openExport(), nil
+			}
+			cmd, err := m.exportCmd(path)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			return m, cmd
+		},
+	})
+}
+
+func newExportInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "export.csv, export.json or transcript.md"
+	ti.CharLimit = 512
+	ti.Width = 60
+	return ti
+}
+
+// openExport shows the export path prompt, suggesting a file name for the
+// artifact that would be exported.
+func (m Model) openExport() Model {
+	m.exporting = true
+	m.notice = ""
+	m.exportInput.Reset()
+	if a, ok := m.exportArtifact(); ok && a.Identifier != "" {
+		m.exportInput.SetValue(a.Identifier + ".csv")
+	} else {
+		m.exportInput.SetValue("transcript.md")
+	}
+	m.exportInput.CursorEnd()
+	m.exportInput.Focus()
+	m.chatInput.Blur()
+	return m
+}
+
+func (m Model) closeExport() Model {
+	m.exporting = false
+	m.exportInput.Blur()
+	if m.tableFocus == nil {
+		m.chatInput.Focus()
+	}
+	return m
+}
+
+func (m Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.exportInput, cmd = m.exportInput.Update(msg)
+		return m, cmd
+	}
+
+	path := strings.TrimSpace(m.exportInput.Value())
+	if path == "" {
+		return m, nil
+	}
+	cmd, err := m.exportCmd(path)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.err = nil
+	return m.closeExport(), cmd
+}
+
+// exportArtifact returns the artifact to export: the focused table, or else
+// the most recent artifact in the chat.
+func (m Model) exportArtifact() (sdk.Artifact, bool) {
+	if m.tableFocus != nil {
+		if tables := m.tableArtifacts(); m.tableIdx < len(tables) {
+			return tables[m.tableIdx], true
+		}
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if n := len(m.messages[i].Artifacts); n > 0 {
+			return m.messages[i].Artifacts[n-1], true
+		}
+	}
+	return sdk.Artifact{}, false
+}
+
+// exportCmd writes the selected artifact (CSV/JSON) or the conversation
+// transcript (Markdown) to path, choosing the format from its extension.
+func (m Model) exportCmd(path string) (tea.Cmd, error) {
+	format, err := sdk.ExportFormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+	path = expandHome(path)
+
+	if format == sdk.ExportMarkdown {
+		switch {
+		case m.threadID != "" && m.client != nil:
+			client, threadID := m.client, m.threadID
+			return writeExport(path, func(buf *bytes.Buffer) error {
+				return client.Threads().ExportTranscript(threadID, buf)
+			}), nil
+		case m.conversation != nil && len(m.conversation.Interactions) > 0:
+			events := m.conversation.Events()
+			return writeExport(path, func(buf *bytes.Buffer) error {
+				return sdk.WriteTranscript(buf, "", events)
+			}), nil
+		}
+		return nil, fmt.Errorf("nothing to export yet")
+	}
+
+	a, ok := m.exportArtifact()
+	if !ok {
+		return nil, fmt.Errorf("no artifact to export")
+	}
+	return writeExport(path, func(buf *bytes.Buffer) error {
+		return sdk.WriteArtifact(buf, a, format)
+	}), nil
+}
+
+// writeExport renders the export fully before creating the file, so a
+// failure never leaves a partial file behind.
+func writeExport(path string, render func(*bytes.Buffer) error) tea.Cmd {
+	return func() tea.Msg {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			return exportDoneMsg{path: path, err: err}
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return exportDoneMsg{path: path, err: err}
+			}
+		}
+		err := os.WriteFile(path, buf.Bytes(), 0o644)
+		return exportDoneMsg{path: path, err: err}
+	}
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// feedbackSentMsg reports the outcome of submitting feedback on a message.
+type feedbackSentMsg struct {
+	threadID  string
+	messageID string
+	value     int
+	err       error
+}
+
+func init() {
+	registerSlashCommand(slashCommand{name: "feedback", help: "select a message to rate", run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		if len(m.messages) == 0 {
+			m.err = fmt.Errorf("no messages to rate yet")
+			return m, nil
+		}
+		return m.openSelection(), nil
+	}})
+}
+
+func newFeedbackInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "what was good or wrong? (optional)"
+	ti.CharLimit = 1024
+	ti.Width = 60
+	return ti
+}
+
+// openSelection moves the cursor from the message input to the latest
+// message in the chat history.
+func (m Model) openSelection() Model {
+	if len(m.messages) == 0 {
+		return m
+	}
+	m.selecting = true
+	m.msgCursor = len(m.messages) - 1
+	m.notice = ""
+	m.chatInput.Blur()
+	return m.revealMessage(m.msgCursor)
+}
+
+func (m Model) closeSelection() Model {
+	m.selecting = false
+	m.feedbackValue = 0
+	m.feedbackInput.Blur()
+	m.chatInput.Focus()
+	return m
+}
+
+func (m Model) updateSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.feedbackValue != 0 {
+		return m.updateFeedbackDetails(msg)
+	}
+	switch msg.String() {
+	case "k", "up":
+		m.msgCursor = max(m.msgCursor-1, 0)
+	case "j", "down":
+		m.msgCursor = min(m.msgCursor+1, len(m.messages)-1)
+	case "home", "g":
+		m.msgCursor = 0
+	case "end", "G":
+		m.msgCursor = len(m.messages) - 1
+	case "+":
+		return m.startFeedback(sdk.FeedbackPositive)
+	case "-":
+		return m.startFeedback(sdk.FeedbackNegative)
+	case "q":
+		return m.closeSelection(), nil
+	default:
+		return m, nil
+	}
+	return m.revealMessage(m.msgCursor), nil
+}
+
+// startFeedback asks for optional details on a rating of the selected
+// message.
+func (m Model) startFeedback(value int) (tea.Model, tea.Cmd) {
+	msg := m.messages[m.msgCursor]
+	switch {
+	case m.threadID == "" || msg.MessageID == "" || msg.Role != "assistant":
+		m.err = fmt.Errorf("feedback can only be given on PromptQL messages in a thread")
+		return m, nil
+	case m.offline:
+		m.err = errOffline
+		return m, nil
+	}
+	m.err = nil
+	m.feedbackValue = value
+	m.feedbackInput.Reset()
+	return m, m.feedbackInput.Focus()
+}
+
+func (m Model) updateFeedbackDetails(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.feedbackInput, cmd = m.feedbackInput.Update(msg)
+		return m, cmd
+	}
+	cmd := m.submitFeedback(m.messages[m.msgCursor].MessageID, m.feedbackValue, m.feedbackInput.Value())
+	m.feedbackValue = 0
+	m.feedbackInput.Blur()
+	m.notice = "Sending feedback..."
+	return m, cmd
+}
+
+// cancelFeedback drops the rating being given, staying in selection mode.
+func (m Model) cancelFeedback() Model {
+	m.feedbackValue = 0
+	m.feedbackInput.Blur()
+	return m
+}
+
+func (m Model) handleFeedbackSent(msg feedbackSentMsg) Model {
+	if msg.err != nil {
+		m.notice = ""
+		m.err = fmt.Errorf("feedback failed: %w", msg.err)
+		return m
+	}
+	if msg.threadID != m.threadID {
+		return m
+	}
+	for i := range m.messages {
+		if m.messages[i].MessageID == msg.messageID {
+			m.messages[i].Feedback = msg.value
+		}
+	}
+	m.notice = "Feedback sent, thanks!"
+	return m
+}
+
+func (m Model) viewFeedbackPrompt() string {
+	label := "👍 Details: "
+	if m.feedbackValue == sdk.FeedbackNegative {
+		label = "👎 Details: "
+	}
+	return promptStyle.Render(label) + m.feedbackInput.View() + "\n" +
+		helpStyle.Render("enter: submit  |  esc: cancel")
+}
+
+// feedbackLabel marks a message with the feedback given on it.
+func feedbackLabel(value int) string {
+	switch value {
+	case sdk.FeedbackPositive:
+		return "  " + helpStyle.Render("👍 rated helpful")
+	case sdk.FeedbackNegative:
+		return "  " + helpStyle.Render("👎 rated unhelpful")
+	}
+	return ""
+}
+
+// --- Commands ---
+
+func (m Model) submitFeedback(messageID string, value int, details string) tea.Cmd {
+	client, threadID := m.client, m.threadID
+	return func() tea.Msg {
+		_, err := client.Threads().SubmitFeedback(threadID, messageID, value, details)
+		return feedbackSentMsg{threadID: threadID, messageID: messageID, value: value, err: err}
+	}
+}
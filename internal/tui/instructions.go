@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/diff"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// historyListSize is how many versions the history list shows at once.
+const historyListSize = 8
+
+func newInstructionsInput() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "System instructions for PromptQL..."
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = true
+	return ta
+}
+
+// editInstructions opens the system instructions editor with the current
+// instructions.
+func (m Model) editInstructions() (tea.Model, tea.Cmd) {
+	if m.playgroundConfig == nil {
+		return m, nil
+	}
+	m.instructionsInput.SetWidth(max(m.width-4, 40))
+	m.instructionsInput.SetHeight(max(m.height-12, 5))
+	m.instructionsInput.SetValue(m.playgroundConfig.SystemInstructions)
+	m.adminMode = adminInstructions
+	m.adminScroll = 0
+	m.notice = ""
+	m.err = nil
+	return m, m.instructionsInput.Focus()
+}
+
+func (m Model) updateInstructionsEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+s" {
+		v := m.instructionsInput.Value()
+		if v == m.playgroundConfig.SystemInstructions {
+			m.notice = "No changes to save"
+			return m, nil
+		}
+		m.instructionsInput.Blur()
+		return m.previewUpdate(sdk.PlaygroundConfigUpdate{SystemInstructions: &v}), nil
+	}
+	var cmd tea.Cmd
+	m.instructionsInput, cmd = m.instructionsInput.Update(msg)
+	return m, cmd
+}
+
+// openInstructionHistory shows the local history of the system instructions.
+func (m Model) openInstructionHistory() (tea.Model, tea.Cmd) {
+	m.adminMode = adminHistory
+	m.historyCursor = 0
+	m.historyAgainstLive = false
+	m.adminScroll = 0
+	m.notice = ""
+	m.err = nil
+	return m, nil
+}
+
+// selectedVersion returns the index into the history of the version under
+// the cursor; the list shows the newest version first.
+func (m Model) selectedVersion() int {
+	if m.instructionHistory == nil {
+		return -1
+	}
+	return len(m.instructionHistory.Versions) - 1 - m.historyCursor
+}
+
+func (m Model) viewInstructionHistory() string {
+	var b strings.Builder
+	h := m.instructionHistory
+	if h == nil || len(h.Versions) == 0 {
+		b.WriteString(helpStyle.Render("No versions recorded yet. Versions are recorded each time the system instructions are saved from this app."))
+		return b.String()
+	}
+
+	live := ""
+	if m.playgroundConfig != nil {
+		live = m.playgroundConfig.SystemInstructions
+	}
+	start := max(min(m.historyCursor-historyListSize/2, len(h.Versions)-historyListSize), 0)
+	end := min(start+historyListSize, len(h.Versions))
+	for pos := start; pos < end; pos++ {
+		i := len(h.Versions) - 1 - pos
+		v := h.Versions[i]
+		line := fmt.Sprintf("v%-3d %s  %s", i+1, v.SavedAt.Local().Format("2006-01-02 15:04"), truncate(firstLine(strings.TrimSpace(v.Instructions)), 50))
+		if v.RestoredFrom > 0 {
+			line += fmt.Sprintf("  (rollback to v%d)", v.RestoredFrom)
+		} else if v.Note != "" {
+			line += "  (" + v.Note + ")"
+		}
+		if v.Instructions == live {
+			line += "  [live]"
+		}
+		if pos == m.historyCursor {
+			b.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			b.WriteString(normalItemStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	if len(h.Versions) > historyListSize {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %d versions", len(h.Versions))) + "\n")
+	}
+
+	i := m.selectedVersion()
+	fromName, from := "empty", ""
+	if m.historyAgainstLive {
+		fromName, from = "live", live
+	} else if i > 0 {
+		fromName, from = fmt.Sprintf("v%d", i), h.Versions[i-1].Instructions
+	}
+	toName := fmt.Sprintf("v%d", i+1)
+	b.WriteString("\n" + subtitleStyle.Render(fmt.Sprintf("Changes from %s to %s", fromName, toName)) + "\n")
+	if d := diff.Unified(fromName, toName, from, h.Versions[i].Instructions, 3); d != "" {
+		b.WriteString(renderDiff(d))
+	} else {
+		b.WriteString(helpStyle.Render("(identical)") + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) updateInstructionHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := 0
+	if m.instructionHistory != nil {
+		count = len(m.instructionHistory.Versions)
+	}
+	switch msg.String() {
+	case "j", "down":
+		if m.historyCursor < count-1 {
+			m.historyCursor++
+			m.adminScroll = 0
+		}
+	case "k", "up":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+			m.adminScroll = 0
+		}
+	case "tab":
+		m.historyAgainstLive = !m.historyAgainstLive
+		m.adminScroll = 0
+	case "r":
+		if count == 0 {
+			return m, nil
+		}
+		if m.instructionHistory.Versions[m.selectedVersion()].Instructions == m.playgroundConfig.SystemInstructions {
+			m.notice = fmt.Sprintf("Version %d is already live", m.selectedVersion()+1)
+			return m, nil
+		}
+		m.adminMode = adminConfirmRollback
+		m.notice = ""
+	default:
+		return m.scrollAdmin(msg), nil
+	}
+	return m, nil
+}
+
+// rollback pushes the selected version back to the server. The rollback is
+// recorded as a new version that names the one it restored, so the history
+// is never rewritten.
+func (m Model) rollback() (tea.Model, tea.Cmd) {
+	i := m.selectedVersion()
+	m.adminMode = adminHistory
+	m.loading = true
+	m.err = nil
+	return m, tea.Batch(m.spinner.Tick, m.saveInstructions(m.instructionHistory.Versions[i].Instructions, i+1))
+}
+
+// recordInstructions adds a saved version of a project's system
+// instructions to its local history; restoredFrom is the version a rollback
+// restored, or 0. If the instructions that were replaced are not the latest
+// recorded version (the history is new, or they were changed elsewhere)
+// they are recorded first so they can be restored.
+func recordInstructions(projectID, before, after string, restoredFrom int) (*config.InstructionHistory, error) {
+	h, err := config.LoadInstructionHistory(projectID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if latest := h.Latest(); latest == nil || latest.Instructions != before {
+		h.Record(config.InstructionVersion{SavedAt: now, Instructions: before, Note: "previous server version"})
+	}
+	h.Record(config.InstructionVersion{SavedAt: now, Instructions: after, RestoredFrom: restoredFrom})
+	return h, h.Save()
+}
+
+// --- Commands ---
+
+func (m Model) saveInstructions(instructions string, restoredFrom int) tea.Cmd {
+	projectID := m.selectedProject.ProjectID
+	before := m.playgroundConfig.SystemInstructions
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		cfg, err := m.client.Projects().UpdateSystemInstructionsContext(ctx, projectID, instructions)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		history, err := recordInstructions(projectID, before, cfg.SystemInstructions, restoredFrom)
+		return playgroundSavedMsg{scope: m.scope(), config: cfg, history: history, historyErr: err}
+	})
+}
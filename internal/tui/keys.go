@@ -0,0 +1,407 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/connect"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// keyMode is the state of the API keys view.
+type keyMode int
+
+const (
+	keyList keyMode = iota
+	keyGenerate
+	keyConfirmRevoke
+)
+
+// Generate form fields.
+const (
+	keyFieldName = iota
+	keyFieldPromptQLTimeout
+	keyFieldSQLTimeout
+	keyFieldCount
+)
+
+func newKeyInputs() []textinput.Model {
+	inputs := make([]textinput.Model, keyFieldCount)
+
+	name := textinput.New()
+	name.Placeholder = "e.g. ci-pipeline"
+	name.CharLimit = 128
+	inputs[keyFieldName] = name
+
+	for _, f := range []int{keyFieldPromptQLTimeout, keyFieldSQLTimeout} {
+		ti := textinput.New()
+		ti.Placeholder = "seconds (optional)"
+		ti.CharLimit = 6
+		ti.Validate = func(s string) error {
+			if _, err := strconv.Atoi(s); s != "" && err != nil {
+				return fmt.Errorf("timeout must be a whole number of seconds")
+			}
+			return nil
+		}
+		inputs[f] = ti
+	}
+	return inputs
+}
+
+// openKeys switches to the API keys view and loads the project's keys.
+func (m Model) openKeys() (tea.Model, tea.Cmd) {
+	if m.client == nil || m.selectedProject == nil {
+		m.err = fmt.Errorf("API keys need a PAT and a selected project")
+		return m, nil
+	}
+	m.view = viewKeys
+	m.keyMode = keyList
+	m.keyCursor = 0
+	m.notice = ""
+	m.loading = true
+	m.err = nil
+	return m, tea.Batch(m.spinner.Tick, m.loadAPIKeys())
+}
+
+func (m Model) viewKeys() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("API Keys"))
+	if m.selectedProject != nil {
+		b.WriteString("  " + subtitleStyle.Render(m.selectedProject.Name))
+	}
+	b.WriteString("\n\n")
+
+	if m.keyMode == keyGenerate {
+		return b.String() + m.viewKeyForm()
+	}
+
+	if m.loading {
+		b.WriteString(m.spinner.View() + " Loading API keys...  " + helpStyle.Render("ctrl+x: cancel"))
+		return b.String()
+	}
+
+	if len(m.apiKeys) == 0 {
+		b.WriteString(helpStyle.Render("  No API keys yet. Press g to generate one."))
+		b.WriteString("\n")
+	} else {
+		header := fmt.Sprintf("  %-20s  %-16s  %-8s  %-19s  %s", "NAME", "KEY", "ACTIVE", "LAST USED", "TIMEOUTS (PQL/SQL)")
+		b.WriteString(helpStyle.Render(header) + "\n")
+	}
+	for i, k := range m.apiKeys {
+		cursor := "  "
+		style := normalItemStyle
+		if i == m.keyCursor {
+			cursor = "> "
+			style = selectedItemStyle
+		}
+		active := "yes"
+		if k.IsActive != nil && !*k.IsActive {
+			active = "revoked"
+		}
+		lastUsed := k.LastUsedAt
+		if lastUsed == "" {
+			lastUsed = "never"
+		}
+		line := fmt.Sprintf("%s%-20s  %-16s  %-8s  %-19s  %s/%s", cursor,
+			truncate(k.Name, 20), truncate(k.APIKeyMasked, 16), active,
+			lastUsed[:min(19, len(lastUsed))], formatTimeout(k.PromptQLTimeout), formatTimeout(k.SQLTimeout))
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	} else if m.notice != "" {
+		b.WriteString(successStyle.Render(m.notice) + "\n")
+	}
+	if m.keyMode == keyConfirmRevoke {
+		if k, ok := m.selectedAPIKey(); ok {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("Revoke %q? Clients using it will stop working. y: revoke  |  n: cancel", k.Name)))
+		}
+		return b.String()
+	}
+	b.WriteString(helpStyle.Render("↑/↓: navigate  |  g: generate  |  d: revoke  |  r: refresh  |  esc: back"))
+	return b.String()
+}
+
+func (m Model) viewKeyForm() string {
+	var b strings.Builder
+	labels := []string{"Name", "PromptQL timeout", "SQL timeout"}
+	for i, label := range labels {
+		if i == m.keyFieldCursor {
+			b.WriteString(promptStyle.Render("> " + label))
+		} else {
+			b.WriteString(helpStyle.Render("  " + label))
+		}
+		b.WriteString("\n  " + m.keyInputs[i].View() + "\n\n")
+	}
+	if m.loading {
+		b.WriteString(m.spinner.View() + " Generating...  " + helpStyle.Render("ctrl+x: cancel") + "\n")
+	} else if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("tab: next field  |  enter: generate  |  esc: cancel"))
+	return b.String()
+}
+
+// viewKeyReveal shows a generated key. It is drawn over whatever view is
+// open, as the key cannot be shown again later.
+func (m Model) viewKeyReveal() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("API Keys"))
+	b.WriteString("\n\n")
+	b.WriteString(successStyle.Render(fmt.Sprintf("Generated API key %q", m.revealedKey.Name)))
+	b.WriteString("\n\n")
+	b.WriteString(boxStyle.Render(m.revealedKey.APIKey))
+	b.WriteString("\n\n")
+	b.WriteString(errorStyle.Render("This key will not be shown again. Copy or save it now."))
+	b.WriteString("\n\n")
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
+	} else if m.notice != "" {
+		b.WriteString(successStyle.Render(m.notice) + "\n\n")
+	}
+	b.WriteString(helpStyle.Render("c: copy to clipboard  |  s: save as my API key  |  enter/esc: done"))
+	return b.String()
+}
+
+func (m Model) updateKeys(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case apiKeysLoadedMsg:
+		m.apiKeys = msg.keys
+		m.loading = false
+		m.err = nil
+		m.keyCursor = min(m.keyCursor, max(len(m.apiKeys)-1, 0))
+		return m, nil
+
+	case apiKeyRemovedMsg:
+		m.notice = "API key revoked"
+		return m, m.loadAPIKeys()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			if m.keyMode == keyList {
+				m.err = errCancelled
+			}
+			return m, nil
+		}
+		if m.loading {
+			return m, nil
+		}
+		switch m.keyMode {
+		case keyGenerate:
+			return m.updateKeyForm(msg)
+		case keyConfirmRevoke:
+			switch msg.String() {
+			case "y":
+				m.keyMode = keyList
+				if k, ok := m.selectedAPIKey(); ok {
+					m.loading = true
+					return m, tea.Batch(m.spinner.Tick, m.removeAPIKey(k.ID))
+				}
+			case "n":
+				m.keyMode = keyList
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "j", "down":
+			if m.keyCursor < len(m.apiKeys)-1 {
+				m.keyCursor++
+			}
+		case "k", "up":
+			if m.keyCursor > 0 {
+				m.keyCursor--
+			}
+		case "g":
+			return m.openKeyForm()
+		case "d":
+			if _, ok := m.selectedAPIKey(); ok {
+				m.keyMode = keyConfirmRevoke
+				m.notice = ""
+			}
+		case "r":
+			m.loading = true
+			m.err = nil
+			return m, tea.Batch(m.spinner.Tick, m.loadAPIKeys())
+		}
+	}
+	return m, nil
+}
+
+func (m Model) openKeyForm() (tea.Model, tea.Cmd) {
+	m.keyMode = keyGenerate
+	m.err = nil
+	m.notice = ""
+	for i := range m.keyInputs {
+		m.keyInputs[i].Reset()
+		m.keyInputs[i].Blur()
+	}
+	m.keyFieldCursor = keyFieldName
+	return m, m.keyInputs[keyFieldName].Focus()
+}
+
+func (m Model) updateKeyForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "down", "shift+tab", "up":
+		step := 1
+		if msg.String() == "shift+tab" || msg.String() == "up" {
+			step = keyFieldCount - 1
+		}
+		m.keyInputs[m.keyFieldCursor].Blur()
+		m.keyFieldCursor = (m.keyFieldCursor + step) % keyFieldCount
+		return m, m.keyInputs[m.keyFieldCursor].Focus()
+	case "enter":
+		opts, err := m.keyFormOptions()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.loading = true
+		m.err = nil
+		return m, tea.Batch(m.spinner.Tick, m.generateAPIKey(opts))
+	}
+
+	var cmd tea.Cmd
+	m.keyInputs[m.keyFieldCursor], cmd = m.keyInputs[m.keyFieldCursor].Update(msg)
+	return m, cmd
+}
+
+// keyFormOptions validates the generate form.
+func (m Model) keyFormOptions() (sdk.GenerateOptions, error) {
+	opts := sdk.GenerateOptions{
+		ProjectID: m.selectedProject.ProjectID,
+		Name:      strings.TrimSpace(m.keyInputs[keyFieldName].Value()),
+	}
+	if opts.Name == "" {
+		return opts, fmt.Errorf("name is required")
+	}
+	timeouts := []struct {
+		field int
+		label string
+		dst   **int
+	}{
+		{keyFieldPromptQLTimeout, "PromptQL timeout", &opts.PromptQLTimeout},
+		{keyFieldSQLTimeout, "SQL timeout", &opts.SQLTimeout},
+	}
+	for _, t := range timeouts {
+		value := strings.TrimSpace(m.keyInputs[t.field].Value())
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("%s must be a positive number of seconds", t.label)
+		}
+		*t.dst = &n
+	}
+	return opts, nil
+}
+
+// handleAPIKeyGenerated reveals a generated key, whatever the view: it
+// cannot be seen again later.
+func (m Model) handleAPIKeyGenerated(msg apiKeyGeneratedMsg) Model {
+	m.err = nil
+	m.notice = ""
+	m.revealedKey = msg.key
+	if m.view == viewKeys && m.selectedProject != nil && m.selectedProject.ProjectID == msg.projectID {
+		m.loading = false
+		m.keyMode = keyList
+		m.apiKeys = append(m.apiKeys, msg.key.RuntimeAPIKey)
+		m.keyCursor = len(m.apiKeys) - 1
+	}
+	return m
+}
+
+// updateKeyReveal handles the keys of the generated key screen.
+func (m Model) updateKeyReveal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "c":
+		return m, copyToClipboard(m.revealedKey.APIKey)
+	case "s":
+		return m.saveRevealedKey()
+	case "enter", "esc":
+		return m.closeKeyReveal(), nil
+	}
+	return m, nil
+}
+
+// closeKeyReveal leaves the reveal screen, discarding the plaintext key.
+func (m Model) closeKeyReveal() Model {
+	m.revealedKey = nil
+	m.notice = ""
+	m.err = nil
+	return m
+}
+
+// saveRevealedKey stores the revealed key as the configured API key.
+func (m Model) saveRevealedKey() (tea.Model, tea.Cmd) {
+	m.cfg.APIKey = m.revealedKey.APIKey
+	m.client = connect.NewClient(m.cfg)
+	return m, m.saveConfig(func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return apiKeySavedMsg{m.scope()}
+	})
+}
+
+func (m Model) selectedAPIKey() (sdk.RuntimeAPIKey, bool) {
+	if m.keyCursor < 0 || m.keyCursor >= len(m.apiKeys) {
+		return sdk.RuntimeAPIKey{}, false
+	}
+	return m.apiKeys[m.keyCursor], true
+}
+
+func formatTimeout(seconds *int) string {
+	if seconds == nil {
+		return "default"
+	}
+	return fmt.Sprintf("%ds", *seconds)
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
+// --- Commands ---
+
+func (m Model) loadAPIKeys() tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		keys, err := m.client.APIKeys().ListContext(ctx, m.selectedProject.ProjectID)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return apiKeysLoadedMsg{scope: m.scope(), keys: keys}
+	})
+}
+
+func (m Model) generateAPIKey(opts sdk.GenerateOptions) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		key, err := m.client.APIKeys().GenerateContext(ctx, opts)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return apiKeyGeneratedMsg{projectID: m.selectedProject.ProjectID, key: key}
+	})
+}
+
+func (m Model) removeAPIKey(id int) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		if _, err := m.client.APIKeys().RemoveContext(ctx, m.selectedProject.ProjectID, id); err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return apiKeyRemovedMsg{m.scope()}
+	})
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// markdownCacheSize bounds the number of rendered messages kept. The chat
+// is re-rendered on every frame, so rendering must not be repeated; the
+// least recently shown messages are dropped first.
+const markdownCacheSize = 512
+
+type markdownKey struct {
+	text  string
+	width int
+}
+
+type markdownEntry struct {
+	key markdownKey
+	out string
+}
+
+var markdown = struct {
+	sync.Mutex
+	renderers map[int]*glamour.TermRenderer
+	rendered  map[markdownKey]*list.Element
+	recent    *list.List // of *markdownEntry, most recently shown first
+}{
+	renderers: map[int]*glamour.TermRenderer{},
+	rendered:  map[markdownKey]*list.Element{},
+	recent:    list.New(),
+}
+
+// renderMarkdown renders assistant text as markdown wrapped to width, with
+// syntax highlighting in fenced code blocks. Text that fails to render is
+// shown wrapped as is. The result is cached unless the text is still
+// changing, as a streaming answer is with every chunk.
+func renderMarkdown(text string, width int, cache bool) string {
+	key := markdownKey{text, width}
+	markdown.Lock()
+	defer markdown.Unlock()
+	if e, ok := markdown.rendered[key]; ok {
+		markdown.recent.MoveToFront(e)
+		return e.Value.(*markdownEntry).out
+	}
+
+	out := ansi.Wrap(text, width, "")
+	r, ok := markdown.renderers[width]
+	if !ok {
+		var err error
+		r, err = glamour.NewTermRenderer(
+			glamour.WithStandardStyle(markdownStyle()),
+			glamour.WithColorProfile(lipgloss.ColorProfile()),
+			glamour.WithWordWrap(width),
+		)
+		if err != nil {
+			r = nil
+		}
+		markdown.renderers[width] = r
+	}
+	if r != nil {
+		if s, err := r.Render(text); err == nil {
+			out = trimRenderedMarkdown(s)
+		}
+	}
+
+	if !cache {
+		return out
+	}
+	markdown.rendered[key] = markdown.recent.PushFront(&markdownEntry{key, out})
+	if markdown.recent.Len() > markdownCacheSize {
+		oldest := markdown.recent.Back()
+		markdown.recent.Remove(oldest)
+		delete(markdown.rendered, oldest.Value.(*markdownEntry).key)
+	}
+	return out
+}
+
+// markdownStyle is the glamour style that suits the terminal's background.
+func markdownStyle() string {
+	if lipgloss.HasDarkBackground() {
+		return styles.DarkStyle
+	}
+	return styles.LightStyle
+}
+
+// trimRenderedMarkdown drops the blank lines glamour puts around a document
+// and the padding at the end of each line.
+func trimRenderedMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
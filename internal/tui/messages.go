@@ -1,43 +1,169 @@
 package tui
 
-import "github.com/sandalsoft/promptql-tui/internal/sdk"
+import (
+	"time"
+
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+	"github.com/sandalsoft/promptql-tui/internal/search"
+)
 
 // Message types for the TUI event loop.
 
-type errMsg struct{ err error }
+// scope ties the result of a command to the project and thread that were
+// active when it was dispatched. Command results embed the scope of their
+// command, and Update drops messages from an earlier scope, such as the
+// events of a thread the user has since left.
+type scope struct {
+	gen int // Model.scopeGen at dispatch
+}
+
+func (s scope) msgScope() scope { return s }
+
+// scopedMsg is implemented by the messages that embed a scope.
+type scopedMsg interface {
+	msgScope() scope
+}
+
+type errMsg struct {
+	scope
+	err error
+}
 
 func (e errMsg) Error() string { return e.err.Error() }
 
 type projectsLoadedMsg struct {
+	scope
 	projects []sdk.UserProject
+	cachedAt time.Time // set when read from the local cache
 }
 
 type projectSelectedMsg struct {
+	scope
 	project sdk.UserProject
 }
 
 type threadsLoadedMsg struct {
-	threads []sdk.Thread
+	scope
+	threads  []sdk.Thread
+	cachedAt time.Time // set when read from the local cache
 }
 
 type threadStartedMsg struct {
+	scope
 	result *sdk.StartThreadResult
 }
 
 type messageSentMsg struct {
+	scope
 	result *sdk.SendMessageResult
 }
 
 type eventsLoadedMsg struct {
-	events []sdk.ThreadEvent
+	scope
+	events   []sdk.ThreadEvent
+	cachedAt time.Time // set when read from the local cache
 }
 
-type queryResultMsg struct {
-	result map[string]interface{}
+// queryStreamMsg is sent once a streaming query has been opened.
+type queryStreamMsg struct {
+	scope
+	stream *sdk.QueryStream
 }
 
-type configSavedMsg struct{}
+// queryChunkMsg carries a single chunk read from an open query stream.
+type queryChunkMsg struct {
+	scope
+	stream *sdk.QueryStream
+	chunk  sdk.QueryChunk
+}
+
+// queryStreamDoneMsg is sent when a query stream ends, with err set if it
+// ended abnormally.
+type queryStreamDoneMsg struct {
+	scope
+	stream *sdk.QueryStream
+	err    error
+}
+
+type promptsLoadedMsg struct {
+	scope
+	prompts []sdk.SamplePrompt
+}
+
+type promptSavedMsg struct {
+	scope
+	prompt *sdk.SamplePrompt
+}
+
+type promptDeletedMsg struct {
+	scope
+	id string
+}
+
+type apiKeysLoadedMsg struct {
+	scope
+	keys []sdk.RuntimeAPIKey
+}
+
+// apiKeyGeneratedMsg carries a new API key. Its plaintext can only be seen
+// now, so it is not scoped: the key is shown even if the user has left the
+// keys view or the project meanwhile.
+type apiKeyGeneratedMsg struct {
+	projectID string
+	key       *sdk.GeneratedAPIKey
+}
+
+type apiKeyRemovedMsg struct{ scope }
+
+// apiKeySavedMsg is sent once a generated key has been saved to the config.
+type apiKeySavedMsg struct{ scope }
+
+type adminConfigLoadedMsg struct {
+	scope
+	promptql   *sdk.PromptQLConfig
+	playground *sdk.PlaygroundConfig
+	history    *config.InstructionHistory
+}
+
+type promptqlToggledMsg struct {
+	scope
+	message string
+}
+
+// playgroundSavedMsg is sent once playground settings are saved. history is
+// set when the system instructions changed and were recorded locally.
+type playgroundSavedMsg struct {
+	scope
+	config     *sdk.PlaygroundConfig
+	history    *config.InstructionHistory
+	historyErr error
+}
+
+type configSavedMsg struct{ scope }
+
+// composerLoadedMsg carries the chat input history and drafts of a project.
+type composerLoadedMsg struct {
+	scope
+	projectID string
+	composer  *config.Composer
+	err       error
+}
 
 type lookupResultMsg struct {
+	scope
 	result *sdk.LookupProjectResult
 }
+
+// searchIndexedMsg is sent after a batch of threads has been indexed for
+// full-text search, with the threads still to be indexed.
+// Indexing goes on while threads are opened, so it is not scoped; projectID
+// and gen stop the runs of an earlier project instead.
+type searchIndexedMsg struct {
+	projectID string
+	gen       int
+	index     *search.Index
+	pending   []sdk.Thread
+	savedAt   time.Time // when the run last saved the index
+	err       error
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// errOffline is shown when a change is attempted while browsing cached data.
+var errOffline = errors.New("offline: cached data is read-only; go back and press r to reconnect")
+
+// applyLoad updates the loading state for a list loaded from the local
+// cache (cachedAt set) or from the API, and reports whether it should be
+// shown. Lists render from the cache first and are replaced once the API
+// answers; a cached copy arriving after the fresh one is dropped.
+func (m Model) applyLoad(cachedAt time.Time) (Model, bool) {
+	if !cachedAt.IsZero() {
+		if !m.loading {
+			return m, false
+		}
+		m.loading = false
+		m.refreshing = true
+		m.cachedAt = cachedAt
+		return m, true
+	}
+	// While refreshing, loading belongs to whatever the user did since.
+	if !m.refreshing {
+		m.loading = false
+	}
+	m.refreshing = false
+	m.offline = false
+	m.cachedAt = time.Time{}
+	return m, true
+}
+
+// cacheStatus describes where the data on screen came from, or "" if it
+// is fresh.
+func (m Model) cacheStatus() string {
+	switch {
+	case m.offline && !m.cachedAt.IsZero():
+		return "offline · cached " + formatAge(time.Since(m.cachedAt)) + " · read-only"
+	case m.offline:
+		return "offline"
+	case m.refreshing:
+		return m.spinner.View() + " refreshing"
+	}
+	return ""
+}
+
+// formatAge renders a duration as a rough age, e.g. "5m ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// --- Commands ---
+
+// The cached* commands read the last API response from the client's cache
+// and send nothing if there is none.
+
+func (m Model) cachedProjects() tea.Cmd {
+	return func() tea.Msg {
+		var at time.Time
+		projects, err := m.client.Projects().ListUserProjectsContext(sdk.CacheOnly(context.Background(), &at))
+		if err != nil {
+			return nil
+		}
+		return projectsLoadedMsg{scope: m.scope(), projects: projects, cachedAt: at}
+	}
+}
+
+func (m Model) cachedThreads(projectID string) tea.Cmd {
+	return func() tea.Msg {
+		var at time.Time
+		threads, err := m.client.Threads().ListContext(sdk.CacheOnly(context.Background(), &at), projectID, "")
+		if err != nil {
+			return nil
+		}
+		return threadsLoadedMsg{scope: m.scope(), threads: threads, cachedAt: at}
+	}
+}
+
+func (m Model) cachedEvents(threadID string) tea.Cmd {
+	return func() tea.Msg {
+		var at time.Time
+		events, err := m.client.Threads().GetEventsContext(sdk.CacheOnly(context.Background(), &at), threadID)
+		if err != nil {
+			return nil
+		}
+		return eventsLoadedMsg{scope: m.scope(), events: events, cachedAt: at}
+	}
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/connect"
+)
+
+func newProfileInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "profile name, e.g. staging"
+	ti.CharLimit = 64
+	ti.Width = 40
+	return ti
+}
+
+// openProfiles switches to the profile switcher with the active profile selected.
+func (m Model) openProfiles() (tea.Model, tea.Cmd) {
+	m.view = viewProfiles
+	m.profileCreating = false
+	m.profileCursor = 0
+	for i, name := range m.cfg.ProfileNames() {
+		if name == m.cfg.Name() {
+			m.profileCursor = i
+		}
+	}
+	m.err = nil
+	return m, nil
+}
+
+func (m Model) viewProfiles() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Profiles"))
+	b.WriteString("\n\n")
+
+	for i, name := range m.cfg.ProfileNames() {
+		line := name
+		if name == m.cfg.Name() {
+			line += helpStyle.Render("  (active)")
+		}
+		if i == m.profileCursor {
+			b.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			b.WriteString(normalItemStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.profileCreating {
+		b.WriteString(promptStyle.Render("New profile name") + "\n")
+		b.WriteString("  " + m.profileInput.View() + "\n\n")
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
+	}
+
+	if m.profileCreating {
+		b.WriteString(helpStyle.Render("enter: create & set up  |  esc: cancel"))
+	} else {
+		b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: switch  |  n: new profile  |  esc: back  |  ctrl+c: quit"))
+	}
+	return b.String()
+}
+
+func (m Model) updateProfiles(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.profileCreating {
+		if key.String() == "enter" {
+			name := strings.TrimSpace(m.profileInput.Value())
+			if err := m.cfg.AddProfile(name); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.profileCreating = false
+			m.profileInput.Blur()
+			return m.switchProfile(name)
+		}
+		var cmd tea.Cmd
+		m.profileInput, cmd = m.profileInput.Update(msg)
+		return m, cmd
+	}
+
+	names := m.cfg.ProfileNames()
+	switch key.String() {
+	case "j", "down":
+		if m.profileCursor < len(names)-1 {
+			m.profileCursor++
+		}
+	case "k", "up":
+		if m.profileCursor > 0 {
+			m.profileCursor--
+		}
+	case "enter":
+		return m.switchProfile(names[m.profileCursor])
+	case "n":
+		m.profileCreating = true
+		m.profileInput.SetValue("")
+		m.err = nil
+		return m, m.profileInput.Focus()
+	}
+	return m, nil
+}
+
+// switchProfile activates the named profile and rebuilds the client from
+// its credentials and endpoints. Everything loaded with the previous
+// profile is dropped; a profile without credentials opens the setup view.
+func (m Model) switchProfile(name string) (tea.Model, tea.Cmd) {
+	if err := m.cfg.UseProfile(name); err != nil {
+		m.err = err
+		return m, nil
+	}
+	// Results for the previous profile must not show up in this one.
+	m = m.enterScope()
+
+	m.projects = nil
+	m.projectCursor = 0
+	m.selectedProject = nil
+	m.buildFQDN = ""
+	m.threads = nil
+	m.threadCursor = 0
+	m = m.clearThreadFilter()
+	m.activeThread = nil
+	m.threadID = ""
+	m.messages = nil
+	m.conversation = newConversation()
+	m.prompts = nil
+	m.apiKeys = nil
+	m.promptqlConfig = nil
+	m.playgroundConfig = nil
+	m.instructionHistory = nil
+	m.searchIndex = nil
+	m.searchProject = ""
+	m.searchPending = 0
+	m.searchResults = nil
+	m = m.stopIndexing()
+	m.refreshing = false
+	m.offline = false
+	m.cachedAt = time.Time{}
+	m.notice = ""
+	m.err = nil
+	fillSetupInputs(m.setupInputs, m.cfg)
+
+	if !m.cfg.HasCredentials() {
+		m.client = nil
+		m.loading = false
+		m.view = viewSetup
+		m.setupCursor = 0
+		m.setupInputs[0].Focus()
+		return m, nil
+	}
+	m.client = connect.NewClient(m.cfg)
+	m.view = viewProjects
+	m.loading = true
+	return m, tea.Batch(m.spinner.Tick, m.loadProjects())
+}
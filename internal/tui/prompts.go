@@ -0,0 +1,392 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// promptMode is the state of the sample prompts view.
+type promptMode int
+
+const (
+	promptList promptMode = iota
+	promptEdit
+	promptConfirmDelete
+)
+
+func init() {
+	registerSlashCommand(slashCommand{
+		name: "prompt",
+		args: "<name>",
+		help: "insert a sample prompt",
+		complete: func(m Model) []string {
+			names := make([]string, len(m.prompts))
+			for i, p := range m.prompts {
+				names[i] = p.DisplayText
+			}
+			return names
+		},
+		run: slashPrompt,
+	})
+}
+
+func newPromptInputs() (textinput.Model, textarea.Model) {
+	title := textinput.New()
+	title.Placeholder = "Short text shown in the prompt list"
+	title.CharLimit = 256
+	title.Width = 60
+
+	body := textarea.New()
+	body.Placeholder = "The full prompt sent to PromptQL..."
+	body.CharLimit = 8192
+	body.SetHeight(8)
+	body.ShowLineNumbers = false
+	return title, body
+}
+
+// openPrompts switches to the sample prompts view and loads the project's prompts.
+func (m Model) openPrompts() (tea.Model, tea.Cmd) {
+	if m.client == nil || m.selectedProject == nil {
+		m.err = fmt.Errorf("sample prompts need a PAT and a selected project")
+		return m, nil
+	}
+	m.view = viewPrompts
+	m.promptMode = promptList
+	m.promptCursor = 0
+	m.loading = true
+	m.err = nil
+	return m, tea.Batch(m.spinner.Tick, m.loadPrompts())
+}
+
+func (m Model) viewPrompts() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Sample Prompts"))
+	if m.selectedProject != nil {
+		b.WriteString("  " + subtitleStyle.Render(m.selectedProject.Name))
+	}
+	b.WriteString("\n")
+
+	if m.promptMode == promptEdit {
+		return b.String() + m.viewPromptEditor()
+	}
+
+	if m.loading {
+		b.WriteString(m.spinner.View() + " Loading prompts...  " + helpStyle.Render("ctrl+x: cancel"))
+		return b.String()
+	}
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
+	}
+
+	b.WriteString("\n")
+	if len(m.prompts) == 0 {
+		b.WriteString(helpStyle.Render("  No sample prompts yet. Press n to create one."))
+		b.WriteString("\n")
+	}
+	for i, p := range m.prompts {
+		cursor := "  "
+		style := normalItemStyle
+		if i == m.promptCursor {
+			cursor = "> "
+			style = selectedItemStyle
+		}
+		b.WriteString(style.Render(cursor + p.DisplayText))
+		b.WriteString("\n")
+	}
+
+	if p, ok := m.selectedPrompt(); ok {
+		b.WriteString("\n")
+		preview := strings.Split(p.FullPrompt, "\n")
+		if len(preview) > 4 {
+			preview = append(preview[:4], "…")
+		}
+		b.WriteString(boxStyle.Render(strings.Join(preview, "\n")))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.promptMode == promptConfirmDelete {
+		if p, ok := m.selectedPrompt(); ok {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("Delete %q? y: delete  |  n: cancel", p.DisplayText)))
+		}
+		return b.String()
+	}
+	b.WriteString(helpStyle.Render("↑/↓: navigate  |  enter: start thread  |  n: new  |  e: edit  |  d: delete  |  r: refresh  |  esc: back"))
+	return b.String()
+}
+
+func (m Model) viewPromptEditor() string {
+	var b strings.Builder
+	heading := "New prompt"
+	if m.promptEditID != "" {
+		heading = "Edit prompt"
+	}
+	b.WriteString(subtitleStyle.Render(heading))
+	b.WriteString("\n\n")
+
+	label := func(text string, focused bool) string {
+		if focused {
+			return promptStyle.Render("> " + text)
+		}
+		return helpStyle.Render("  " + text)
+	}
+	b.WriteString(label("Display text", m.promptTitle.Focused()) + "\n")
+	b.WriteString("  " + m.promptTitle.View() + "\n\n")
+	b.WriteString(label("Full prompt", m.promptBody.Focused()) + "\n")
+	b.WriteString(m.promptBody.View() + "\n")
+
+	if m.loading {
+		b.WriteString(m.spinner.View() + " Saving...  " + helpStyle.Render("ctrl+x: cancel") + "\n")
+	} else if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("tab: switch field  |  ctrl+s: save  |  esc: cancel"))
+	return b.String()
+}
+
+func (m Model) updatePrompts(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case promptsLoadedMsg:
+		m.prompts = msg.prompts
+		m.loading = false
+		m.err = nil
+		m.promptCursor = min(m.promptCursor, max(len(m.prompts)-1, 0))
+		return m, nil
+
+	case promptSavedMsg:
+		m.loading = false
+		m.err = nil
+		m.promptMode = promptList
+		replaced := false
+		for i := range m.prompts {
+			if m.prompts[i].ID == msg.prompt.ID {
+				m.prompts[i] = *msg.prompt
+				m.promptCursor = i
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.prompts = append(m.prompts, *msg.prompt)
+			m.promptCursor = len(m.prompts) - 1
+		}
+		return m, nil
+
+	case promptDeletedMsg:
+		m.loading = false
+		m.err = nil
+		for i := range m.prompts {
+			if m.prompts[i].ID == msg.id {
+				m.prompts = append(m.prompts[:i], m.prompts[i+1:]...)
+				break
+			}
+		}
+		m.promptCursor = min(m.promptCursor, max(len(m.prompts)-1, 0))
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+x" && m.canCancel() {
+			m = m.cancelRequest()
+			if m.promptMode == promptList {
+				m.err = errCancelled
+			}
+			return m, nil
+		}
+		if m.loading {
+			return m, nil
+		}
+		switch m.promptMode {
+		case promptEdit:
+			return m.updatePromptEditor(msg)
+		case promptConfirmDelete:
+			switch msg.String() {
+			case "y":
+				m.promptMode = promptList
+				if p, ok := m.selectedPrompt(); ok {
+					m.loading = true
+					return m, tea.Batch(m.spinner.Tick, m.deletePrompt(p.ID))
+				}
+			case "n":
+				m.promptMode = promptList
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "j", "down":
+			if m.promptCursor < len(m.prompts)-1 {
+				m.promptCursor++
+			}
+		case "k", "up":
+			if m.promptCursor > 0 {
+				m.promptCursor--
+			}
+		case "enter":
+			if p, ok := m.selectedPrompt(); ok {
+				return m.startFromPrompt(p)
+			}
+		case "n":
+			return m.editPrompt(nil)
+		case "e":
+			if p, ok := m.selectedPrompt(); ok {
+				return m.editPrompt(&p)
+			}
+		case "d":
+			if _, ok := m.selectedPrompt(); ok {
+				m.promptMode = promptConfirmDelete
+			}
+		case "r":
+			m.loading = true
+			m.err = nil
+			return m, tea.Batch(m.spinner.Tick, m.loadPrompts())
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updatePromptEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "shift+tab":
+		if m.promptTitle.Focused() {
+			m.promptTitle.Blur()
+			return m, m.promptBody.Focus()
+		}
+		m.promptBody.Blur()
+		return m, m.promptTitle.Focus()
+	case "ctrl+s":
+		title := strings.TrimSpace(m.promptTitle.Value())
+		body := strings.TrimSpace(m.promptBody.Value())
+		if title == "" || body == "" {
+			m.err = fmt.Errorf("display text and full prompt are required")
+			return m, nil
+		}
+		m.loading = true
+		m.err = nil
+		return m, tea.Batch(m.spinner.Tick, m.savePrompt(m.promptEditID, title, body))
+	}
+
+	var cmd tea.Cmd
+	if m.promptTitle.Focused() {
+		m.promptTitle, cmd = m.promptTitle.Update(msg)
+	} else {
+		m.promptBody, cmd = m.promptBody.Update(msg)
+	}
+	return m, cmd
+}
+
+// editPrompt opens the editor for p, or for a new prompt if p is nil.
+func (m Model) editPrompt(p *sdk.SamplePrompt) (tea.Model, tea.Cmd) {
+	m.promptMode = promptEdit
+	m.err = nil
+	m.promptEditID = ""
+	m.promptTitle.Reset()
+	m.promptBody.Reset()
+	if p != nil {
+		m.promptEditID = p.ID
+		m.promptTitle.SetValue(p.DisplayText)
+		m.promptBody.SetValue(p.FullPrompt)
+	}
+	m.promptBody.Blur()
+	return m, m.promptTitle.Focus()
+}
+
+// startFromPrompt opens a new thread with the prompt's text ready to send.
+func (m Model) startFromPrompt(p sdk.SamplePrompt) (tea.Model, tea.Cmd) {
+	model, cmd := m.openNewChat()
+	m = model.(Model)
+	return m.setDraft(p.FullPrompt), cmd
+}
+
+// slashPrompt puts the sample prompt called name in the chat input, loading
+// the project's prompts first if needed.
+func slashPrompt(m Model, name string) (tea.Model, tea.Cmd) {
+	if m.client == nil || m.selectedProject == nil {
+		m.err = fmt.Errorf("sample prompts need a PAT and a selected project")
+		return m, nil
+	}
+	if m.prompts == nil {
+		m.promptQuery = name
+		m.notice = "Loading sample prompts..."
+		return m, m.loadPrompts()
+	}
+	return m.insertPrompt(name), nil
+}
+
+// insertPrompt puts the prompt whose name matches query in the chat input.
+// Without a single match, the input lists the prompts to choose from.
+func (m Model) insertPrompt(query string) Model {
+	m.promptQuery = ""
+	m.notice = ""
+	var matches []sdk.SamplePrompt
+	for _, p := range m.prompts {
+		if strings.EqualFold(p.DisplayText, query) {
+			return m.setDraft(p.FullPrompt)
+		}
+		if strings.Contains(strings.ToLower(p.DisplayText), strings.ToLower(query)) {
+			matches = append(matches, p)
+		}
+	}
+	switch {
+	case len(m.prompts) == 0:
+		m.err = fmt.Errorf("this project has no sample prompts")
+		return m
+	case len(matches) == 1:
+		return m.setDraft(matches[0].FullPrompt)
+	case len(matches) == 0:
+		m.err = fmt.Errorf("no sample prompt matches %q", query)
+	}
+	return m.setDraft("/prompt " + query)
+}
+
+func (m Model) selectedPrompt() (sdk.SamplePrompt, bool) {
+	if m.promptCursor < 0 || m.promptCursor >= len(m.prompts) {
+		return sdk.SamplePrompt{}, false
+	}
+	return m.prompts[m.promptCursor], true
+}
+
+// --- Commands ---
+
+func (m Model) loadPrompts() tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		prompts, err := m.client.Prompts().ListContext(ctx, m.selectedProject.ProjectID)
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return promptsLoadedMsg{scope: m.scope(), prompts: prompts}
+	})
+}
+
+func (m Model) savePrompt(id, displayText, fullPrompt string) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		var (
+			prompt *sdk.SamplePrompt
+			err    error
+		)
+		if id == "" {
+			prompt, err = m.client.Prompts().CreateContext(ctx, m.selectedProject.ProjectID, displayText, fullPrompt)
+		} else {
+			prompt, err = m.client.Prompts().UpdateContext(ctx, m.selectedProject.ProjectID, id, displayText, fullPrompt)
+		}
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return promptSavedMsg{scope: m.scope(), prompt: prompt}
+	})
+}
+
+func (m Model) deletePrompt(id string) tea.Cmd {
+	return m.cancelable(func(ctx context.Context) tea.Msg {
+		if _, err := m.client.Prompts().DeleteContext(ctx, m.selectedProject.ProjectID, id); err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return promptDeletedMsg{scope: m.scope(), id: id}
+	})
+}
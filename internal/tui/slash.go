@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSlashSuggestions bounds the completion popup.
+const maxSlashSuggestions = 6
+
+// slashCommand is a command typed in the chat input, such as /export.
+type slashCommand struct {
+	name string // without the slash
+	args string // argument shown in the completion popup, e.g. "<path>"
+	help string
+	// complete returns the arguments to suggest; nil if there are none.
+	complete func(m Model) []string
+	// run carries out the command. The input is cleared before it runs.
+	run func(m Model, arg string) (tea.Model, tea.Cmd)
+}
+
+// slashCommands is the registry of commands, sorted by name.
+var slashCommands []slashCommand
+
+// registerSlashCommand adds a command to the chat input. Features register
+// their commands from init.
+func registerSlashCommand(c slashCommand) {
+	i, found := slices.BinarySearchFunc(slashCommands, c.name, func(c slashCommand, name string) int {
+		return strings.Compare(c.name, name)
+	})
+	if found {
+		panic("slash command registered twice: /" + c.name)
+	}
+	slashCommands = slices.Insert(slashCommands, i, c)
+}
+
+func lookupSlashCommand(name string) (slashCommand, bool) {
+	for _, c := range slashCommands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return slashCommand{}, false
+}
+
+func init() {
+	registerSlashCommand(slashCommand{name: "new", help: "start a new thread", run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		return m.openNewChat()
+	}})
+	registerSlashCommand(slashCommand{name: "clear", help: "clear the messages shown", run: slashClear})
+	registerSlashCommand(slashCommand{name: "retry", help: "send your last message again", run: slashRetry})
+	registerSlashCommand(slashCommand{name: "title", help: "show the thread title", run: slashTitle})
+	registerSlashCommand(slashCommand{name: "tz", args: "<zone>", help: "set the timezone for questions", run: slashTimezone})
+	registerSlashCommand(slashCommand{
+		name:     "visibility",
+		args:     "[visibility]",
+		help:     "show or set the visibility of the new thread",
+		complete: func(m Model) []string { return threadVisibilities(m.threads) },
+		run:      slashVisibility,
+	})
+}
+
+// slashLine returns the chat input if it is a slash command: a single line
+// starting with one slash. "//" sends a message starting with a slash.
+func (m Model) slashLine() (string, bool) {
+	v := m.chatInput.Value()
+	if !strings.HasPrefix(v, "/") || strings.HasPrefix(v, "//") || strings.Contains(v, "\n") {
+		return "", false
+	}
+	return v, true
+}
+
+// slashSuggestion is an entry of the completion popup.
+type slashSuggestion struct {
+	text  string // the input it completes to
+	label string
+	help  string
+}
+
+// slashSuggestions lists the commands whose name starts with what was
+// typed, or once a command is typed, its matching arguments.
+func (m Model) slashSuggestions() []slashSuggestion {
+	line, ok := m.slashLine()
+	if !ok {
+		return nil
+	}
+	name, arg, hasArg := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	var out []slashSuggestion
+	if !hasArg {
+		for _, c := range slashCommands {
+			if strings.HasPrefix(c.name, name) {
+				text := "/" + c.name
+				if c.args != "" {
+					text += " "
+				}
+				out = append(out, slashSuggestion{text: text, label: strings.TrimSpace("/" + c.name + " " + c.args), help: c.help})
+			}
+		}
+		return out
+	}
+	c, ok := lookupSlashCommand(name)
+	if !ok || c.complete == nil {
+		return nil
+	}
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	for _, a := range c.complete(m) {
+		if strings.Contains(strings.ToLower(a), arg) {
+			out = append(out, slashSuggestion{text: "/" + c.name + " " + a, label: a})
+		}
+	}
+	return out
+}
+
+// updateSlash handles the keys of the completion popup while a slash
+// command is typed, reporting whether msg was one of them.
+func (m Model) updateSlash(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	suggestions := m.slashSuggestions()
+	switch key := msg.String(); {
+	case key == "up" && len(suggestions) > 0:
+		m.slashCursor = max(min(m.slashCursor, len(suggestions)-1)-1, 0)
+	case key == "down" && len(suggestions) > 0:
+		m.slashCursor = min(m.slashCursor+1, len(suggestions)-1)
+	case key == "tab" && len(suggestions) > 0:
+		m = m.setDraft(suggestions[min(m.slashCursor, len(suggestions)-1)].text)
+		m.slashCursor = 0
+	case key == "enter" || m.isSendKey(key):
+		next, cmd := m.runSlash(suggestions)
+		return next, cmd, true
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// runSlash runs the command in the input. A partly typed name runs the
+// command selected in the popup.
+func (m Model) runSlash(suggestions []slashSuggestion) (tea.Model, tea.Cmd) {
+	line, _ := m.slashLine()
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	c, ok := lookupSlashCommand(name)
+	if !ok && len(suggestions) > 0 && !strings.Contains(line, " ") {
+		c, ok = lookupSlashCommand(strings.TrimSpace(strings.TrimPrefix(suggestions[min(m.slashCursor, len(suggestions)-1)].text, "/")))
+	}
+	if !ok {
+		m.err = fmt.Errorf("unknown command /%s", name)
+		return m, nil
+	}
+	m = m.setDraft("")
+	m.slashCursor = 0
+	m.recallDepth = 0
+	m.err = nil
+	m.notice = ""
+	return c.run(m, strings.TrimSpace(arg))
+}
+
+// viewSlashSuggestions renders the completion popup.
+func (m Model) viewSlashSuggestions(suggestions []slashSuggestion) string {
+	cursor := min(m.slashCursor, len(suggestions)-1)
+	start := max(cursor-maxSlashSuggestions+1, 0)
+	end := min(start+maxSlashSuggestions, len(suggestions))
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		s := suggestions[i]
+		line := "  " + normalItemStyle.Render(s.label)
+		if i == cursor {
+			line = selectedItemStyle.Render("> " + s.label)
+		}
+		if s.help != "" {
+			line += "  " + helpStyle.Render(s.help)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(helpStyle.Render("↑/↓: select  |  tab: complete  |  enter: run  |  esc: cancel"))
+	return b.String()
+}
+
+// --- Built-in commands ---
+
+func slashClear(m Model, _ string) (tea.Model, tea.Cmd) {
+	m.messages = []ChatMessage{}
+	m.selecting = false
+	m.jumping = false
+	m.chatScrolled = false
+	if m.threadID == "" {
+		m.conversation.Reset()
+	}
+	m.notice = "Cleared"
+	return m, nil
+}
+
+// slashRetry sends the last user message again. A message that got no
+// reply is taken out of the chat first, so it is not shown twice.
+func slashRetry(m Model, _ string) (tea.Model, tea.Cmd) {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role != "user" {
+			continue
+		}
+		text := m.messages[i].Content
+		if i == len(m.messages)-1 {
+			m.messages = m.messages[:i]
+		}
+		return m.setDraft(text).sendMessage()
+	}
+	m.err = fmt.Errorf("nothing to retry")
+	return m, nil
+}
+
+func slashTitle(m Model, arg string) (tea.Model, tea.Cmd) {
+	if arg != "" {
+		m.err = fmt.Errorf("threads cannot be renamed; PromptQL titles them")
+		return m, nil
+	}
+	switch {
+	case m.activeThread == nil:
+		m.notice = "New thread; PromptQL titles it after the first message"
+	case m.activeThread.Title == "":
+		m.notice = "Untitled thread " + m.activeThread.ThreadID
+	default:
+		m.notice = fmt.Sprintf("%s  (thread %s)", m.activeThread.Title, m.activeThread.ThreadID)
+	}
+	return m, nil
+}
+
+// slashTimezone sets the timezone questions are asked in and saves it.
+func slashTimezone(m Model, zone string) (tea.Model, tea.Cmd) {
+	if zone == "" {
+		m.notice = "Timezone: " + m.cfg.Timezone
+		return m, nil
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		m.err = fmt.Errorf("unknown timezone %q", zone)
+		return m, nil
+	}
+	m.cfg.Timezone = zone
+	m.setupInputs[fieldTimezone].SetValue(zone)
+	m.notice = "Timezone set to " + zone
+	return m, m.saveConfig(func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{m.scope(), err}
+		}
+		return nil
+	})
+}
+
+// slashVisibility shows the visibility of the thread, or sets the one the
+// thread being started will get.
+func slashVisibility(m Model, visibility string) (tea.Model, tea.Cmd) {
+	switch {
+	case m.selectedProject == nil:
+		m.err = fmt.Errorf("visibility only applies to threads")
+	case m.activeThread != nil && visibility != "":
+		m.err = fmt.Errorf("the visibility of a thread is set when it starts")
+	case m.activeThread != nil:
+		m.notice = "Visibility: " + cmp.Or(m.activeThread.Visibility, "default")
+	case visibility != "":
+		m.newThreadVisibility = visibility
+		m.notice = "The new thread will be " + visibility
+	default:
+		m.notice = "Visibility of the new thread: " + cmp.Or(m.newThreadVisibility, "default")
+	}
+	return m, nil
+}
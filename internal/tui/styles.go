@@ -44,6 +44,11 @@ var (
 	assistantMsgStyle = lipgloss.NewStyle().
 				Foreground(fgColor)
 
+	// Search matches
+	matchStyle = lipgloss.NewStyle().
+			Foreground(bgColor).
+			Background(secondaryColor)
+
 	// List items
 	selectedItemStyle = lipgloss.NewStyle().
 				Foreground(primaryColor).
@@ -0,0 +1,393 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+const (
+	// maxCellWidth caps a column's width; longer cells are truncated.
+	maxCellWidth = 32
+	// previewRows is the number of rows shown for a table inline in the chat.
+	previewRows = 5
+	// columnGap separates table columns.
+	columnGap = "  "
+)
+
+// artifactTable is a navigable view over a table artifact, used by the
+// chat view's table focus mode.
+type artifactTable struct {
+	title   string
+	columns []string
+	rows    [][]string // formatted cells, in artifact order
+	order   []int      // indices into rows, in display order
+
+	cursorRow int // position in order
+	cursorCol int
+	rowOffset int // first visible position in order
+	colOffset int // first visible column
+
+	sortCol  int // -1 when unsorted
+	sortDesc bool
+	expanded bool
+}
+
+// newArtifactTable builds a table view from a table artifact.
+func newArtifactTable(a sdk.Artifact) (*artifactTable, error) {
+	table, err := a.Table()
+	if err != nil {
+		return nil, err
+	}
+	t := &artifactTable{
+		title:   artifactTitle(a),
+		columns: table.Columns,
+		rows:    table.Strings(),
+		sortCol: -1,
+	}
+	t.resetOrder()
+	return t, nil
+}
+
+func artifactTitle(a sdk.Artifact) string {
+	if a.Title != "" {
+		return a.Title
+	}
+	return a.Identifier
+}
+
+func (t *artifactTable) resetOrder() {
+	t.order = make([]int, len(t.rows))
+	for i := range t.order {
+		t.order[i] = i
+	}
+}
+
+// sortBy cycles the sort on the given column: ascending, descending, unsorted.
+func (t *artifactTable) sortBy(col int) {
+	switch {
+	case t.sortCol != col:
+		t.sortCol, t.sortDesc = col, false
+	case !t.sortDesc:
+		t.sortDesc = true
+	default:
+		t.sortCol = -1
+	}
+
+	t.resetOrder()
+	if t.sortCol >= 0 {
+		sort.SliceStable(t.order, func(i, j int) bool {
+			a, b := t.rows[t.order[i]][col], t.rows[t.order[j]][col]
+			if t.sortDesc {
+				return lessCell(b, a)
+			}
+			return lessCell(a, b)
+		})
+	}
+	t.cursorRow, t.rowOffset = 0, 0
+}
+
+// lessCell orders cells numerically when both parse as numbers, and
+// case-insensitively otherwise. Empty cells sort last.
+func lessCell(a, b string) bool {
+	if a == "" || b == "" {
+		return a != "" && b == ""
+	}
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return fa < fb
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// update handles a key in focus mode, reporting whether it was consumed.
+func (t *artifactTable) update(key string, pageSize int) bool {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	switch key {
+	case "j", "down":
+		t.moveRow(1)
+	case "k", "up":
+		t.moveRow(-1)
+	case "pgdown", "ctrl+d", " ":
+		t.moveRow(pageSize)
+	case "pgup", "ctrl+u":
+		t.moveRow(-pageSize)
+	case "g", "home":
+		t.moveRow(-len(t.order))
+	case "G", "end":
+		t.moveRow(len(t.order))
+	case "l", "right":
+		if t.cursorCol < len(t.columns)-1 {
+			t.cursorCol++
+		}
+	case "h", "left":
+		if t.cursorCol > 0 {
+			t.cursorCol--
+		}
+	case "s":
+		if len(t.columns) > 0 {
+			t.sortBy(t.cursorCol)
+		}
+	case "enter":
+		t.expanded = !t.expanded
+	default:
+		return false
+	}
+	return true
+}
+
+func (t *artifactTable) moveRow(delta int) {
+	t.cursorRow += delta
+	if t.cursorRow >= len(t.order) {
+		t.cursorRow = len(t.order) - 1
+	}
+	if t.cursorRow < 0 {
+		t.cursorRow = 0
+	}
+}
+
+// selectedCell returns the full content of the cell under the cursor.
+func (t *artifactTable) selectedCell() string {
+	if len(t.order) == 0 || len(t.columns) == 0 {
+		return ""
+	}
+	return t.rows[t.order[t.cursorRow]][t.cursorCol]
+}
+
+// columnWidths returns each column's display width, capped at maxCellWidth.
+func (t *artifactTable) columnWidths() []int {
+	widths := make([]int, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = ansi.StringWidth(c)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if w := ansi.StringWidth(firstLine(cell)); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > maxCellWidth {
+			widths[i] = maxCellWidth
+		}
+	}
+	return widths
+}
+
+// bodyHeight is the number of rows shown when the table is rendered in
+// height lines, reserving lines for the title, header, separator and
+// expanded cell.
+func (t *artifactTable) bodyHeight(height int) int {
+	h := height - 3
+	if t.expanded {
+		h -= 4
+	}
+	return max(h, 1)
+}
+
+// layout scrolls the table so the cursor cell is visible when it is
+// rendered within width and height. It must follow every change to the
+// cursor, the sort order or the size, since view does not scroll.
+func (t *artifactTable) layout(width, height int) {
+	rows := t.bodyHeight(height)
+	t.rowOffset = min(t.rowOffset, t.cursorRow)
+	if t.cursorRow >= t.rowOffset+rows {
+		t.rowOffset = t.cursorRow - rows + 1
+	}
+	t.rowOffset = max(t.rowOffset, 0)
+
+	widths := t.columnWidths()
+	t.colOffset = min(t.colOffset, t.cursorCol)
+	for t.colOffset < t.cursorCol && t.cursorCol >= fitColumns(widths, t.colOffset, width) {
+		t.colOffset++
+	}
+	t.colOffset = max(t.colOffset, 0)
+}
+
+// fitColumns returns the end (exclusive) of the columns from start that fit in width.
+func fitColumns(widths []int, start, width int) int {
+	used := 0
+	end := start
+	for end < len(widths) {
+		w := widths[end]
+		if end > start {
+			w += len(columnGap)
+		}
+		if used+w > width && end > start {
+			break
+		}
+		used += w
+		end++
+	}
+	return end
+}
+
+// view renders the table in focus mode within the given dimensions.
+func (t *artifactTable) view(width, height int) string {
+	var b strings.Builder
+
+	sortInfo := ""
+	if t.sortCol >= 0 {
+		dir := "asc"
+		if t.sortDesc {
+			dir = "desc"
+		}
+		sortInfo = fmt.Sprintf("  sorted by %s %s", t.columns[t.sortCol], dir)
+	}
+	b.WriteString(subtitleStyle.Render(t.title))
+	b.WriteString(helpStyle.Render(fmt.Sprintf("  row %d/%d%s", min(t.cursorRow+1, len(t.order)), len(t.order), sortInfo)))
+	b.WriteString("\n")
+
+	if len(t.columns) == 0 {
+		b.WriteString(helpStyle.Render("(empty table)"))
+		return b.String()
+	}
+
+	widths := t.columnWidths()
+	start := min(t.colOffset, len(t.columns)-1)
+	end := fitColumns(widths, start, width)
+	bodyHeight := t.bodyHeight(height)
+
+	header := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		cell := padCell(t.columns[i], widths[i])
+		if i == t.cursorCol {
+			cell = selectedItemStyle.Render(cell)
+		} else {
+			cell = promptStyle.Render(cell)
+		}
+		header = append(header, cell)
+	}
+	b.WriteString(strings.Join(header, columnGap))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(separatorLine(widths[start:end])))
+	b.WriteString("\n")
+
+	for pos := t.rowOffset; pos < len(t.order) && pos < t.rowOffset+bodyHeight; pos++ {
+		row := t.rows[t.order[pos]]
+		cells := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			cell := padCell(row[i], widths[i])
+			if pos == t.cursorRow && i == t.cursorCol {
+				cell = selectedItemStyle.Reverse(true).Render(cell)
+			} else if pos == t.cursorRow {
+				cell = selectedItemStyle.Render(cell)
+			}
+			cells = append(cells, cell)
+		}
+		b.WriteString(strings.Join(cells, columnGap))
+		b.WriteString("\n")
+	}
+
+	if start > 0 || end < len(t.columns) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("columns %d-%d of %d", start+1, end, len(t.columns))))
+		b.WriteString("\n")
+	}
+
+	if t.expanded {
+		b.WriteString("\n")
+		b.WriteString(promptStyle.Render(t.columns[t.cursorCol] + ":"))
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Width(width).Render(t.selectedCell()))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderArtifactPreview renders an artifact inline in the chat: the first
+// few rows of a table, or the text of a text artifact.
+func renderArtifactPreview(a sdk.Artifact, width int) string {
+	switch a.ArtifactType {
+	case sdk.ArtifactTypeTable:
+		t, err := newArtifactTable(a)
+		if err != nil {
+			return errorStyle.Render("Error: " + err.Error())
+		}
+		return t.preview(width)
+	case sdk.ArtifactTypeText:
+		text, err := a.Text()
+		if err != nil {
+			return errorStyle.Render("Error: " + err.Error())
+		}
+		return subtitleStyle.Render(artifactTitle(a)) + "\n" + text
+	}
+	return subtitleStyle.Render(artifactTitle(a)) + helpStyle.Render(fmt.Sprintf("  (%s artifact)", a.ArtifactType))
+}
+
+// preview renders the table's first rows without cursor highlighting.
+func (t *artifactTable) preview(width int) string {
+	var b strings.Builder
+	b.WriteString(subtitleStyle.Render(t.title))
+	b.WriteString(helpStyle.Render(fmt.Sprintf("  %d rows × %d columns", len(t.rows), len(t.columns))))
+	if len(t.columns) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n")
+
+	widths := t.columnWidths()
+	end := fitColumns(widths, 0, width)
+
+	header := make([]string, 0, end)
+	for i := 0; i < end; i++ {
+		header = append(header, promptStyle.Render(padCell(t.columns[i], widths[i])))
+	}
+	b.WriteString(strings.Join(header, columnGap))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(separatorLine(widths[:end])))
+
+	for i := 0; i < len(t.rows) && i < previewRows; i++ {
+		cells := make([]string, 0, end)
+		for j := 0; j < end; j++ {
+			cells = append(cells, padCell(t.rows[i][j], widths[j]))
+		}
+		b.WriteString("\n")
+		b.WriteString(strings.Join(cells, columnGap))
+	}
+
+	var more []string
+	if len(t.rows) > previewRows {
+		more = append(more, fmt.Sprintf("%d more rows", len(t.rows)-previewRows))
+	}
+	if end < len(t.columns) {
+		more = append(more, fmt.Sprintf("%d more columns", len(t.columns)-end))
+	}
+	if len(more) > 0 {
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("… " + strings.Join(more, ", ") + " (ctrl+t to explore)"))
+	}
+	return b.String()
+}
+
+// padCell truncates or pads a cell to exactly width display columns.
+func padCell(s string, width int) string {
+	s = firstLine(s)
+	if ansi.StringWidth(s) > width {
+		return ansi.Truncate(s, width, "…")
+	}
+	return s + strings.Repeat(" ", width-ansi.StringWidth(s))
+}
+
+// firstLine returns s up to its first newline, marking that more follows.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + "…"
+	}
+	return s
+}
+
+func separatorLine(widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strings.Repeat("─", w)
+	}
+	return strings.Join(parts, columnGap)
+}
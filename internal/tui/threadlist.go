@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+)
+
+// threadSort is the order of the threads list.
+type threadSort int
+
+const (
+	sortUpdated threadSort = iota // most recently updated first
+	sortCreated                   // most recently created first
+	sortTitle                     // alphabetical
+	threadSortCount
+)
+
+func (s threadSort) String() string {
+	switch s {
+	case sortCreated:
+		return "created"
+	case sortTitle:
+		return "title"
+	}
+	return "updated"
+}
+
+// threadRange limits the threads list to recent activity.
+type threadRange int
+
+const (
+	rangeAll threadRange = iota
+	rangeToday
+	rangeWeek
+	rangeMonth
+	threadRangeCount
+)
+
+func (r threadRange) String() string {
+	switch r {
+	case rangeToday:
+		return "today"
+	case rangeWeek:
+		return "last 7 days"
+	case rangeMonth:
+		return "last 30 days"
+	}
+	return "any time"
+}
+
+// since returns the earliest time included by the range, or the zero time
+// for rangeAll.
+func (r threadRange) since(now time.Time) time.Time {
+	today := startOfDay(now)
+	switch r {
+	case rangeToday:
+		return today
+	case rangeWeek:
+		return today.AddDate(0, 0, -6)
+	case rangeMonth:
+		return today.AddDate(0, 0, -29)
+	}
+	return time.Time{}
+}
+
+// threadFilter is the search, filters and sort order of the threads list.
+type threadFilter struct {
+	query      string
+	dateRange  threadRange
+	visibility string // "" shows every visibility
+	sort       threadSort
+}
+
+// filtered reports whether any threads may be hidden by the filter.
+func (f threadFilter) filtered() bool {
+	return f.query != "" || f.dateRange != rangeAll || f.visibility != ""
+}
+
+func newThreadSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "search thread titles"
+	ti.Prompt = "/ "
+	ti.CharLimit = 128
+	ti.Width = 40
+	return ti
+}
+
+// visibleThreads returns the loaded threads that match the filter, in the
+// selected order. Threads that compare equal keep the server's order.
+func (m Model) visibleThreads() []sdk.Thread {
+	f := m.threadFilter
+	since := f.dateRange.since(time.Now())
+	var out []sdk.Thread
+	for _, t := range m.threads {
+		if f.visibility != "" && t.Visibility != f.visibility {
+			continue
+		}
+		if !since.IsZero() && threadTime(t, f.sort).Before(since) {
+			continue
+		}
+		if f.query != "" && !fuzzyMatch(f.query, threadTitle(t)) {
+			continue
+		}
+		out = append(out, t)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if f.sort == sortTitle {
+			return strings.ToLower(threadTitle(out[i])) < strings.ToLower(threadTitle(out[j]))
+		}
+		return threadTime(out[i], f.sort).After(threadTime(out[j], f.sort))
+	})
+	return out
+}
+
+// threadRow is a line of the threads list: a group heading, or the thread
+// at cursor position cursor (0 is "New Thread").
+type threadRow struct {
+	heading string
+	cursor  int
+	thread  *sdk.Thread
+}
+
+// threadRows lays out the list. Threads sorted by date are grouped under
+// Today, Yesterday, Last 7 days and Older.
+func threadRows(threads []sdk.Thread, order threadSort, now time.Time) []threadRow {
+	rows := []threadRow{{cursor: 0}}
+	group := ""
+	for i := range threads {
+		if order != sortTitle {
+			if g := threadGroup(threadTime(threads[i], order), now); g != group {
+				group = g
+				rows = append(rows, threadRow{heading: g})
+			}
+		}
+		rows = append(rows, threadRow{cursor: i + 1, thread: &threads[i]})
+	}
+	return rows
+}
+
+// threadGroup names the date group a thread with time t belongs to.
+func threadGroup(t, now time.Time) string {
+	today := startOfDay(now)
+	switch {
+	case t.IsZero():
+		return "Older"
+	case !t.Before(today):
+		return "Today"
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return "Last 7 days"
+	}
+	return "Older"
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+// threadTime is the time a thread is sorted and grouped by: its creation
+// time when sorting by created, otherwise its last update.
+func threadTime(t sdk.Thread, order threadSort) time.Time {
+	if order == sortCreated || t.UpdatedAt == "" {
+		return parseThreadTime(t.CreatedAt)
+	}
+	return parseThreadTime(t.UpdatedAt)
+}
+
+// parseThreadTime parses a thread timestamp in local time, returning the
+// zero time if it is missing or malformed.
+func parseThreadTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Local()
+		}
+	}
+	return time.Time{}
+}
+
+func threadTitle(t sdk.Thread) string {
+	if t.Title == "" {
+		return t.ThreadID
+	}
+	return t.Title
+}
+
+// fuzzyMatch reports whether every character of query appears in s in
+// order, ignoring case and spaces in the query.
+func fuzzyMatch(query, s string) bool {
+	target := []rune(strings.ToLower(s))
+	i := 0
+	for _, q := range strings.ToLower(query) {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		for i < len(target) && target[i] != q {
+			i++
+		}
+		if i == len(target) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// threadVisibilities returns the distinct visibilities of the threads, sorted.
+func threadVisibilities(threads []sdk.Thread) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range threads {
+		if t.Visibility != "" && !seen[t.Visibility] {
+			seen[t.Visibility] = true
+			out = append(out, t.Visibility)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// nextVisibility cycles the visibility filter through "" (all) and the
+// visibilities of the loaded threads.
+func nextVisibility(current string, threads []sdk.Thread) string {
+	options := append([]string{""}, threadVisibilities(threads)...)
+	for i, v := range options {
+		if v == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return ""
+}
+
+// describeThreadFilter summarises the list for the subtitle, e.g.
+// "12 of 340 threads  ·  sorted by title  ·  last 7 days".
+func describeThreadFilter(f threadFilter, shown, total int) string {
+	parts := []string{fmt.Sprintf("%d threads", total)}
+	if f.filtered() {
+		parts[0] = fmt.Sprintf("%d of %d threads", shown, total)
+	}
+	parts = append(parts, "sorted by "+f.sort.String())
+	if f.dateRange != rangeAll {
+		parts = append(parts, f.dateRange.String())
+	}
+	if f.visibility != "" {
+		parts = append(parts, "visibility: "+f.visibility)
+	}
+	if f.query != "" {
+		parts = append(parts, fmt.Sprintf("matching %q", f.query))
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// formatThreadTime renders a thread timestamp for the list, falling back to
+// the raw value if it cannot be parsed.
+func formatThreadTime(raw string) string {
+	if t := parseThreadTime(raw); !t.IsZero() {
+		return t.Format("2006-01-02 15:04")
+	}
+	return raw[:min(19, len(raw))]
+}
@@ -0,0 +1,325 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandalsoft/promptql-tui/internal/config"
+	"github.com/sandalsoft/promptql-tui/internal/sdk"
+	"github.com/sandalsoft/promptql-tui/internal/search"
+)
+
+const (
+	// indexBatchSize is how many threads are fetched per indexing step.
+	indexBatchSize = 5
+	// indexSaveInterval is how often a long indexing run saves its progress.
+	indexSaveInterval = 30 * time.Second
+	// searchResultLimit caps the number of results shown.
+	searchResultLimit = 100
+)
+
+func newSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "search messages in every thread"
+	ti.CharLimit = 256
+	ti.Width = 50
+	return ti
+}
+
+// openSearch switches to the full-text search view.
+func (m Model) openSearch() (tea.Model, tea.Cmd) {
+	m.view = viewSearch
+	m.err = nil
+	m = m.runSearch()
+	return m, m.searchInput.Focus()
+}
+
+// runSearch refreshes the results for the current query.
+func (m Model) runSearch() Model {
+	m.searchResults = nil
+	if m.searchIndex != nil {
+		m.searchResults = m.searchIndex.Search(m.searchInput.Value(), searchResultLimit)
+	}
+	m.searchCursor = min(m.searchCursor, max(len(m.searchResults)-1, 0))
+	return m
+}
+
+func (m Model) viewSearch() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Search"))
+	if m.selectedProject != nil {
+		b.WriteString("  " + subtitleStyle.Render(m.selectedProject.Name))
+	}
+	b.WriteString("\n")
+	b.WriteString(m.searchInput.View() + "\n")
+
+	status := "Index not loaded yet"
+	if m.searchIndex != nil {
+		status = fmt.Sprintf("%d threads indexed", m.searchIndex.Len())
+	}
+	if m.searchPending > 0 {
+		status += fmt.Sprintf(", indexing %d more...", m.searchPending)
+	}
+	b.WriteString(helpStyle.Render(status) + "\n")
+	if m.searchErr != nil {
+		b.WriteString(errorStyle.Render("Indexing error: "+m.searchErr.Error()) + "\n")
+	}
+	b.WriteString("\n")
+
+	query := strings.TrimSpace(m.searchInput.Value())
+	switch {
+	case query == "":
+		b.WriteString(helpStyle.Render("Type to search the messages of this project's threads.") + "\n")
+	case len(m.searchResults) == 0:
+		b.WriteString(helpStyle.Render("No matches.") + "\n")
+	default:
+		// Each result takes two lines plus a blank one.
+		perPage := max((m.height-9)/3, 2)
+		start := max(min(m.searchCursor-perPage/2, len(m.searchResults)-perPage), 0)
+		end := min(start+perPage, len(m.searchResults))
+		terms := search.Terms(query)
+		width := max(m.width-6, 40)
+		for i := start; i < end; i++ {
+			r := m.searchResults[i]
+			title := r.ThreadTitle
+			if title == "" {
+				title = r.ThreadID
+			}
+			role := "PromptQL"
+			if r.Role == "user" {
+				role = "You"
+			}
+			cursor, style := "  ", normalItemStyle
+			if i == m.searchCursor {
+				cursor, style = "> ", selectedItemStyle
+			}
+			b.WriteString(style.Render(cursor+truncate(title, width-12)) + helpStyle.Render("  ("+role+")"))
+			b.WriteString("\n    " + highlightTerms(truncate(r.Snippet, width), terms) + "\n\n")
+		}
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d of %d matches", m.searchCursor+1, len(m.searchResults))) + "\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("type to search  |  ↑/↓: navigate  |  enter: open at match  |  esc: back  |  ctrl+c: quit"))
+	return b.String()
+}
+
+func (m Model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "down":
+		if m.searchCursor < len(m.searchResults)-1 {
+			m.searchCursor++
+		}
+		return m, nil
+	case "up":
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+		return m, nil
+	case "enter":
+		if len(m.searchResults) == 0 {
+			return m, nil
+		}
+		return m.openSearchResult(m.searchResults[m.searchCursor])
+	}
+	before := m.searchInput.Value()
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != before {
+		m.searchCursor = 0
+		m = m.runSearch()
+	}
+	return m, cmd
+}
+
+// openSearchResult resumes the result's thread with the chat scrolled to
+// the matching message.
+func (m Model) openSearchResult(r search.Result) (tea.Model, tea.Cmd) {
+	t := sdk.Thread{ThreadID: r.ThreadID, Title: r.ThreadTitle}
+	for _, th := range m.threads {
+		if th.ThreadID == r.ThreadID {
+			t = th
+		}
+	}
+	m.searchInput.Blur()
+	next, cmd := m.resumeThread(t)
+	model := next.(Model)
+	model.jumping = true
+	model.jumpMessage = r.Message
+	model.jumpTerms = search.Terms(m.searchInput.Value())
+	return model, cmd
+}
+
+// highlightTerms marks every case-insensitive occurrence of the terms in
+// text.
+func highlightTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) || len(terms) == 0 {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		n := 0
+		for _, t := range terms {
+			if t != "" && strings.HasPrefix(lower[i:], t) && len(t) > n {
+				n = len(t)
+			}
+		}
+		if n == 0 {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		b.WriteString(matchStyle.Render(text[i : i+n]))
+		i += n
+	}
+	return b.String()
+}
+
+// eventMessages converts thread events into chat messages the same way the
+// chat view does, so indexed message positions match the chat.
+func eventMessages(events []sdk.ThreadEvent) []ChatMessage {
+	var tmp Model
+	for _, evt := range events {
+		tmp.appendEvent(evt.ThreadEventID, evt.Event())
+	}
+	return tmp.messages
+}
+
+// searchThread converts a thread and its chat messages for the index.
+func searchThread(t sdk.Thread, messages []ChatMessage) search.Thread {
+	st := search.Thread{ID: t.ThreadID, Title: t.Title, UpdatedAt: t.UpdatedAt}
+	for _, msg := range messages {
+		text := msg.Content
+		for _, a := range msg.Artifacts {
+			text += "\n" + a.Title
+		}
+		st.Messages = append(st.Messages, search.Message{Role: msg.Role, Text: text})
+	}
+	return st
+}
+
+// indexOpenThread updates the index with the messages of the thread open
+// in the chat and saves it in the background.
+func (m Model) indexOpenThread() tea.Cmd {
+	if m.searchIndex == nil || m.activeThread == nil || m.selectedProject == nil || m.searchProject != m.selectedProject.ProjectID {
+		return nil
+	}
+	ix := m.searchIndex
+	ix.Update(searchThread(*m.activeThread, m.messages))
+	return func() tea.Msg {
+		_ = ix.Save()
+		return nil
+	}
+}
+
+// --- Commands ---
+
+// indexThreads brings the project's search index up to date with the
+// loaded threads. It stops the previous run, opens the index if needed,
+// drops deleted threads and indexes the first batch of new or updated
+// ones; each searchIndexedMsg schedules the next batch.
+func (m Model) indexThreads(threads []sdk.Thread) (Model, tea.Cmd) {
+	m = m.stopIndexing()
+	if m.client == nil || m.selectedProject == nil {
+		return m, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.indexCtx, m.indexCancel = ctx, cancel
+	client, projectID, gen := m.client, m.selectedProject.ProjectID, m.indexGen
+	ix := m.searchIndex
+	if m.searchProject != projectID {
+		ix = nil
+	}
+	return m, func() tea.Msg {
+		if ix == nil {
+			path, err := config.SearchIndexPath(projectID)
+			if err == nil {
+				ix, err = search.Open(path)
+			}
+			if err != nil {
+				return searchIndexedMsg{projectID: projectID, gen: gen, err: err}
+			}
+		}
+		ids := make([]string, len(threads))
+		var stale []sdk.Thread
+		for i, t := range threads {
+			ids[i] = t.ThreadID
+			if ix.Stale(t.ThreadID, t.UpdatedAt) {
+				stale = append(stale, t)
+			}
+		}
+		ix.Retain(ids)
+		return indexBatch(ctx, client, ix, projectID, gen, stale, time.Now())
+	}
+}
+
+// stopIndexing aborts the indexing run in progress; the index keeps the
+// threads it got to. Its remaining results are dropped.
+func (m Model) stopIndexing() Model {
+	if m.indexCancel != nil {
+		m.indexCancel()
+	}
+	m.indexCtx, m.indexCancel = nil, nil
+	m.indexGen++
+	return m
+}
+
+// indexBatch fetches and indexes the next few pending threads. The index
+// is saved once the run ends or is stopped, and every indexSaveInterval
+// since savedAt meanwhile. Threads whose events cannot be fetched stay
+// stale and are retried the next time the threads are loaded.
+func indexBatch(ctx context.Context, client *sdk.Client, ix *search.Index, projectID string, gen int, pending []sdk.Thread, savedAt time.Time) searchIndexedMsg {
+	msg := searchIndexedMsg{projectID: projectID, gen: gen, index: ix, savedAt: savedAt}
+	n := min(indexBatchSize, len(pending))
+	for _, t := range pending[:n] {
+		events, err := client.Threads().GetEventsContext(ctx, t.ThreadID)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			msg.err = err
+			continue
+		}
+		ix.Update(searchThread(t, eventMessages(events)))
+	}
+	msg.pending = pending[n:]
+	if len(msg.pending) == 0 || ctx.Err() != nil || time.Since(savedAt) >= indexSaveInterval {
+		if err := ix.Save(); err != nil {
+			msg.err = err
+		}
+		msg.savedAt = time.Now()
+	}
+	return msg
+}
+
+// handleSearchIndexed stores the index and continues with the next batch,
+// unless the project changed or the threads were reloaded since.
+func (m Model) handleSearchIndexed(msg searchIndexedMsg) (tea.Model, tea.Cmd) {
+	if m.selectedProject == nil || msg.projectID != m.selectedProject.ProjectID || msg.gen != m.indexGen {
+		return m, nil
+	}
+	m.searchErr = msg.err
+	if msg.index != nil {
+		m.searchIndex = msg.index
+		m.searchProject = msg.projectID
+	}
+	m.searchPending = len(msg.pending)
+	if m.view == viewSearch {
+		m = m.runSearch()
+	}
+	if len(msg.pending) == 0 || msg.index == nil {
+		return m, nil
+	}
+	ctx, client, ix, gen := m.indexCtx, m.client, msg.index, msg.gen
+	return m, func() tea.Msg {
+		return indexBatch(ctx, client, ix, msg.projectID, gen, msg.pending, msg.savedAt)
+	}
+}